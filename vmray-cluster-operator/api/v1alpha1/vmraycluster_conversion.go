@@ -0,0 +1,8 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+// Hub marks v1alpha1 as the version other versions of VMRayCluster are converted
+// through, it's also the version clusters are stored in & controllers work with.
+func (*VMRayCluster) Hub() {}
@@ -0,0 +1,63 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	vmopv1 "github.com/vmware-tanzu/vm-operator/api/v1alpha2"
+	apiresource "k8s.io/apimachinery/pkg/api/resource"
+)
+
+// HardwareResources returns the resources provided by hardware of a VM class, GPUs
+// being its vGPU & passthrough devices. CPUs & memory are left unset for hardware
+// without them, e.g. of a VM class lacking its spec, while GPUs are always set as a
+// VM class without devices provides none.
+func HardwareResources(hardware vmopv1.VirtualMachineClassHardware) NodeResource {
+	resources := NodeResource{}
+	if hardware.Cpus > 0 {
+		resources.CPU = apiresource.NewQuantity(hardware.Cpus, apiresource.DecimalSI)
+	}
+	if hardware.Memory.Sign() > 0 {
+		memory := hardware.Memory.DeepCopy()
+		resources.Memory = &memory
+	}
+	gpus := int64(len(hardware.Devices.VGPUDevices) + len(hardware.Devices.DynamicDirectPathIODevices))
+	resources.GPU = apiresource.NewQuantity(gpus, apiresource.DecimalSI)
+	return resources
+}
+
+// DefaultFrom returns the resources with unset cpu & gpu taken from provided, e.g.
+// the HardwareResources of the node type's VM class. Memory isn't taken, as ray would
+// schedule tasks on all of the VM's memory, leaving none to the OS & ray itself.
+func (r NodeResource) DefaultFrom(provided NodeResource) NodeResource {
+	if r.CPU == nil && provided.CPU != nil {
+		cpu := provided.CPU.DeepCopy()
+		r.CPU = &cpu
+	}
+	if r.GPU == nil && provided.HasGPU() {
+		gpu := provided.GPU.DeepCopy()
+		r.GPU = &gpu
+	}
+	return r
+}
+
+// ExceededBy describes the resources which exceed the provided ones, e.g. "cpu 8 > 4".
+// Resources which aren't provided aren't compared.
+func (r NodeResource) ExceededBy(provided NodeResource) []string {
+	exceeded := []string{}
+	for _, resource := range []struct {
+		name             string
+		value, available *apiresource.Quantity
+	}{
+		{"cpu", r.CPU, provided.CPU},
+		{"memory", r.Memory, provided.Memory},
+		{"gpu", r.GPU, provided.GPU},
+	} {
+		if resource.value != nil && resource.available != nil && resource.value.Cmp(*resource.available) > 0 {
+			exceeded = append(exceeded, fmt.Sprintf("%s %s > %s", resource.name, resource.value.String(), resource.available.String()))
+		}
+	}
+	return exceeded
+}
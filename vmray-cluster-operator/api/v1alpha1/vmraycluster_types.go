@@ -5,6 +5,7 @@ package v1alpha1
 
 import (
 	vmopv1 "github.com/vmware-tanzu/vm-operator/api/v1alpha2"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -14,11 +15,15 @@ const (
 	VMRayClusterConditionHeadNodeReady   = "HeadNodeReady"
 	VMRayClusterConditionWorkerNodeReady = "WorkerNodeReady"
 	VMRayClusterConditionClusterDelete   = "DeleteCluster"
+	VMRayClusterConditionClusterIdle     = "ClusterIdle"
+	VMRayClusterConditionClusterSuspend  = "SuspendCluster"
+	VMRayClusterConditionAdoptVms        = "AdoptVms"
 
 	// Conditions which could be observed by  reconciler.
 	NodeConfigInvalidVMI          = "InvalidVirtualMachineImage"
 	NodeConfigInvalidStorageClass = "InvalidStorageClass"
 	NodeConfigInvalidVMClass      = "InvalidVirtualMachineClass"
+	NodeConfigInvalidResources    = "InvalidNodeResources"
 
 	// List of reasons for the observed conditions.
 	FailureToDeployNodeReason               = "FailureToDeployNode"
@@ -26,6 +31,36 @@ const (
 	FailureToDeleteHeadNodeReason           = "FailureToDeleteHeadNode"
 	FailureToDeleteWorkerNodeReason         = "FailureToDeleteWorkerNode"
 	ResourceNotFoundReason                  = "ResourceNotFound"
+	FailureToSuspendClusterReason           = "FailureToSuspendCluster"
+	ClusterIdleReason                       = "ClusterIdle"
+	ClusterExpiredReason                    = "ClusterExpired"
+	ClusterSuspendedReason                  = "ClusterSuspended"
+	FailureToAdoptVmsReason                 = "FailureToAdoptVms"
+	VmsAdoptedReason                        = "VmsAdopted"
+	ResourcesExceedVMClassReason            = "ResourcesExceedVirtualMachineClass"
+
+	// ProtectedAnnotation exempts a cluster from being suspended
+	// or deleted by the idle & expiry reclaim policies.
+	ProtectedAnnotation = "vmray.kubernetes.io/protected"
+
+	// Annotations requesting the cluster to adopt existing VMs, e.g. deployed by
+	// `ray up`, instead of deploying new ones. AdoptHeadAnnotation & AdoptHeadServiceAnnotation
+	// name the head VM & its VM service, workers are named by a comma separated list
+	// in AdoptWorkersAnnotation or selected by a label selector in AdoptWorkerSelectorAnnotation.
+	AdoptHeadAnnotation           = "vmray.kubernetes.io/adopt-head"
+	AdoptHeadServiceAnnotation    = "vmray.kubernetes.io/adopt-head-service"
+	AdoptWorkersAnnotation        = "vmray.kubernetes.io/adopt-workers"
+	AdoptWorkerSelectorAnnotation = "vmray.kubernetes.io/adopt-worker-selector"
+)
+
+// ReclaimAction describes what the operator does with a
+// cluster once its idle or expiry policy is triggered.
+// +kubebuilder:validation:Enum=suspend;delete
+type ReclaimAction string
+
+const (
+	ReclaimActionSuspend ReclaimAction = "suspend"
+	ReclaimActionDelete  ReclaimAction = "delete"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -54,8 +89,61 @@ type VMRayClusterSpec struct {
 	EnableTLS bool `json:"enable_tls"`
 	// This defines node's docker's configuration, such as authentication details with registry.
 	DockerConfig DockerRegistryConfig `json:"docker_config,omitempty"`
+	// Number of seconds the whole cluster may stay idle, i.e. no running jobs and
+	// no client connections as reported by ray dashboard, before it is reclaimed.
+	// +optional
+	TTLSecondsAfterIdle *int32 `json:"ttl_seconds_after_idle,omitempty"`
+	// Absolute time after which the cluster is reclaimed regardless of its activity.
+	// +optional
+	ExpiresAt *metav1.Time `json:"expires_at,omitempty"`
+	// Action performed once ttl_seconds_after_idle or expires_at is reached.
+	// +kubebuilder:default=suspend
+	// +optional
+	ReclaimAction ReclaimAction `json:"reclaim_action,omitempty"`
+	// When set, all ray nodes of the cluster are torn down while the cluster
+	// object & its secrets are retained. Unset it to bring the cluster back.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+	// Defines how ray nodes are replaced when their configuration, i.e. ray_docker_image,
+	// vm_class of their node type or setup commands, is changed on a live cluster.
+	// +optional
+	UpgradeStrategy UpgradeStrategy `json:"upgrade_strategy,omitempty"`
 }
 
+type UpgradeStrategy struct {
+	// Maximum number of outdated workers deleted before their replacement is ready. Defaults to 1.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxUnavailable *int32 `json:"max_unavailable,omitempty"`
+	// Maximum number of replacement workers created above the desired number of workers. Defaults to 1.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxSurge *int32 `json:"max_surge,omitempty"`
+	// Replace the head node once all workers are upgraded, the
+	// cluster is unavailable while its head node is being replaced.
+	// +optional
+	UpgradeHead bool `json:"upgrade_head,omitempty"`
+	// How workers are upgraded when only vm_class of their node type changed. With InPlace
+	// the worker's VM is powered off, switched to the new VM class & powered back on.
+	// +kubebuilder:default=Replace
+	// +optional
+	ResizePolicy ResizePolicy `json:"resize_policy,omitempty"`
+}
+
+const (
+	DefaultMaxUnavailable int32 = 1
+	DefaultMaxSurge       int32 = 1
+)
+
+// ResizePolicy describes how a worker is upgraded when only its VM class changes.
+// +kubebuilder:validation:Enum=Replace;InPlace
+type ResizePolicy string
+
+const (
+	ResizePolicyReplace ResizePolicy = "Replace"
+	ResizePolicyInPlace ResizePolicy = "InPlace"
+)
+
 type VMNodeStatus string
 type RayProcessStatus string
 
@@ -92,6 +180,161 @@ type VMRayNodeStatus struct {
 	VmStatus VMNodeStatus `json:"vm_status,omitempty"`
 	// This will define & track ray process status.
 	RayStatus RayProcessStatus `json:"ray_status,omitempty"`
+	// Name of the node type, from available_node_types, the node is deployed as.
+	NodeType string `json:"node_type,omitempty"`
+	// VM class the node's VirtualMachine is deployed with.
+	VMClass string `json:"vm_class,omitempty"`
+	// Time at which node's VirtualMachine was requested.
+	CreationTime *metav1.Time `json:"creation_time,omitempty"`
+	// Time at which node's VirtualMachine got its IP assigned.
+	ReadyTime *metav1.Time `json:"ready_time,omitempty"`
+	// ID of the ray node running on the VM, as reported by ray dashboard.
+	RayNodeID string `json:"ray_node_id,omitempty"`
+	// State of the ray node as reported by ray dashboard i.e. ALIVE or DEAD.
+	RayNodeState RayNodeState `json:"ray_node_state,omitempty"`
+	// Reason reported by ray for the ray node's state, e.g. why it is dead.
+	RayNodeStateMessage string `json:"ray_node_state_message,omitempty"`
+	// Total resources of the ray node as reported by ray dashboard, e.g. CPU: "4".
+	RayResources map[string]string `json:"ray_resources,omitempty"`
+	// Progress of draining the ray node before its VM is deleted.
+	// +optional
+	Drain *VMRayNodeDrainStatus `json:"drain,omitempty"`
+	// Hash of the configuration node's VirtualMachine was deployed with.
+	ConfigHash string `json:"config_hash,omitempty"`
+	// Progress of resizing node's VirtualMachine in place to a new VM class.
+	// +optional
+	Resize *VMRayNodeResizeStatus `json:"resize,omitempty"`
+	// Readiness of the node's GPUs, set for nodes of node types with GPUs only.
+	// +optional
+	GPU *VMRayNodeGPUStatus `json:"gpu,omitempty"`
+	// vSphere zone node's VirtualMachine is deployed to.
+	// +optional
+	Zone string `json:"zone,omitempty"`
+	// Host node's VirtualMachine runs on, as reported by vm-operator.
+	// +optional
+	Host string `json:"host,omitempty"`
+	// Set on adopted workers till the autoscaler lists them in its desired workers,
+	// adopted workers aren't deleted for missing from desired workers till then.
+	// +optional
+	Adopted bool `json:"adopted,omitempty"`
+}
+
+type NodeGPUState string
+
+const (
+	// NVIDIA driver & container toolkit are being set up or ray hasn't started yet.
+	NODE_GPU_PENDING NodeGPUState = "pending"
+	// Ray runs in a container with access to the node's GPUs.
+	NODE_GPU_READY NodeGPUState = "ready"
+	// Ray didn't start with access to the node's GPUs within the GPU setup timeout.
+	NODE_GPU_NOT_READY NodeGPUState = "not_ready"
+)
+
+// VMRayNodeGPUStatus captures readiness of a ray node's GPUs. Ray's container is run with
+// access to all GPUs of the VM, which requires the NVIDIA driver to be loaded & the devices
+// to be visible, so GPUs are ready once ray runs on the node.
+type VMRayNodeGPUStatus struct {
+	// State of the node's GPUs.
+	State NodeGPUState `json:"state,omitempty"`
+	// Number of GPUs ray reports for the node.
+	Devices int32 `json:"devices,omitempty"`
+	// Details of why the node's GPUs aren't ready, if any.
+	Message string `json:"message,omitempty"`
+}
+
+type NodeResizeState string
+
+const (
+	// VM is being powered off, switched to the new VM class & powered back on.
+	NODE_RESIZING NodeResizeState = "resizing"
+	// Ray is being restarted on the resized VM to advertise its new resources.
+	NODE_RAY_RESTARTING NodeResizeState = "restarting_ray"
+)
+
+// VMRayNodeResizeStatus captures progress of resizing a ray node's VM in place.
+type VMRayNodeResizeStatus struct {
+	// State of the resize.
+	State NodeResizeState `json:"state,omitempty"`
+	// VM class the node's VirtualMachine is being resized to.
+	VMClass string `json:"vm_class,omitempty"`
+	// Time at which resize of the node started.
+	StartTime *metav1.Time `json:"start_time,omitempty"`
+	// Details of the last error observed while resizing the node, if any.
+	Message string `json:"message,omitempty"`
+}
+
+type NodeDrainState string
+
+const (
+	// Ray is asked to drain the node and operator waits for its running tasks to finish.
+	NODE_DRAINING NodeDrainState = "draining"
+	// Node has no running tasks and its VM can be deleted.
+	NODE_DRAINED NodeDrainState = "drained"
+	// Grace period elapsed before node finished its running tasks.
+	NODE_DRAIN_TIMEOUT NodeDrainState = "timeout"
+)
+
+// VMRayNodeDrainStatus captures progress of draining a ray node.
+type VMRayNodeDrainStatus struct {
+	// State of the drain.
+	State NodeDrainState `json:"state,omitempty"`
+	// Time at which draining of the node started.
+	StartTime *metav1.Time `json:"start_time,omitempty"`
+	// Number of tasks still running on the node when it was last checked.
+	RunningTasks int32 `json:"running_tasks,omitempty"`
+	// Details of the last error observed while draining the node, if any.
+	Message string `json:"message,omitempty"`
+}
+
+// RayNodeState mirrors ray's node state as reported by ray dashboard.
+type RayNodeState string
+
+const (
+	RayNodeAlive RayNodeState = "ALIVE"
+	RayNodeDead  RayNodeState = "DEAD"
+)
+
+type UpgradeState string
+
+const (
+	// Outdated workers are being replaced.
+	UPGRADE_WORKERS UpgradeState = "upgrading_workers"
+	// Workers are upgraded, head node is outdated and upgrade_head isn't set.
+	UPGRADE_HEAD_PENDING UpgradeState = "head_pending"
+	// Head node is being replaced.
+	UPGRADE_HEAD UpgradeState = "upgrading_head"
+)
+
+// VMRayWorkerReplacement tracks replacement of an outdated worker.
+type VMRayWorkerReplacement struct {
+	// Name of the outdated worker being replaced.
+	Worker string `json:"worker"`
+	// Node type of the outdated worker.
+	NodeType string `json:"node_type"`
+	// Set once VM of the outdated worker is being deleted, the worker is
+	// re-deployed under its name with the current configuration once it's gone.
+	// +optional
+	Recreating bool `json:"recreating,omitempty"`
+}
+
+// VMRayClusterUpgradeStatus captures progress of replacing ray nodes whose configuration changed.
+type VMRayClusterUpgradeStatus struct {
+	// State of the upgrade.
+	State UpgradeState `json:"state,omitempty"`
+	// Time at which configuration drift was first detected.
+	StartTime *metav1.Time `json:"start_time,omitempty"`
+	// Number of desired workers running with the current configuration.
+	UpdatedWorkers int32 `json:"updated_workers"`
+	// Number of desired workers running with an outdated configuration.
+	OutdatedWorkers int32 `json:"outdated_workers"`
+	// Number of workers being resized in place.
+	ResizingWorkers int32 `json:"resizing_workers,omitempty"`
+	// Whether the head node runs with an outdated configuration.
+	HeadOutdated bool `json:"head_outdated,omitempty"`
+	// Outdated workers being replaced, keyed by name of the surge worker standing
+	// in for them while they're re-deployed, or by their own name without surge.
+	// +optional
+	Replacements map[string]VMRayWorkerReplacement `json:"replacements,omitempty"`
 }
 
 type VMServiceStatus struct {
@@ -105,6 +348,7 @@ type VMRayClusterState string
 const (
 	HEALTHY   VMRayClusterState = "healthy"
 	UNHEALTHY VMRayClusterState = "unhealthy"
+	SUSPENDED VMRayClusterState = "suspended"
 )
 
 // VMRayClusterStatus defines the observed state of VMRayCluster
@@ -120,10 +364,17 @@ type VMRayClusterStatus struct {
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 	// Status of VM service associated with head VirtualMachine.
 	VMServiceStatus VMServiceStatus `json:"vm_service_status,omitempty"`
+	// Last time ray dashboard reported running jobs or client connections.
+	// +optional
+	LastActivityTime *metav1.Time `json:"last_activity_time,omitempty"`
+	// Progress of replacing ray nodes whose configuration changed, unset when all nodes are up to date.
+	// +optional
+	Upgrade *VMRayClusterUpgradeStatus `json:"upgrade,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:storageversion
 
 // VMRayCluster is the Schema for the vmrayclusters API
 type VMRayCluster struct {
@@ -157,6 +408,11 @@ type HeadNodeConfig struct {
 type WorkerNodeConfig struct {
 	// These setup commands are executed in worker node's Ray container before starting ray process.
 	SetupCommands []string `json:"setup_commands,omitempty"`
+	// Maximum number of seconds to wait for a worker's ray node to drain i.e. finish
+	// its running tasks, before its VM is deleted. Defaults to 300, 0 disables draining.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	DrainGracePeriodSeconds *int32 `json:"drain_grace_period_seconds,omitempty"`
 }
 
 type DockerRegistryConfig struct {
@@ -186,6 +442,36 @@ type CommonNodeConfig struct {
 	SetupCommands []string `json:"setup_commands,omitempty"`
 	// These commands will run outside the container in ray's VM node before docker container starts.
 	InitializationCommands []string `json:"initialization_commands,omitempty"`
+	// vSphere zones ray nodes are spread across, each node is deployed to a single zone.
+	// +optional
+	Zones []string `json:"zones,omitempty"`
+	// Nodes which are spread evenly across zones, either all nodes of the cluster, the
+	// default, or the nodes of each node type separately.
+	// +optional
+	ZoneSpread ZoneSpreadStrategy `json:"zone_spread,omitempty"`
+	// VirtualMachineSetResourcePolicy of the namespace ray nodes' VMs are deployed with,
+	// e.g. to keep them on separate hosts.
+	// +optional
+	ResourcePolicy *ResourcePolicyReference `json:"resource_policy,omitempty"`
+}
+
+// ZoneSpreadStrategy describes which nodes are spread evenly across zones.
+// +kubebuilder:validation:Enum=cluster;node_type
+type ZoneSpreadStrategy string
+
+const (
+	ZoneSpreadCluster  ZoneSpreadStrategy = "cluster"
+	ZoneSpreadNodeType ZoneSpreadStrategy = "node_type"
+)
+
+// ResourcePolicyReference references a VirtualMachineSetResourcePolicy.
+type ResourcePolicyReference struct {
+	// Name of the VirtualMachineSetResourcePolicy.
+	Name string `json:"name"`
+	// Cluster module group of the policy ray nodes' VMs join, VMs of a cluster
+	// module are kept on separate hosts of a vSphere cluster.
+	// +optional
+	ClusterModuleGroup string `json:"cluster_module_group,omitempty"`
 }
 
 type NodeType struct {
@@ -197,17 +483,35 @@ type NodeType struct {
 	MaxWorkers uint `json:"max_workers"`
 	// Resource limit to be set to be leveraged by ray process towards workload
 	Resources NodeResource `json:"resources,omitempty"`
+	// vSphere zones nodes of the node type are spread across instead of common_node_config's zones.
+	// +optional
+	Zones []string `json:"zones,omitempty"`
 }
 
+// NodeResource describes the resources ray advertises for a node, resources which
+// aren't set are detected by ray itself. Integers, which were the only values before
+// resources became quantities, keep their meaning, i.e. memory in bytes.
 type NodeResource struct {
-	// CPU limit to be used by the node.
-	CPU uint8 `json:"cpu,omitempty"`
+	// CPU limit to be used by the node, a whole number of CPUs.
+	// +optional
+	CPU *resource.Quantity `json:"cpu,omitempty"`
+
+	// Memory limit to be used by the node, e.g. 16Gi.
+	// +optional
+	Memory *resource.Quantity `json:"memory,omitempty"`
+
+	// GPU limit to be used by the node, a whole number of GPUs.
+	// +optional
+	GPU *resource.Quantity `json:"gpu,omitempty"`
 
-	// Memory limit to be used by the node.
-	Memory uint `json:"memory,omitempty"`
+	// Custom ray resources of the node, e.g. {"TPU": 1, "accelerator_type:A100": 1}.
+	// +optional
+	CustomResources map[string]resource.Quantity `json:"custom_resources,omitempty"`
+}
 
-	// GPU limit to be used by the node.
-	GPU uint8 `json:"gpu,omitempty"`
+// HasGPU returns true if the node type's nodes have GPUs.
+func (r NodeResource) HasGPU() bool {
+	return r.GPU != nil && r.GPU.Sign() > 0
 }
 
 // +kubebuilder:object:root=true
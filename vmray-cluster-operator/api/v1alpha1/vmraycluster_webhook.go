@@ -4,29 +4,68 @@
 package v1alpha1
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/distribution/reference"
+	vmopv1 "github.com/vmware-tanzu/vm-operator/api/v1alpha2"
+	admissionv1 "k8s.io/api/admission/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apiresource "k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
+// reservedRayResources are the resources ray detects itself, which can't be custom.
+var reservedRayResources = map[string]bool{
+	"CPU":                 true,
+	"GPU":                 true,
+	"memory":              true,
+	"object_store_memory": true,
+}
+
 var (
 	nameRegex, _         = regexp.Compile("^[a-z]([-a-z0-9]*[a-z0-9])?$")
 	dnsComplaintRegex, _ = regexp.Compile(`^(([a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9\-]*[a-zA-Z0-9])\.)*([A-Za-z0-9]|[A-Za-z0-9][A-Za-z0-9\-]*[A-Za-z0-9])$`)
 	vmrayclusterlog      = logf.Log.WithName("vmraycluster-resource")
 )
 
+const (
+	// DefaultHeadPort is the port of the head ray process when none is provided.
+	DefaultHeadPort = uint(6379)
+	// DefaultIdleTimeoutMinutes matches ray autoscaler's own default idle timeout.
+	DefaultIdleTimeoutMinutes = uint(5)
+	// DefaultVMUser is the user created to run the ray process in ray VMs.
+	DefaultVMUser = "ray-vm"
+
+	mutatingWebhookPath = "/mutate-vmray-broadcom-com-v1alpha1-vmraycluster"
+)
+
 func (r *VMRayCluster) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	// Referenced objects are read with the API reader, so the webhooks
+	// don't start informers on them. The defaulting webhook is registered
+	// before the builder, which skips the already handled path.
+	mgr.GetWebhookServer().Register(mutatingWebhookPath, &webhook.Admission{
+		Handler: &vmRayClusterDefaulter{
+			reader:  mgr.GetAPIReader(),
+			decoder: admission.NewDecoder(mgr.GetScheme()),
+		},
+	})
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(r).
+		WithValidator(&vmRayClusterValidator{reader: mgr.GetAPIReader()}).
 		Complete()
 }
 
@@ -34,11 +73,156 @@ func (r *VMRayCluster) SetupWebhookWithManager(mgr ctrl.Manager) error {
 
 var _ webhook.Defaulter = &VMRayCluster{}
 
-// Default implements webhook.Defaulter so a webhook will be registered for the type
+// Default implements webhook.Defaulter, it fills in the defaults which don't depend
+// on other objects, so the stored spec is explicit about the values ray nodes are
+// deployed with. enable_tls is defaulted by the CRD schema instead, as unset can't
+// be told apart from false here.
 func (r *VMRayCluster) Default() {
 	vmrayclusterlog.Info("default", "name", r.ObjectMeta.Name)
+	r.defaultSpec()
+}
 
-	// TODO(user): fill in your defaulting logic.
+// defaultSpec fills in the defaults of Default, it returns the warnings of defaultMaxWorkers.
+func (r *VMRayCluster) defaultSpec() admission.Warnings {
+	if r.Spec.HeadNode.Port == nil {
+		port := DefaultHeadPort
+		r.Spec.HeadNode.Port = &port
+	}
+	if r.Spec.NodeConfig.IdleTimeoutMinutes == 0 {
+		r.Spec.NodeConfig.IdleTimeoutMinutes = DefaultIdleTimeoutMinutes
+	}
+	if r.Spec.NodeConfig.VMUser == "" {
+		r.Spec.NodeConfig.VMUser = DefaultVMUser
+	}
+	return r.defaultMaxWorkers(r.getNodeTypeNames())
+}
+
+// defaultMaxWorkers defaults max_workers of the given node types. Like ray autoscaler,
+// a worker node type without max_workers may scale up to cluster's max_workers. Head
+// node type launches no workers unless asked to. A warning names each defaulted node type.
+func (r *VMRayCluster) defaultMaxWorkers(names []string) admission.Warnings {
+	warnings := admission.Warnings{}
+	nodeTypesPath := field.NewPath("spec").Child("common_node_config").Child("available_node_types")
+	for _, name := range names {
+		nt := r.Spec.NodeConfig.NodeTypes[name]
+		if nt.MaxWorkers == 0 && name != r.Spec.HeadNode.NodeType {
+			nt.MaxWorkers = r.Spec.NodeConfig.MaxWorkers
+			r.Spec.NodeConfig.NodeTypes[name] = nt
+			warnings = append(warnings, fmt.Sprintf("%s: defaulted to spec.common_node_config.max_workers %d",
+				nodeTypesPath.Key(name).Child("max_workers"), nt.MaxWorkers))
+		}
+	}
+	return warnings
+}
+
+func (r *VMRayCluster) getNodeTypeNames() []string {
+	names := make([]string, 0, len(r.Spec.NodeConfig.NodeTypes))
+	for name := range r.Spec.NodeConfig.NodeTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// vmRayClusterDefaulter defaults the cluster like VMRayCluster.Default and
+// additionally derives node types' resources from their VM classes. Unlike a
+// webhook.CustomDefaulter, it responds with warnings about the derived values.
+type vmRayClusterDefaulter struct {
+	reader  client.Reader
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &vmRayClusterDefaulter{}
+
+// Handle implements admission.Handler, it patches the cluster with its defaults.
+func (d *vmRayClusterDefaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	r := &VMRayCluster{}
+	if err := d.decoder.Decode(req, r); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	warnings, err := d.Default(ctx, req, r)
+	if err != nil {
+		var apiStatus apierrors.APIStatus
+		if errors.As(err, &apiStatus) {
+			return admission.Errored(apiStatus.Status().Code, err)
+		}
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	marshalled, err := json.Marshal(r)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshalled).WithWarnings(warnings...)
+}
+
+// Default defaults the cluster of the request and returns a warning for each value
+// derived from the rest of the cluster or its VM classes. Clusters are defaulted when
+// they're created, defaulting fields of a running cluster would replace its nodes. On
+// update only node types added by the update are defaulted, as no nodes run them yet.
+func (d *vmRayClusterDefaulter) Default(ctx context.Context, req admission.Request, r *VMRayCluster) (admission.Warnings, error) {
+	if req.Operation != admissionv1.Update {
+		vmrayclusterlog.Info("default", "name", r.ObjectMeta.Name)
+		warnings := r.defaultSpec()
+		resourceWarnings, err := r.defaultResources(ctx, d.reader, r.getNodeTypeNames())
+		return append(warnings, resourceWarnings...), err
+	}
+
+	old := &VMRayCluster{}
+	if err := json.Unmarshal(req.OldObject.Raw, old); err != nil {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("failed to decode old VMRayCluster: %v", err))
+	}
+	added := []string{}
+	for _, name := range r.getNodeTypeNames() {
+		if _, ok := old.Spec.NodeConfig.NodeTypes[name]; !ok {
+			added = append(added, name)
+		}
+	}
+	warnings := r.defaultMaxWorkers(added)
+	resourceWarnings, err := r.defaultResources(ctx, d.reader, added)
+	return append(warnings, resourceWarnings...), err
+}
+
+// defaultResources fills in cpu & gpu the given node types don't provide from the
+// hardware of their VM class, see NodeResource.DefaultFrom, with a warning naming
+// the derived resources. Node types whose VM class doesn't exist are left as they
+// are, the controller reports the missing VM class on the cluster.
+func (r *VMRayCluster) defaultResources(ctx context.Context, reader client.Reader, names []string) (admission.Warnings, error) {
+	warnings := admission.Warnings{}
+	nodeTypesPath := field.NewPath("spec").Child("common_node_config").Child("available_node_types")
+	for _, name := range names {
+		nt := r.Spec.NodeConfig.NodeTypes[name]
+		// VM class isn't looked up for node types explicit about their resources.
+		if nt.Resources.CPU != nil && nt.Resources.Memory != nil {
+			continue
+		}
+		vmclass := &vmopv1.VirtualMachineClass{}
+		key := client.ObjectKey{Namespace: r.ObjectMeta.Namespace, Name: nt.VMClass}
+		if err := reader.Get(ctx, key, vmclass); err != nil {
+			if apierrors.IsNotFound(err) {
+				vmrayclusterlog.Info("VM class not found, resources aren't defaulted", "name",
+					r.ObjectMeta.Name, "node type", name, "vm class", nt.VMClass)
+				continue
+			}
+			return warnings, err
+		}
+		defaulted := nt.Resources.DefaultFrom(HardwareResources(vmclass.Spec.Hardware))
+		derived := []string{}
+		if nt.Resources.CPU == nil && defaulted.CPU != nil {
+			derived = append(derived, "cpu "+defaulted.CPU.String())
+		}
+		if nt.Resources.GPU == nil && defaulted.GPU != nil {
+			derived = append(derived, "gpu "+defaulted.GPU.String())
+		}
+		if len(derived) > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s: %s defaulted from VirtualMachineClass %s",
+				nodeTypesPath.Key(name).Child("resources"), strings.Join(derived, " & "), nt.VMClass))
+		}
+		nt.Resources = defaulted
+		r.Spec.NodeConfig.NodeTypes[name] = nt
+	}
+	return warnings, nil
 }
 
 // TODO(user): change verbs to "verbs=create;update;delete" if you want to enable deletion validation.
@@ -50,14 +234,27 @@ var _ webhook.Validator = &VMRayCluster{}
 func (r *VMRayCluster) ValidateCreate() (admission.Warnings, error) {
 	vmrayclusterlog.Info("validate create", "name", r.ObjectMeta.Name)
 
-	return nil, r.validateVMRayCluster()
+	return r.resourceWarnings(nil), r.invalidError(r.validateVMRayCluster(nil))
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
 func (r *VMRayCluster) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
 	vmrayclusterlog.Info("validate update", "name", r.ObjectMeta.Name)
 
-	return nil, r.validateVMRayCluster()
+	oldCluster, ok := old.(*VMRayCluster)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a VMRayCluster but got a %T", old))
+	}
+
+	// A cluster being deleted only has its finalizer removed, which mustn't be
+	// blocked by a spec that's no longer valid.
+	if !r.ObjectMeta.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+
+	allErrs := append(r.validateVMRayCluster(oldCluster), r.validateTransition(oldCluster)...)
+	warnings := append(r.updateWarnings(oldCluster), r.resourceWarnings(oldCluster)...)
+	return warnings, r.invalidError(allErrs)
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
@@ -68,7 +265,9 @@ func (r *VMRayCluster) ValidateDelete() (admission.Warnings, error) {
 	return nil, nil
 }
 
-func (r *VMRayCluster) validateVMRayCluster() error {
+// validateVMRayCluster validates the cluster, old is the cluster being updated
+// or nil on create.
+func (r *VMRayCluster) validateVMRayCluster(old *VMRayCluster) field.ErrorList {
 	var allErrs field.ErrorList
 
 	if err := r.validateName(); err != nil {
@@ -88,10 +287,20 @@ func (r *VMRayCluster) validateVMRayCluster() error {
 		allErrs = append(allErrs, err)
 	}
 
-	if err := r.validateDesiredWorkers(field.NewPath("spec").Child("autoscaler_desired_workers")); err != nil {
+	allErrs = append(allErrs, r.validateResources(field.NewPath("spec").Child("common_node_config").Child("available_node_types"))...)
+
+	allErrs = append(allErrs, r.validateDesiredWorkers(field.NewPath("spec").Child("autoscaler_desired_workers"), old)...)
+
+	allErrs = append(allErrs, r.validatePlacement(field.NewPath("spec").Child("common_node_config"))...)
+
+	if err := r.validateUpgradeStrategy(field.NewPath("spec").Child("upgrade_strategy")); err != nil {
 		allErrs = append(allErrs, err)
 	}
 
+	return allErrs
+}
+
+func (r *VMRayCluster) invalidError(allErrs field.ErrorList) error {
 	if len(allErrs) == 0 {
 		return nil
 	}
@@ -166,12 +375,201 @@ func (r *VMRayCluster) validateDockerImage(fieldPath *field.Path, dockerImageNam
 	return nil
 }
 
-func (r *VMRayCluster) validateDesiredWorkers(fieldPath *field.Path) *field.Error {
-	for k := range r.Spec.AutoscalerDesiredWorkers {
-		if !dnsComplaintRegex.MatchString(k) {
-			return field.Invalid(fieldPath, "name",
-				fmt.Sprintf("Must be DNS compliant name %s", k))
+// validateDesiredWorkers validates names & node types of the desired workers and
+// their counts. On update only the workers added or changed since old are validated,
+// counts of node types without such workers aren't either, so lowering max_workers
+// is left to the autoscaler scaling the cluster down.
+func (r *VMRayCluster) validateDesiredWorkers(fieldPath *field.Path, old *VMRayCluster) field.ErrorList {
+	var allErrs field.ErrorList
+
+	names := make([]string, 0, len(r.Spec.AutoscalerDesiredWorkers))
+	for name, nodeType := range r.Spec.AutoscalerDesiredWorkers {
+		if old != nil {
+			if oldNodeType, ok := old.Spec.AutoscalerDesiredWorkers[name]; ok && oldNodeType == nodeType {
+				continue
+			}
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Head node's name is suffixed with a nounce which changes whenever the head
+	// node is replaced, so workers can't use any of the names it might get.
+	headname := r.ObjectMeta.Name + "-h"
+	counts := make(map[string]uint)
+	for _, name := range names {
+		if !dnsComplaintRegex.MatchString(name) {
+			allErrs = append(allErrs, field.Invalid(fieldPath, "name",
+				fmt.Sprintf("Must be DNS compliant name %s", name)))
+			continue
+		}
+		if name == headname || strings.HasPrefix(name, headname+"-") {
+			allErrs = append(allErrs, field.Invalid(fieldPath.Key(name), name,
+				fmt.Sprintf("Worker name collides with head node names, it mustn't be %s or start with %s-", headname, headname)))
+		}
+		nodeType := r.Spec.AutoscalerDesiredWorkers[name]
+		if _, ok := r.Spec.NodeConfig.NodeTypes[nodeType]; !ok {
+			allErrs = append(allErrs, field.Invalid(fieldPath.Key(name), nodeType,
+				"Node type doesn't exist in available_node_types"))
+			continue
+		}
+		counts[nodeType] = 0
+	}
+	if len(counts) == 0 {
+		return allErrs
+	}
+
+	total := uint(0)
+	for _, nodeType := range r.Spec.AutoscalerDesiredWorkers {
+		if _, ok := r.Spec.NodeConfig.NodeTypes[nodeType]; !ok {
+			continue
+		}
+		if _, ok := counts[nodeType]; ok {
+			counts[nodeType]++
+		}
+		total++
+	}
+	nodeTypes := make([]string, 0, len(counts))
+	for nodeType := range counts {
+		nodeTypes = append(nodeTypes, nodeType)
+	}
+	sort.Strings(nodeTypes)
+	for _, nodeType := range nodeTypes {
+		if maxWorkers := r.Spec.NodeConfig.NodeTypes[nodeType].MaxWorkers; counts[nodeType] > maxWorkers {
+			allErrs = append(allErrs, field.Invalid(fieldPath, int64(counts[nodeType]),
+				fmt.Sprintf("Desired workers of node type %s exceed its max_workers %d", nodeType, maxWorkers)))
+		}
+	}
+	if total > r.Spec.NodeConfig.MaxWorkers {
+		allErrs = append(allErrs, field.Invalid(fieldPath, int64(total),
+			fmt.Sprintf("Desired workers exceed spec.common_node_config.max_workers %d", r.Spec.NodeConfig.MaxWorkers)))
+	}
+	return allErrs
+}
+
+// validateResources validates node types' resources, ray only schedules whole CPUs
+// & GPUs and custom resources can't shadow the ones it detects itself.
+func (r *VMRayCluster) validateResources(fieldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	names := make([]string, 0, len(r.Spec.NodeConfig.NodeTypes))
+	for name := range r.Spec.NodeConfig.NodeTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		resources := r.Spec.NodeConfig.NodeTypes[name].Resources
+		resourcesPath := fieldPath.Key(name).Child("resources")
+		for _, whole := range []struct {
+			name     string
+			quantity *apiresource.Quantity
+		}{{"cpu", resources.CPU}, {"gpu", resources.GPU}} {
+			if whole.quantity == nil {
+				continue
+			}
+			if _, ok := whole.quantity.AsInt64(); !ok || whole.quantity.Sign() < 0 {
+				allErrs = append(allErrs, field.Invalid(resourcesPath.Child(whole.name), whole.quantity.String(),
+					"Must be a whole, non-negative number"))
+			}
+		}
+		if resources.Memory != nil && resources.Memory.Sign() < 0 {
+			allErrs = append(allErrs, field.Invalid(resourcesPath.Child("memory"), resources.Memory.String(),
+				"Must be non-negative"))
 		}
+
+		customNames := make([]string, 0, len(resources.CustomResources))
+		for custom := range resources.CustomResources {
+			customNames = append(customNames, custom)
+		}
+		sort.Strings(customNames)
+		for _, custom := range customNames {
+			customPath := resourcesPath.Child("custom_resources").Key(custom)
+			if reservedRayResources[custom] {
+				allErrs = append(allErrs, field.Invalid(customPath, custom,
+					"Ray's own resources must be set by cpu, gpu & memory instead"))
+			} else if strings.TrimSpace(custom) == "" {
+				allErrs = append(allErrs, field.Invalid(customPath, custom, "Name must not be empty"))
+			}
+			if quantity := resources.CustomResources[custom]; quantity.Sign() < 0 {
+				allErrs = append(allErrs, field.Invalid(customPath, quantity.String(), "Must be non-negative"))
+			}
+		}
+	}
+	return allErrs
+}
+
+// resourceWarnings warns about memory of node types, but not of old's node types
+// with the same memory, given in a form which doesn't mean what it likely meant.
+// Plain integers are deprecated, they're read as bytes rather than e.g. MiB, and
+// fractions of a byte are rounded up as ray only takes whole bytes.
+func (r *VMRayCluster) resourceWarnings(old *VMRayCluster) admission.Warnings {
+	warnings := admission.Warnings{}
+	nodeTypesPath := field.NewPath("spec").Child("common_node_config").Child("available_node_types")
+	for _, name := range r.getNodeTypeNames() {
+		memory := r.Spec.NodeConfig.NodeTypes[name].Resources.Memory
+		if memory == nil {
+			continue
+		}
+		if old != nil {
+			oldMemory := old.Spec.NodeConfig.NodeTypes[name].Resources.Memory
+			if oldMemory != nil && oldMemory.Equal(*memory) {
+				continue
+			}
+		}
+		memoryPath := nodeTypesPath.Key(name).Child("resources").Child("memory")
+		if _, ok := memory.AsInt64(); !ok {
+			warnings = append(warnings, fmt.Sprintf("%s: %s is rounded up to %d bytes", memoryPath,
+				memory.String(), memory.Value()))
+		} else if memory.Format == apiresource.DecimalSI && strings.Trim(memory.String(), "0123456789") == "" {
+			warnings = append(warnings, fmt.Sprintf("%s: %s has no unit, plain integers are deprecated & "+
+				"read as bytes, set a quantity like 16Gi instead", memoryPath, memory.String()))
+		}
+	}
+	return warnings
+}
+
+// validatePlacement validates zones common to all node types & zones of each node
+// type, along with the resource policy VMs of ray nodes are deployed with.
+func (r *VMRayCluster) validatePlacement(fieldPath *field.Path) field.ErrorList {
+	allErrs := validateZones(fieldPath.Child("zones"), r.Spec.NodeConfig.Zones)
+
+	names := make([]string, 0, len(r.Spec.NodeConfig.NodeTypes))
+	for name := range r.Spec.NodeConfig.NodeTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		allErrs = append(allErrs, validateZones(fieldPath.Child("available_node_types").Key(name).Child("zones"),
+			r.Spec.NodeConfig.NodeTypes[name].Zones)...)
+	}
+
+	if policy := r.Spec.NodeConfig.ResourcePolicy; policy != nil && strings.TrimSpace(policy.Name) == "" {
+		allErrs = append(allErrs, field.Required(fieldPath.Child("resource_policy").Child("name"),
+			"Name of the VirtualMachineSetResourcePolicy must be set"))
+	}
+	return allErrs
+}
+
+func validateZones(fieldPath *field.Path, zones []string) field.ErrorList {
+	var allErrs field.ErrorList
+	seen := make(map[string]bool, len(zones))
+	for i, zone := range zones {
+		if strings.TrimSpace(zone) == "" {
+			allErrs = append(allErrs, field.Invalid(fieldPath.Index(i), zone, "Zone must not be empty"))
+		} else if seen[zone] {
+			allErrs = append(allErrs, field.Duplicate(fieldPath.Index(i), zone))
+		}
+		seen[zone] = true
+	}
+	return allErrs
+}
+
+func (r *VMRayCluster) validateUpgradeStrategy(fieldPath *field.Path) *field.Error {
+	strategy := r.Spec.UpgradeStrategy
+	if strategy.MaxSurge != nil && *strategy.MaxSurge == 0 &&
+		strategy.MaxUnavailable != nil && *strategy.MaxUnavailable == 0 {
+		return field.Invalid(fieldPath, "max_surge/max_unavailable",
+			"max_surge and max_unavailable can't both be 0, outdated workers would never be replaced")
 	}
 	return nil
 }
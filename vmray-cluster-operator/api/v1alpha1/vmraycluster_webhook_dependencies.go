@@ -0,0 +1,240 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	vmopv1 "github.com/vmware-tanzu/vm-operator/api/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+/*
+The VM image, VM classes & storage class a cluster references have to be available
+in its namespace, otherwise its VMs can't be deployed:
+
+  - VM image is either a VirtualMachineImage of the namespace or a ClusterVirtualMachineImage.
+  - VM classes are bound to a namespace by existing in it.
+  - Storage class is assigned to a namespace by its ResourceQuotas, when any of them
+    limits storage per storage class. Without such a quota all storage classes are.
+  - Resource policy is a VirtualMachineSetResourcePolicy of the namespace, listing
+    the cluster module group if one is set.
+
+Only references added or changed by an update are validated, so a cluster whose
+references were removed later can still be updated e.g. to be deleted. References
+which can't be looked up are reported as warnings instead of failing admission.
+Node types' resources exceeding the hardware of their VM class are reported as
+warnings too, as ray would plan with capacity its nodes don't have, while node types
+with GPUs whose VM class has no vGPU or PCI devices are invalid.
+*/
+
+// storageClassQuotaSuffix separates storage class name from the resource in names of
+// ResourceQuota's resources limited per storage class.
+const storageClassQuotaSuffix = ".storageclass.storage.k8s.io/"
+
+// +kubebuilder:rbac:groups=vmoperator.vmware.com,resources=virtualmachineimages;clustervirtualmachineimages;virtualmachineclasses;virtualmachinesetresourcepolicies,verbs=get;list;watch
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=resourcequotas,verbs=get;list;watch
+
+// vmRayClusterValidator validates the cluster like VMRayCluster's webhook.Validator
+// and additionally that the objects it references are available in its namespace.
+type vmRayClusterValidator struct {
+	reader client.Reader
+}
+
+var _ webhook.CustomValidator = &vmRayClusterValidator{}
+
+// ValidateCreate implements webhook.CustomValidator.
+func (v *vmRayClusterValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	r, ok := obj.(*VMRayCluster)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a VMRayCluster but got a %T", obj))
+	}
+	warnings, err := r.ValidateCreate()
+	if err != nil {
+		return warnings, err
+	}
+	dependencyWarnings, allErrs := r.validateDependencies(ctx, v.reader, nil)
+	return append(warnings, dependencyWarnings...), r.invalidError(allErrs)
+}
+
+// ValidateUpdate implements webhook.CustomValidator.
+func (v *vmRayClusterValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	r, ok := newObj.(*VMRayCluster)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a VMRayCluster but got a %T", newObj))
+	}
+	warnings, err := r.ValidateUpdate(oldObj)
+	if err != nil || !r.ObjectMeta.DeletionTimestamp.IsZero() {
+		return warnings, err
+	}
+	dependencyWarnings, allErrs := r.validateDependencies(ctx, v.reader, oldObj.(*VMRayCluster))
+	return append(warnings, dependencyWarnings...), r.invalidError(allErrs)
+}
+
+// ValidateDelete implements webhook.CustomValidator.
+func (v *vmRayClusterValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	r, ok := obj.(*VMRayCluster)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a VMRayCluster but got a %T", obj))
+	}
+	return r.ValidateDelete()
+}
+
+// validateDependencies validates that the objects referenced by the cluster, but not
+// by old if it's updated, are available in cluster's namespace.
+func (r *VMRayCluster) validateDependencies(ctx context.Context, reader client.Reader,
+	old *VMRayCluster) (admission.Warnings, field.ErrorList) {
+	var allErrs field.ErrorList
+	warnings := admission.Warnings{}
+	nodeConfigPath := field.NewPath("spec").Child("common_node_config")
+
+	report := func(fieldPath *field.Path, fieldErr *field.Error, err error) {
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("Couldn't verify %s: %v", fieldPath, err))
+		} else if fieldErr != nil {
+			allErrs = append(allErrs, fieldErr)
+		}
+	}
+
+	if old == nil || r.Spec.NodeConfig.VMImage != old.Spec.NodeConfig.VMImage {
+		fieldPath := nodeConfigPath.Child("vm_image")
+		fieldErr, err := r.validateVMImage(ctx, reader, fieldPath)
+		report(fieldPath, fieldErr, err)
+	}
+	if old == nil || r.Spec.NodeConfig.StorageClass != old.Spec.NodeConfig.StorageClass {
+		fieldPath := nodeConfigPath.Child("storage_class")
+		fieldErr, err := r.validateStorageClass(ctx, reader, fieldPath)
+		report(fieldPath, fieldErr, err)
+	}
+	if policy := r.Spec.NodeConfig.ResourcePolicy; policy != nil &&
+		(old == nil || !equality.Semantic.DeepEqual(policy, old.Spec.NodeConfig.ResourcePolicy)) {
+		fieldPath := nodeConfigPath.Child("resource_policy")
+		fieldErr, err := r.validateResourcePolicy(ctx, reader, fieldPath)
+		report(fieldPath, fieldErr, err)
+	}
+
+	names := make([]string, 0, len(r.Spec.NodeConfig.NodeTypes))
+	for name := range r.Spec.NodeConfig.NodeTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		nt := r.Spec.NodeConfig.NodeTypes[name]
+		classChanged, resourcesChanged := true, true
+		if old != nil {
+			if oldnt, ok := old.Spec.NodeConfig.NodeTypes[name]; ok {
+				classChanged = oldnt.VMClass != nt.VMClass
+				resourcesChanged = classChanged || !equality.Semantic.DeepEqual(oldnt.Resources, nt.Resources)
+			}
+		}
+		if !resourcesChanged {
+			continue
+		}
+		nodeTypePath := nodeConfigPath.Child("available_node_types").Key(name)
+		vmclass := &vmopv1.VirtualMachineClass{}
+		fieldErr, err := r.validateVMClass(ctx, reader, nodeTypePath.Child("vm_class"), nt.VMClass, vmclass)
+		if classChanged {
+			report(nodeTypePath.Child("vm_class"), fieldErr, err)
+		}
+		if fieldErr != nil || err != nil {
+			continue
+		}
+		provided := HardwareResources(vmclass.Spec.Hardware)
+		if nt.Resources.HasGPU() && !provided.HasGPU() {
+			allErrs = append(allErrs, field.Invalid(nodeTypePath.Child("resources", "gpu"), nt.Resources.GPU.String(),
+				fmt.Sprintf("VirtualMachineClass %s has no vGPU or PCI devices", nt.VMClass)))
+		} else if exceeded := nt.Resources.ExceededBy(provided); len(exceeded) > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s exceed VirtualMachineClass %s: %s",
+				nodeTypePath.Child("resources"), nt.VMClass, strings.Join(exceeded, ", ")))
+		}
+	}
+
+	if len(warnings) == 0 {
+		warnings = nil
+	}
+	return warnings, allErrs
+}
+
+func (r *VMRayCluster) validateVMImage(ctx context.Context, reader client.Reader, fieldPath *field.Path) (*field.Error, error) {
+	name := r.Spec.NodeConfig.VMImage
+	err := reader.Get(ctx, client.ObjectKey{Namespace: r.ObjectMeta.Namespace, Name: name}, &vmopv1.VirtualMachineImage{})
+	if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	err = reader.Get(ctx, client.ObjectKey{Name: name}, &vmopv1.ClusterVirtualMachineImage{})
+	if apierrors.IsNotFound(err) {
+		return field.Invalid(fieldPath, name, fmt.Sprintf("Neither VirtualMachineImage in namespace %s nor "+
+			"ClusterVirtualMachineImage exists", r.ObjectMeta.Namespace)), nil
+	}
+	return nil, err
+}
+
+// validateVMClass validates the named VM class is bound to cluster's namespace & reads it into vmclass.
+func (r *VMRayCluster) validateVMClass(ctx context.Context, reader client.Reader, fieldPath *field.Path,
+	name string, vmclass *vmopv1.VirtualMachineClass) (*field.Error, error) {
+	err := reader.Get(ctx, client.ObjectKey{Namespace: r.ObjectMeta.Namespace, Name: name}, vmclass)
+	if apierrors.IsNotFound(err) {
+		return field.Invalid(fieldPath, name, fmt.Sprintf("VirtualMachineClass isn't bound to namespace %s",
+			r.ObjectMeta.Namespace)), nil
+	}
+	return nil, err
+}
+
+func (r *VMRayCluster) validateStorageClass(ctx context.Context, reader client.Reader, fieldPath *field.Path) (*field.Error, error) {
+	name := r.Spec.NodeConfig.StorageClass
+	err := reader.Get(ctx, client.ObjectKey{Name: name}, &storagev1.StorageClass{})
+	if apierrors.IsNotFound(err) {
+		return field.Invalid(fieldPath, name, "StorageClass doesn't exist"), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	quotas := &corev1.ResourceQuotaList{}
+	if err := reader.List(ctx, quotas, client.InNamespace(r.ObjectMeta.Namespace)); err != nil {
+		return nil, err
+	}
+	assigned := make(map[string]bool)
+	for _, quota := range quotas.Items {
+		for resource := range quota.Spec.Hard {
+			if sc, _, ok := strings.Cut(string(resource), storageClassQuotaSuffix); ok {
+				assigned[sc] = true
+			}
+		}
+	}
+	if len(assigned) > 0 && !assigned[name] {
+		return field.Invalid(fieldPath, name, fmt.Sprintf("StorageClass isn't assigned to namespace %s by its ResourceQuotas",
+			r.ObjectMeta.Namespace)), nil
+	}
+	return nil, nil
+}
+
+func (r *VMRayCluster) validateResourcePolicy(ctx context.Context, reader client.Reader, fieldPath *field.Path) (*field.Error, error) {
+	policy := r.Spec.NodeConfig.ResourcePolicy
+	setPolicy := &vmopv1.VirtualMachineSetResourcePolicy{}
+	err := reader.Get(ctx, client.ObjectKey{Namespace: r.ObjectMeta.Namespace, Name: policy.Name}, setPolicy)
+	if apierrors.IsNotFound(err) {
+		return field.Invalid(fieldPath.Child("name"), policy.Name, fmt.Sprintf("VirtualMachineSetResourcePolicy "+
+			"doesn't exist in namespace %s", r.ObjectMeta.Namespace)), nil
+	} else if err != nil {
+		return nil, err
+	}
+	if policy.ClusterModuleGroup != "" && !slices.Contains(setPolicy.Spec.ClusterModuleGroups, policy.ClusterModuleGroup) {
+		return field.Invalid(fieldPath.Child("cluster_module_group"), policy.ClusterModuleGroup,
+			fmt.Sprintf("Isn't a cluster module group of VirtualMachineSetResourcePolicy %s", policy.Name)), nil
+	}
+	return nil, nil
+}
@@ -5,18 +5,50 @@ package v1alpha1_test
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	vmopv1 "github.com/vmware-tanzu/vm-operator/api/v1alpha2"
 	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
 	. "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	testutil "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/test/builder/utils"
 )
 
+// dependencyName names the VM image, VM class & storage class available to test clusters.
+const dependencyName = "vmray-dependency"
+
+// warningRecorder records the warnings returned by the API server & its webhooks.
+type warningRecorder struct {
+	texts []string
+}
+
+func (w *warningRecorder) HandleWarningHeader(_ int, _ string, text string) {
+	w.texts = append(w.texts, text)
+}
+
 func vmRayClusterUnitTests() {
 	var (
 		rayCluster VMRayCluster
 	)
+
+	BeforeEach(func() {
+		testutil.CreateAuxiliaryDependencies(context.TODO(), suite.GetK8sClient(), "default", dependencyName)
+	})
+
+	AfterEach(func() {
+		testutil.DeleteAuxiliaryDependencies(context.TODO(), suite.GetK8sClient(), "default", dependencyName)
+	})
 	Describe("VMRayCluster validating webhook", func() {
 
 		port := uint(6379)
@@ -35,11 +67,11 @@ func vmRayClusterUnitTests() {
 					Image:    "rayproject/ray:2.5.0",
 					HeadNode: head_node,
 					NodeConfig: CommonNodeConfig{
-						VMImage:      "vm-image",
-						StorageClass: "storage-class",
+						VMImage:      dependencyName,
+						StorageClass: dependencyName,
 						NodeTypes: map[string]NodeType{
 							"worker_1": {
-								VMClass:    "vm-class",
+								VMClass:    dependencyName,
 								MinWorkers: 3,
 								MaxWorkers: 5,
 							},
@@ -49,6 +81,47 @@ func vmRayClusterUnitTests() {
 			}
 		})
 
+		Context("when memory is set", func() {
+			setMemory := func(quantity string) {
+				memory := resource.MustParse(quantity)
+				nt := rayCluster.Spec.NodeConfig.NodeTypes["worker_1"]
+				nt.Resources.Memory = &memory
+				rayCluster.Spec.NodeConfig.NodeTypes["worker_1"] = nt
+				rayCluster.Spec.NodeConfig.MaxWorkers = nt.MaxWorkers
+			}
+
+			It("should warn that a plain integer is read as bytes", func() {
+				setMemory("16384")
+
+				warnings, err := rayCluster.ValidateCreate()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(warnings).To(ConsistOf("spec.common_node_config.available_node_types[worker_1].resources.memory: " +
+					"16384 has no unit, plain integers are deprecated & read as bytes, set a quantity like 16Gi instead"))
+
+				// Memory an update doesn't change isn't warned about again.
+				warnings, err = rayCluster.ValidateUpdate(rayCluster.DeepCopy())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(warnings).To(BeEmpty())
+			})
+
+			It("should warn that a fraction of a byte is rounded up", func() {
+				setMemory("1500m")
+
+				warnings, err := rayCluster.ValidateCreate()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(warnings).To(ConsistOf("spec.common_node_config.available_node_types[worker_1].resources.memory: " +
+					"1500m is rounded up to 2 bytes"))
+			})
+
+			It("should not warn about a quantity with a unit", func() {
+				setMemory("16Gi")
+
+				warnings, err := rayCluster.ValidateCreate()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(warnings).To(BeEmpty())
+			})
+		})
+
 		Context("when name is invalid", func() {
 			It("should return error", func() {
 				rayCluster.Name = "invalid.name"
@@ -127,6 +200,46 @@ func vmRayClusterUnitTests() {
 			})
 		})
 
+		Context("invalid resources", func() {
+
+			It("should return error", func() {
+				nt := rayCluster.Spec.NodeConfig.NodeTypes["worker_1"]
+				nt.Resources = NodeResource{
+					CPU:    resource.NewMilliQuantity(500, resource.DecimalSI),
+					Memory: resource.NewQuantity(-1, resource.BinarySI),
+					CustomResources: map[string]resource.Quantity{
+						"GPU": resource.MustParse("1"),
+					},
+				}
+				rayCluster.Spec.NodeConfig.NodeTypes["worker_1"] = nt
+
+				err := suite.GetK8sClient().Create(context.TODO(), &rayCluster)
+				Expect(err).To(HaveOccurred())
+
+				resourcesPath := "spec.common_node_config.available_node_types[worker_1].resources"
+				Expect(err.Error()).To(ContainSubstring(resourcesPath + ".cpu: Invalid value: \"500m\""))
+				Expect(err.Error()).To(ContainSubstring(resourcesPath + ".memory: Invalid value: \"-1\""))
+				Expect(err.Error()).To(ContainSubstring(resourcesPath + ".custom_resources[GPU]: Invalid value: \"GPU\""))
+			})
+		})
+
+		Context("fractional custom resources", func() {
+
+			It("should be accepted", func() {
+				nt := rayCluster.Spec.NodeConfig.NodeTypes["worker_1"]
+				nt.Resources = NodeResource{
+					Memory: resource.NewQuantity(16*1024*1024*1024, resource.BinarySI),
+					CustomResources: map[string]resource.Quantity{
+						"accelerator_type:A100": resource.MustParse("0.5"),
+					},
+				}
+				rayCluster.Spec.NodeConfig.NodeTypes["worker_1"] = nt
+
+				Expect(suite.GetK8sClient().Create(context.TODO(), &rayCluster)).To(Succeed())
+				Expect(suite.GetK8sClient().Delete(context.TODO(), &rayCluster)).To(Succeed())
+			})
+		})
+
 		Context("invalid desired workers", func() {
 
 			It("should return error", func() {
@@ -141,5 +254,724 @@ func vmRayClusterUnitTests() {
 				Expect(err.Error()).To(ContainSubstring("Must be DNS compliant name"))
 			})
 		})
+
+		Context("desired worker of unknown node type", func() {
+
+			It("should return error", func() {
+				rayCluster.Spec.AutoscalerDesiredWorkers = map[string]string{
+					"worker-a": "worker_2",
+				}
+
+				err := suite.GetK8sClient().Create(context.TODO(), &rayCluster)
+				Expect(err).To(HaveOccurred())
+
+				Expect(err.Error()).To(ContainSubstring("spec.autoscaler_desired_workers[worker-a]: Invalid value: \"worker_2\": Node type doesn't exist in available_node_types"))
+			})
+		})
+
+		Context("desired worker named like head node", func() {
+
+			It("should return error", func() {
+				rayCluster.Spec.AutoscalerDesiredWorkers = map[string]string{
+					"valid-name-h-abcde": "worker_1",
+				}
+
+				err := suite.GetK8sClient().Create(context.TODO(), &rayCluster)
+				Expect(err).To(HaveOccurred())
+
+				Expect(err.Error()).To(ContainSubstring("spec.autoscaler_desired_workers[valid-name-h-abcde]: Invalid value: \"valid-name-h-abcde\": Worker name collides with head node names"))
+			})
+		})
+
+		Context("desired workers exceeding max_workers", func() {
+
+			It("should return error", func() {
+				zero := int32(0)
+				rayCluster.Spec.UpgradeStrategy.MaxSurge = &zero
+				rayCluster.Spec.NodeConfig.MaxWorkers = 2
+				rayCluster.Spec.NodeConfig.NodeTypes["worker_1"] = NodeType{VMClass: dependencyName, MaxWorkers: 1}
+				rayCluster.Spec.NodeConfig.NodeTypes["worker_2"] = NodeType{VMClass: dependencyName, MaxWorkers: 2}
+				rayCluster.Spec.AutoscalerDesiredWorkers = map[string]string{
+					"worker-a": "worker_1",
+					"worker-b": "worker_1",
+					"worker-c": "worker_2",
+				}
+
+				err := suite.GetK8sClient().Create(context.TODO(), &rayCluster)
+				Expect(err).To(HaveOccurred())
+
+				Expect(err.Error()).To(ContainSubstring("spec.autoscaler_desired_workers: Invalid value: 2: Desired workers of node type worker_1 exceed its max_workers 1"))
+				Expect(err.Error()).To(ContainSubstring("spec.autoscaler_desired_workers: Invalid value: 3: Desired workers exceed spec.common_node_config.max_workers 2"))
+			})
+		})
+
+		Context("invalid upgrade strategy", func() {
+
+			It("should return error", func() {
+				zero := int32(0)
+				rayCluster.Spec.UpgradeStrategy = UpgradeStrategy{
+					MaxSurge:       &zero,
+					MaxUnavailable: &zero,
+				}
+
+				err := suite.GetK8sClient().Create(context.TODO(), &rayCluster)
+				Expect(err).To(HaveOccurred())
+
+				Expect(err.Error()).To(ContainSubstring("spec.upgrade_strategy: Invalid value: \"max_surge/max_unavailable\""))
+			})
+		})
+
+		Context("invalid zones", func() {
+
+			It("should return error", func() {
+				rayCluster.Spec.NodeConfig.Zones = []string{"zone-a", "zone-a"}
+				nt := rayCluster.Spec.NodeConfig.NodeTypes["worker_1"]
+				nt.Zones = []string{""}
+				rayCluster.Spec.NodeConfig.NodeTypes["worker_1"] = nt
+
+				err := suite.GetK8sClient().Create(context.TODO(), &rayCluster)
+				Expect(err).To(HaveOccurred())
+
+				Expect(err.Error()).To(ContainSubstring("spec.common_node_config.zones[1]: Duplicate value: \"zone-a\""))
+				Expect(err.Error()).To(ContainSubstring("spec.common_node_config.available_node_types[worker_1].zones[0]: Invalid value: \"\": Zone must not be empty"))
+			})
+		})
+	})
+
+	Describe("VMRayCluster defaulting webhook", func() {
+		var vmclass *vmopv1.VirtualMachineClass
+
+		BeforeEach(func() {
+			vmclass = &vmopv1.VirtualMachineClass{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Name:      "gpu-class",
+				},
+				Spec: vmopv1.VirtualMachineClassSpec{
+					Hardware: vmopv1.VirtualMachineClassHardware{
+						Cpus:   8,
+						Memory: resource.MustParse("16Gi"),
+						Devices: vmopv1.VirtualDevices{
+							VGPUDevices: []vmopv1.VGPUDevice{{ProfileName: "grid_t4-16c"}},
+						},
+					},
+				},
+			}
+			Expect(suite.GetK8sClient().Create(context.TODO(), vmclass)).To(Succeed())
+
+			rayCluster = VMRayCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Name:      "defaulted",
+				},
+				Spec: VMRayClusterSpec{
+					Image:    "rayproject/ray:2.5.0",
+					HeadNode: HeadNodeConfig{NodeType: "head"},
+					NodeConfig: CommonNodeConfig{
+						VMImage:      dependencyName,
+						StorageClass: dependencyName,
+						MaxWorkers:   4,
+						NodeTypes: map[string]NodeType{
+							"head": {
+								VMClass: dependencyName,
+							},
+							"worker_1": {
+								VMClass:    "gpu-class",
+								MinWorkers: 1,
+								Resources:  NodeResource{CPU: resource.NewQuantity(2, resource.DecimalSI)},
+							},
+						},
+					},
+				},
+			}
+		})
+
+		AfterEach(func() {
+			Expect(suite.GetK8sClient().Delete(context.TODO(), vmclass)).To(Succeed())
+			Expect(client.IgnoreNotFound(suite.GetK8sClient().Delete(context.TODO(), &rayCluster))).To(Succeed())
+		})
+
+		Context("when optional fields aren't provided", func() {
+			It("should store the cluster with defaults", func() {
+				Expect(suite.GetK8sClient().Create(context.TODO(), &rayCluster)).To(Succeed())
+
+				instance := &VMRayCluster{}
+				Expect(suite.GetK8sClient().Get(context.TODO(), client.ObjectKeyFromObject(&rayCluster), instance)).To(Succeed())
+
+				Expect(*instance.Spec.HeadNode.Port).To(Equal(DefaultHeadPort))
+				Expect(instance.Spec.NodeConfig.IdleTimeoutMinutes).To(Equal(DefaultIdleTimeoutMinutes))
+				Expect(instance.Spec.NodeConfig.VMUser).To(Equal(DefaultVMUser))
+				Expect(instance.Spec.NodeConfig.NodeTypes["head"].MaxWorkers).To(BeZero())
+				Expect(instance.Spec.NodeConfig.NodeTypes["worker_1"].MaxWorkers).To(Equal(uint(4)))
+			})
+		})
+
+		Context("when resources aren't provided", func() {
+			It("should derive them from the VM class", func() {
+				Expect(suite.GetK8sClient().Create(context.TODO(), &rayCluster)).To(Succeed())
+
+				instance := &VMRayCluster{}
+				Expect(suite.GetK8sClient().Get(context.TODO(), client.ObjectKeyFromObject(&rayCluster), instance)).To(Succeed())
+
+				// Provided resources are kept, memory isn't derived & VM class of head node type has no hardware.
+				resources := instance.Spec.NodeConfig.NodeTypes["worker_1"].Resources
+				Expect(resources.CPU.Value()).To(Equal(int64(2)))
+				Expect(resources.Memory).To(BeNil())
+				Expect(resources.GPU.Value()).To(Equal(int64(1)))
+				Expect(instance.Spec.NodeConfig.NodeTypes["head"].Resources).To(Equal(NodeResource{}))
+			})
+		})
+
+		Context("when values are derived", func() {
+			It("should warn about each derived value", func() {
+				warnings := &warningRecorder{}
+				config := rest.CopyConfig(suite.GetConfig())
+				config.WarningHandler = warnings
+				k8sClient, err := client.New(config, client.Options{Scheme: suite.GetK8sClient().Scheme()})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(k8sClient.Create(context.TODO(), &rayCluster)).To(Succeed())
+				Expect(warnings.texts).To(ConsistOf(
+					"spec.common_node_config.available_node_types[worker_1].max_workers: defaulted to spec.common_node_config.max_workers 4",
+					"spec.common_node_config.available_node_types[worker_1].resources: gpu 1 defaulted from VirtualMachineClass gpu-class"))
+
+				warnings.texts = nil
+				instance := &VMRayCluster{}
+				Expect(k8sClient.Get(context.TODO(), client.ObjectKeyFromObject(&rayCluster), instance)).To(Succeed())
+				instance.Spec.NodeConfig.NodeTypes["worker_2"] = NodeType{VMClass: "gpu-class"}
+				Expect(k8sClient.Update(context.TODO(), instance)).To(Succeed())
+				Expect(warnings.texts).To(ConsistOf(
+					"spec.common_node_config.available_node_types[worker_2].max_workers: defaulted to spec.common_node_config.max_workers 4",
+					"spec.common_node_config.available_node_types[worker_2].resources: cpu 8 & gpu 1 defaulted from VirtualMachineClass gpu-class"))
+			})
+		})
+
+		Context("when the cluster is updated", func() {
+			It("should only default node types added by the update", func() {
+				Expect(suite.GetK8sClient().Create(context.TODO(), &rayCluster)).To(Succeed())
+
+				instance := &VMRayCluster{}
+				Expect(suite.GetK8sClient().Get(context.TODO(), client.ObjectKeyFromObject(&rayCluster), instance)).To(Succeed())
+				instance.Spec.NodeConfig.IdleTimeoutMinutes = 0
+				instance.Spec.NodeConfig.NodeTypes["worker_2"] = NodeType{VMClass: "gpu-class"}
+				Expect(suite.GetK8sClient().Update(context.TODO(), instance)).To(Succeed())
+
+				Expect(suite.GetK8sClient().Get(context.TODO(), client.ObjectKeyFromObject(&rayCluster), instance)).To(Succeed())
+				Expect(instance.Spec.NodeConfig.IdleTimeoutMinutes).To(BeZero())
+				Expect(instance.Spec.NodeConfig.NodeTypes["worker_2"].MaxWorkers).To(Equal(uint(4)))
+				resources := instance.Spec.NodeConfig.NodeTypes["worker_2"].Resources
+				Expect(resources.CPU.Value()).To(Equal(int64(8)))
+				Expect(resources.GPU.Value()).To(Equal(int64(1)))
+			})
+		})
+
+		Context("when fields are provided", func() {
+			It("should keep them", func() {
+				port := uint(6380)
+				rayCluster.Spec.HeadNode.Port = &port
+				rayCluster.Spec.NodeConfig.IdleTimeoutMinutes = 10
+				rayCluster.Spec.NodeConfig.VMUser = "ray-user"
+				rayCluster.Spec.EnableTLS = false
+				nt := rayCluster.Spec.NodeConfig.NodeTypes["worker_1"]
+				nt.MaxWorkers = 2
+				rayCluster.Spec.NodeConfig.NodeTypes["worker_1"] = nt
+
+				Expect(suite.GetK8sClient().Create(context.TODO(), &rayCluster)).To(Succeed())
+
+				instance := &VMRayCluster{}
+				Expect(suite.GetK8sClient().Get(context.TODO(), client.ObjectKeyFromObject(&rayCluster), instance)).To(Succeed())
+
+				Expect(*instance.Spec.HeadNode.Port).To(Equal(port))
+				Expect(instance.Spec.NodeConfig.IdleTimeoutMinutes).To(Equal(uint(10)))
+				Expect(instance.Spec.NodeConfig.VMUser).To(Equal("ray-user"))
+				Expect(instance.Spec.EnableTLS).To(BeFalse())
+				Expect(instance.Spec.NodeConfig.NodeTypes["worker_1"].MaxWorkers).To(Equal(uint(2)))
+			})
+		})
+	})
+
+	Describe("VMRayCluster conversion", func() {
+		var cluster *v1alpha2.VMRayCluster
+
+		BeforeEach(func() {
+			cluster = &v1alpha2.VMRayCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Name:      "converted",
+				},
+				Spec: v1alpha2.VMRayClusterSpec{
+					Image:    "rayproject/ray:2.5.0",
+					HeadNode: v1alpha2.HeadNodeConfig{NodeType: "head"},
+					NodeConfig: v1alpha2.CommonNodeConfig{
+						VMImage:      dependencyName,
+						StorageClass: dependencyName,
+						MaxWorkers:   2,
+						NodeTypes: map[string]v1alpha2.NodeType{
+							"head":     {VMClass: dependencyName},
+							"worker_1": {VMClass: dependencyName, MaxWorkers: 2},
+						},
+					},
+				},
+				Autoscaler: v1alpha2.VMRayClusterAutoscaler{
+					DesiredWorkers: map[string]string{"worker-a": "worker_1"},
+				},
+			}
+		})
+
+		AfterEach(func() {
+			Expect(client.IgnoreNotFound(suite.GetK8sClient().Delete(context.TODO(), cluster))).To(Succeed())
+		})
+
+		Context("when a v1alpha2 cluster is created", func() {
+			It("should be served as v1alpha1 with v1alpha1's defaults & validation applied", func() {
+				Expect(suite.GetK8sClient().Create(context.TODO(), cluster)).To(Succeed())
+
+				instance := &VMRayCluster{}
+				Expect(suite.GetK8sClient().Get(context.TODO(), client.ObjectKeyFromObject(cluster), instance)).To(Succeed())
+				Expect(instance.Spec.Image).To(Equal("rayproject/ray:2.5.0"))
+				Expect(instance.Spec.NodeConfig.MaxWorkers).To(Equal(uint(2)))
+				Expect(instance.Spec.AutoscalerDesiredWorkers).To(Equal(map[string]string{"worker-a": "worker_1"}))
+				Expect(*instance.Spec.HeadNode.Port).To(Equal(DefaultHeadPort))
+
+				converted := &v1alpha2.VMRayCluster{}
+				Expect(suite.GetK8sClient().Get(context.TODO(), client.ObjectKeyFromObject(cluster), converted)).To(Succeed())
+				Expect(*converted.Spec.HeadNode.Port).To(Equal(int32(DefaultHeadPort)))
+				Expect(converted.Spec.NodeConfig.VMUser).To(Equal(DefaultVMUser))
+				Expect(converted.Spec.ReclaimAction).To(Equal(v1alpha2.ReclaimActionSuspend))
+			})
+
+			It("should be validated", func() {
+				cluster.Autoscaler.DesiredWorkers["worker-b"] = "worker_2"
+
+				err := suite.GetK8sClient().Create(context.TODO(), cluster)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("spec.autoscaler_desired_workers[worker-b]"))
+			})
+		})
+	})
+
+	Describe("VMRayCluster update validation", func() {
+		var instance *VMRayCluster
+
+		BeforeEach(func() {
+			rayCluster = VMRayCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Name:      "updated",
+				},
+				Spec: VMRayClusterSpec{
+					Image:    "rayproject/ray:2.5.0",
+					HeadNode: HeadNodeConfig{NodeType: "head"},
+					NodeConfig: CommonNodeConfig{
+						VMImage:      dependencyName,
+						StorageClass: dependencyName,
+						MaxWorkers:   4,
+						NodeTypes: map[string]NodeType{
+							"head":     {VMClass: dependencyName},
+							"worker_1": {VMClass: dependencyName, MaxWorkers: 2},
+							"worker_2": {VMClass: dependencyName, MaxWorkers: 2},
+						},
+					},
+					AutoscalerDesiredWorkers: map[string]string{
+						"worker-a": "worker_1",
+					},
+				},
+			}
+			Expect(suite.GetK8sClient().Create(context.TODO(), &rayCluster)).To(Succeed())
+
+			instance = &VMRayCluster{}
+			Expect(suite.GetK8sClient().Get(context.TODO(), client.ObjectKeyFromObject(&rayCluster), instance)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(client.IgnoreNotFound(suite.GetK8sClient().Delete(context.TODO(), &rayCluster))).To(Succeed())
+		})
+
+		Context("when immutable fields are changed", func() {
+			It("should return error", func() {
+				port := uint(6380)
+				instance.Spec.HeadNode.Port = &port
+				instance.Spec.NodeConfig.StorageClass = "other-storage-class"
+
+				err := suite.GetK8sClient().Update(context.TODO(), instance)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("spec.common_node_config.storage_class: Invalid value: \"other-storage-class\": field is immutable"))
+				Expect(err.Error()).To(ContainSubstring("spec.head_node.port: Invalid value: 6380: field is immutable"))
+			})
+		})
+
+		Context("when resource policy is changed", func() {
+			It("should return error", func() {
+				instance.Spec.NodeConfig.ResourcePolicy = &ResourcePolicyReference{Name: "other-policy"}
+
+				err := suite.GetK8sClient().Update(context.TODO(), instance)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("spec.common_node_config.resource_policy: Invalid value"))
+				Expect(err.Error()).To(ContainSubstring("field is immutable"))
+			})
+		})
+
+		Context("when zones are changed", func() {
+			It("should warn that running nodes aren't moved", func() {
+				old := instance.DeepCopy()
+				instance.Spec.NodeConfig.Zones = []string{"zone-a", "zone-b"}
+
+				warnings, err := instance.ValidateUpdate(old)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(warnings).To(ConsistOf(ContainSubstring(
+					"spec.common_node_config.zones changed, only nodes deployed from now on are placed accordingly")))
+				Expect(suite.GetK8sClient().Update(context.TODO(), instance)).To(Succeed())
+			})
+		})
+
+		Context("when max_workers is lowered below desired workers", func() {
+			BeforeEach(func() {
+				instance.Spec.AutoscalerDesiredWorkers["worker-b"] = "worker_1"
+				instance.Status.CurrentWorkers = map[string]VMRayNodeStatus{
+					"worker-a": {NodeType: "worker_1"},
+					"worker-b": {NodeType: "worker_1"},
+				}
+			})
+
+			It("should warn that the cluster is scaled down", func() {
+				old := instance.DeepCopy()
+				instance.Spec.NodeConfig.MaxWorkers = 1
+				instance.Spec.NodeConfig.NodeTypes["worker_1"] = NodeType{VMClass: dependencyName, MaxWorkers: 1}
+				instance.Spec.NodeConfig.NodeTypes["worker_2"] = NodeType{VMClass: dependencyName, MaxWorkers: 1}
+
+				warnings, err := instance.ValidateUpdate(old)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(warnings).To(ConsistOf(
+					"max_workers 1 is less than the 2 current workers, ray autoscaler will scale the cluster down"))
+			})
+
+			It("should return error for added desired workers", func() {
+				old := instance.DeepCopy()
+				instance.Spec.NodeConfig.MaxWorkers = 1
+				instance.Spec.NodeConfig.NodeTypes["worker_1"] = NodeType{VMClass: dependencyName, MaxWorkers: 1}
+				instance.Spec.NodeConfig.NodeTypes["worker_2"] = NodeType{VMClass: dependencyName, MaxWorkers: 1}
+				instance.Spec.AutoscalerDesiredWorkers["worker-c"] = "worker_2"
+
+				_, err := instance.ValidateUpdate(old)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Desired workers exceed spec.common_node_config.max_workers 1"))
+				Expect(err.Error()).ToNot(ContainSubstring("node type worker_1"))
+			})
+		})
+
+		Context("when the cluster is being deleted", func() {
+			It("should skip validation", func() {
+				old := instance.DeepCopy()
+				now := metav1.Now()
+				instance.ObjectMeta.DeletionTimestamp = &now
+				instance.Spec.Image = "Invalid Image"
+				instance.Spec.AutoscalerDesiredWorkers["worker-b"] = "worker_3"
+
+				warnings, err := instance.ValidateUpdate(old)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(warnings).To(BeEmpty())
+			})
+		})
+
+		Context("when vm_user & head node type are changed", func() {
+			It("should return error without upgrade_head", func() {
+				instance.Spec.NodeConfig.VMUser = "ray-user"
+				instance.Spec.HeadNode.NodeType = "worker_2"
+
+				err := suite.GetK8sClient().Update(context.TODO(), instance)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("spec.common_node_config.vm_user: Forbidden"))
+				Expect(err.Error()).To(ContainSubstring("spec.head_node.node_type: Forbidden"))
+			})
+
+			It("should succeed with upgrade_head", func() {
+				instance.Spec.NodeConfig.VMUser = "ray-user"
+				instance.Spec.HeadNode.NodeType = "worker_2"
+				instance.Spec.UpgradeStrategy.UpgradeHead = true
+
+				Expect(suite.GetK8sClient().Update(context.TODO(), instance)).To(Succeed())
+			})
+		})
+
+		Context("when node type of desired workers is removed", func() {
+			It("should return error", func() {
+				delete(instance.Spec.NodeConfig.NodeTypes, "worker_1")
+
+				err := suite.GetK8sClient().Update(context.TODO(), instance)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("spec.common_node_config.available_node_types[worker_1]: Forbidden: node type can't be removed while used by workers: worker-a"))
+			})
+		})
+
+		Context("when node type of current workers is removed", func() {
+			It("should return error", func() {
+				instance.Status.CurrentWorkers = map[string]VMRayNodeStatus{
+					"worker-b": {NodeType: "worker_2"},
+				}
+				Expect(suite.GetK8sClient().Status().Update(context.TODO(), instance)).To(Succeed())
+
+				delete(instance.Spec.NodeConfig.NodeTypes, "worker_2")
+				err := suite.GetK8sClient().Update(context.TODO(), instance)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("node type can't be removed while used by workers: worker-b"))
+			})
+		})
+
+		Context("when an expired cluster is resumed", func() {
+			BeforeEach(func() {
+				expiresAt := metav1.NewTime(time.Now().Add(-time.Hour))
+				instance.Spec.ExpiresAt = &expiresAt
+				instance.Spec.Suspend = true
+				Expect(suite.GetK8sClient().Update(context.TODO(), instance)).To(Succeed())
+			})
+
+			It("should return error", func() {
+				instance.Spec.Suspend = false
+
+				err := suite.GetK8sClient().Update(context.TODO(), instance)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("spec.suspend: Forbidden: cluster expired at"))
+			})
+
+			It("should succeed with extended expires_at", func() {
+				expiresAt := metav1.NewTime(time.Now().Add(time.Hour))
+				instance.Spec.ExpiresAt = &expiresAt
+				instance.Spec.Suspend = false
+
+				Expect(suite.GetK8sClient().Update(context.TODO(), instance)).To(Succeed())
+			})
+
+			It("should succeed for protected cluster", func() {
+				if instance.ObjectMeta.Annotations == nil {
+					instance.ObjectMeta.Annotations = map[string]string{}
+				}
+				instance.ObjectMeta.Annotations[ProtectedAnnotation] = "true"
+				instance.Spec.Suspend = false
+
+				Expect(suite.GetK8sClient().Update(context.TODO(), instance)).To(Succeed())
+			})
+		})
+
+		Context("when unused node type is removed", func() {
+			It("should succeed", func() {
+				delete(instance.Spec.NodeConfig.NodeTypes, "worker_2")
+
+				Expect(suite.GetK8sClient().Update(context.TODO(), instance)).To(Succeed())
+			})
+		})
+	})
+
+	Describe("VMRayCluster dependency validation", func() {
+
+		BeforeEach(func() {
+			rayCluster = VMRayCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Name:      "dependent",
+				},
+				Spec: VMRayClusterSpec{
+					Image:    "rayproject/ray:2.5.0",
+					HeadNode: HeadNodeConfig{NodeType: "head"},
+					NodeConfig: CommonNodeConfig{
+						VMImage:      dependencyName,
+						StorageClass: dependencyName,
+						MaxWorkers:   2,
+						NodeTypes: map[string]NodeType{
+							"head":     {VMClass: dependencyName},
+							"worker_1": {VMClass: dependencyName, MaxWorkers: 2},
+						},
+					},
+				},
+			}
+		})
+
+		AfterEach(func() {
+			Expect(client.IgnoreNotFound(suite.GetK8sClient().Delete(context.TODO(), &rayCluster))).To(Succeed())
+		})
+
+		Context("when VM image doesn't exist", func() {
+			It("should return error", func() {
+				rayCluster.Spec.NodeConfig.VMImage = "missing-image"
+
+				err := suite.GetK8sClient().Create(context.TODO(), &rayCluster)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("spec.common_node_config.vm_image: Invalid value: \"missing-image\": Neither VirtualMachineImage in namespace default nor ClusterVirtualMachineImage exists"))
+			})
+		})
+
+		Context("when VM image is a ClusterVirtualMachineImage", func() {
+			It("should succeed", func() {
+				cvmi := &vmopv1.ClusterVirtualMachineImage{
+					ObjectMeta: metav1.ObjectMeta{Name: "cluster-image"},
+				}
+				Expect(suite.GetK8sClient().Create(context.TODO(), cvmi)).To(Succeed())
+				defer func() {
+					Expect(suite.GetK8sClient().Delete(context.TODO(), cvmi)).To(Succeed())
+				}()
+				rayCluster.Spec.NodeConfig.VMImage = "cluster-image"
+
+				Expect(suite.GetK8sClient().Create(context.TODO(), &rayCluster)).To(Succeed())
+			})
+		})
+
+		Context("when VM class isn't bound to the namespace", func() {
+			It("should return error", func() {
+				rayCluster.Spec.NodeConfig.NodeTypes["worker_1"] = NodeType{VMClass: "missing-class", MaxWorkers: 2}
+
+				err := suite.GetK8sClient().Create(context.TODO(), &rayCluster)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("spec.common_node_config.available_node_types[worker_1].vm_class: Invalid value: \"missing-class\": VirtualMachineClass isn't bound to namespace default"))
+			})
+
+			It("should return error when added by update", func() {
+				Expect(suite.GetK8sClient().Create(context.TODO(), &rayCluster)).To(Succeed())
+
+				rayCluster.Spec.NodeConfig.NodeTypes["worker_2"] = NodeType{VMClass: "missing-class", MaxWorkers: 2}
+				err := suite.GetK8sClient().Update(context.TODO(), &rayCluster)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("spec.common_node_config.available_node_types[worker_2].vm_class"))
+			})
+		})
+
+		Context("when resources exceed the VM class", func() {
+			It("should describe the exceeded resources", func() {
+				hardware := vmopv1.VirtualMachineClassHardware{Cpus: 2, Memory: resource.MustParse("4Gi")}
+				cpu, memory, gpu := resource.MustParse("4"), resource.MustParse("4Gi"), resource.MustParse("1")
+
+				Expect(NodeResource{CPU: &cpu, Memory: &memory, GPU: &gpu}.ExceededBy(HardwareResources(hardware))).To(
+					Equal([]string{"cpu 4 > 2", "gpu 1 > 0"}))
+				Expect(NodeResource{Memory: &memory}.ExceededBy(HardwareResources(hardware))).To(BeEmpty())
+				// Hardware without CPUs & memory isn't compared.
+				Expect(NodeResource{CPU: &cpu, Memory: &memory}.ExceededBy(HardwareResources(vmopv1.VirtualMachineClassHardware{}))).To(BeEmpty())
+			})
+
+			It("should admit the cluster with a warning", func() {
+				vmclass := &vmopv1.VirtualMachineClass{}
+				key := client.ObjectKey{Namespace: "default", Name: dependencyName}
+				Expect(suite.GetK8sClient().Get(context.TODO(), key, vmclass)).To(Succeed())
+				vmclass.Spec.Hardware = vmopv1.VirtualMachineClassHardware{Cpus: 4, Memory: resource.MustParse("8Gi")}
+				Expect(suite.GetK8sClient().Update(context.TODO(), vmclass)).To(Succeed())
+
+				cpu := resource.MustParse("64")
+				rayCluster.Spec.NodeConfig.NodeTypes["worker_1"] = NodeType{VMClass: dependencyName, MaxWorkers: 2,
+					Resources: NodeResource{CPU: &cpu}}
+
+				warnings := &warningRecorder{}
+				config := rest.CopyConfig(suite.GetConfig())
+				config.WarningHandler = warnings
+				k8sClient, err := client.New(config, client.Options{Scheme: suite.GetK8sClient().Scheme()})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(k8sClient.Create(context.TODO(), &rayCluster)).To(Succeed())
+				Expect(warnings.texts).To(ConsistOf(
+					"spec.common_node_config.available_node_types[head].resources: cpu 4 defaulted from VirtualMachineClass vmray-dependency",
+					"spec.common_node_config.available_node_types[worker_1].resources exceed VirtualMachineClass vmray-dependency: cpu 64 > 4"))
+			})
+		})
+
+		Context("when node type with GPUs has a VM class without devices", func() {
+			It("should return error", func() {
+				gpu := resource.MustParse("1")
+				rayCluster.Spec.NodeConfig.NodeTypes["worker_1"] = NodeType{VMClass: dependencyName, MaxWorkers: 2,
+					Resources: NodeResource{GPU: &gpu}}
+
+				err := suite.GetK8sClient().Create(context.TODO(), &rayCluster)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("spec.common_node_config.available_node_types[worker_1].resources.gpu: " +
+					"Invalid value: \"1\": VirtualMachineClass vmray-dependency has no vGPU or PCI devices"))
+			})
+		})
+
+		Context("when resource policy is set", func() {
+			var policy *vmopv1.VirtualMachineSetResourcePolicy
+
+			BeforeEach(func() {
+				policy = &vmopv1.VirtualMachineSetResourcePolicy{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "ray-policy"},
+					Spec:       vmopv1.VirtualMachineSetResourcePolicySpec{ClusterModuleGroups: []string{"ray-nodes"}},
+				}
+				Expect(suite.GetK8sClient().Create(context.TODO(), policy)).To(Succeed())
+			})
+
+			AfterEach(func() {
+				Expect(suite.GetK8sClient().Delete(context.TODO(), policy)).To(Succeed())
+			})
+
+			It("should return error for missing policy", func() {
+				rayCluster.Spec.NodeConfig.ResourcePolicy = &ResourcePolicyReference{Name: "missing-policy"}
+
+				err := suite.GetK8sClient().Create(context.TODO(), &rayCluster)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("spec.common_node_config.resource_policy.name: Invalid value: \"missing-policy\": VirtualMachineSetResourcePolicy doesn't exist in namespace default"))
+			})
+
+			It("should return error for cluster module group missing from the policy", func() {
+				rayCluster.Spec.NodeConfig.ResourcePolicy = &ResourcePolicyReference{Name: "ray-policy", ClusterModuleGroup: "other-nodes"}
+
+				err := suite.GetK8sClient().Create(context.TODO(), &rayCluster)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("spec.common_node_config.resource_policy.cluster_module_group: Invalid value: \"other-nodes\": Isn't a cluster module group of VirtualMachineSetResourcePolicy ray-policy"))
+			})
+
+			It("should succeed for existing policy & group", func() {
+				rayCluster.Spec.NodeConfig.ResourcePolicy = &ResourcePolicyReference{Name: "ray-policy", ClusterModuleGroup: "ray-nodes"}
+
+				Expect(suite.GetK8sClient().Create(context.TODO(), &rayCluster)).To(Succeed())
+			})
+		})
+
+		Context("when storage classes are assigned by ResourceQuota", func() {
+			var quota *corev1.ResourceQuota
+
+			BeforeEach(func() {
+				quota = &corev1.ResourceQuota{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "storage-quota"},
+					Spec: corev1.ResourceQuotaSpec{
+						Hard: corev1.ResourceList{
+							"assigned-storage-class.storageclass.storage.k8s.io/requests.storage": resource.MustParse("10Gi"),
+						},
+					},
+				}
+				Expect(suite.GetK8sClient().Create(context.TODO(), quota)).To(Succeed())
+			})
+
+			AfterEach(func() {
+				Expect(suite.GetK8sClient().Delete(context.TODO(), quota)).To(Succeed())
+			})
+
+			It("should return error for unassigned storage class", func() {
+				err := suite.GetK8sClient().Create(context.TODO(), &rayCluster)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("spec.common_node_config.storage_class: Invalid value: \"vmray-dependency\": StorageClass isn't assigned to namespace default by its ResourceQuotas"))
+			})
+
+			It("should succeed for assigned storage class", func() {
+				quota.Spec.Hard[dependencyName+".storageclass.storage.k8s.io/requests.storage"] = resource.MustParse("10Gi")
+				Expect(suite.GetK8sClient().Update(context.TODO(), quota)).To(Succeed())
+
+				Expect(suite.GetK8sClient().Create(context.TODO(), &rayCluster)).To(Succeed())
+			})
+		})
+	})
+
+	Describe("Shipped samples", func() {
+		It("should strictly decode", func() {
+			samples := map[string]interface{}{
+				"vmray_v1alpha1_vmraycluster.yaml": &VMRayCluster{},
+				"vmray_v1alpha1_vmrayjob.yaml":     &VMRayJob{},
+				"vmray_v1alpha1_vmrayservice.yaml": &VMRayService{},
+			}
+			files, err := filepath.Glob(filepath.Join("..", "..", "config", "samples", "vmray_*.yaml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(HaveLen(len(samples)))
+
+			for _, file := range files {
+				obj, ok := samples[filepath.Base(file)]
+				Expect(ok).To(BeTrue(), "sample %s isn't decoded", file)
+				data, err := os.ReadFile(file)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(yaml.UnmarshalStrict(data, obj)).To(Succeed(), "sample %s", file)
+			}
+		})
 	})
 }
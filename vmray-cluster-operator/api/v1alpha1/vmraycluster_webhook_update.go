@@ -0,0 +1,214 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+/*
+Changes to a running cluster are applied by replacing its outdated nodes, see
+upgrade_strategy. Fields which aren't part of a node's configuration, like the
+storage class, network or resource policy of its VM, would silently apply only to
+new nodes, so they're immutable. Zones are the exception, changing them doesn't
+move running nodes but lets new nodes be deployed elsewhere, e.g. off a zone
+under maintenance. Fields whose change breaks the head node's contact with its
+workers are only allowed when the head node is replaced along with the workers.
+*/
+
+// validateTransition validates the changes from old to the cluster.
+func (r *VMRayCluster) validateTransition(old *VMRayCluster) field.ErrorList {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+	nodeConfigPath := specPath.Child("common_node_config")
+
+	allErrs = append(allErrs, apivalidation.ValidateImmutableField(r.Spec.NodeConfig.StorageClass,
+		old.Spec.NodeConfig.StorageClass, nodeConfigPath.Child("storage_class"))...)
+	allErrs = append(allErrs, apivalidation.ValidateImmutableField(r.Spec.NodeConfig.Network,
+		old.Spec.NodeConfig.Network, nodeConfigPath.Child("network"))...)
+	allErrs = append(allErrs, apivalidation.ValidateImmutableField(r.Spec.NodeConfig.ResourcePolicy,
+		old.Spec.NodeConfig.ResourcePolicy, nodeConfigPath.Child("resource_policy"))...)
+	allErrs = append(allErrs, apivalidation.ValidateImmutableField(int64(getHeadPort(r)),
+		int64(getHeadPort(old)), specPath.Child("head_node").Child("port"))...)
+
+	if !r.Spec.UpgradeStrategy.UpgradeHead {
+		if getVMUser(r) != getVMUser(old) {
+			allErrs = append(allErrs, field.Forbidden(nodeConfigPath.Child("vm_user"),
+				"may only be changed with spec.upgrade_strategy.upgrade_head set, as head node has to be replaced"))
+		}
+		if r.Spec.HeadNode.NodeType != old.Spec.HeadNode.NodeType {
+			allErrs = append(allErrs, field.Forbidden(specPath.Child("head_node").Child("node_type"),
+				"may only be changed with spec.upgrade_strategy.upgrade_head set, as head node has to be replaced"))
+		}
+	}
+
+	// An expired cluster would be suspended again right after it's resumed.
+	if old.Spec.Suspend && !r.Spec.Suspend && r.Spec.ExpiresAt != nil && !r.Spec.ExpiresAt.After(time.Now()) &&
+		r.ObjectMeta.Annotations[ProtectedAnnotation] != "true" {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("suspend"),
+			fmt.Sprintf("cluster expired at %s, extend or remove spec.expires_at to resume it",
+				r.Spec.ExpiresAt.UTC().Format(time.RFC3339))))
+	}
+
+	// Node types of desired & current workers can't be removed.
+	for name := range old.Spec.NodeConfig.NodeTypes {
+		if _, ok := r.Spec.NodeConfig.NodeTypes[name]; ok {
+			continue
+		}
+		if workers := getWorkersOfNodeType(r, old, name); len(workers) > 0 {
+			allErrs = append(allErrs, field.Forbidden(nodeConfigPath.Child("available_node_types").Key(name),
+				fmt.Sprintf("node type can't be removed while used by workers: %s", strings.Join(workers, ", "))))
+		}
+	}
+	return allErrs
+}
+
+// updateWarnings returns warnings about the changes from old to the cluster
+// which disrupt the running cluster.
+func (r *VMRayCluster) updateWarnings(old *VMRayCluster) admission.Warnings {
+	warnings := admission.Warnings{}
+
+	if changed := changedClusterConfig(r, old); len(changed) > 0 {
+		warning := fmt.Sprintf("%s changed, all workers will be replaced", strings.Join(changed, ", "))
+		if !r.Spec.UpgradeStrategy.UpgradeHead {
+			warning += ", head node stays outdated as spec.upgrade_strategy.upgrade_head isn't set"
+		} else {
+			warning += " followed by head node, the cluster is unavailable while head node is replaced"
+		}
+		warnings = append(warnings, warning)
+	}
+
+	names := make([]string, 0, len(r.Spec.NodeConfig.NodeTypes))
+	for name := range r.Spec.NodeConfig.NodeTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		nt := r.Spec.NodeConfig.NodeTypes[name]
+		oldnt, ok := old.Spec.NodeConfig.NodeTypes[name]
+		sameResources := equality.Semantic.DeepEqual(nt.Resources, oldnt.Resources)
+		if !ok || (nt.VMClass == oldnt.VMClass && sameResources) {
+			continue
+		}
+		if sameResources && r.Spec.UpgradeStrategy.ResizePolicy == ResizePolicyInPlace {
+			warnings = append(warnings, fmt.Sprintf("vm_class of node type %s changed, its workers will be "+
+				"powered off to be resized", name))
+		} else {
+			warnings = append(warnings, fmt.Sprintf("node type %s changed, its workers will be replaced", name))
+		}
+	}
+
+	if changed := changedZones(r, old); len(changed) > 0 {
+		warnings = append(warnings, fmt.Sprintf("%s changed, only nodes deployed from now on are placed "+
+			"accordingly, running nodes stay in their zones", strings.Join(changed, ", ")))
+	}
+
+	if current := len(old.Status.CurrentWorkers); r.Spec.NodeConfig.MaxWorkers < uint(current) {
+		warnings = append(warnings, fmt.Sprintf("max_workers %d is less than the %d current workers, "+
+			"ray autoscaler will scale the cluster down", r.Spec.NodeConfig.MaxWorkers, current))
+	}
+
+	if len(warnings) == 0 {
+		return nil
+	}
+	return warnings
+}
+
+// changedClusterConfig returns paths of the changed fields of the configuration
+// shared by all ray nodes, i.e. the ones rendered into their cloud init.
+func changedClusterConfig(r, old *VMRayCluster) []string {
+	changed := []string{}
+	fields := []struct {
+		path     string
+		new, old interface{}
+	}{
+		{"spec.image", r.Spec.Image, old.Spec.Image},
+		{"spec.enable_tls", r.Spec.EnableTLS, old.Spec.EnableTLS},
+		{"spec.docker_config", r.Spec.DockerConfig, old.Spec.DockerConfig},
+		{"spec.common_node_config.vm_image", r.Spec.NodeConfig.VMImage, old.Spec.NodeConfig.VMImage},
+		{"spec.common_node_config.vm_user", getVMUser(r), getVMUser(old)},
+		{"spec.common_node_config.vm_password_salt_hash", r.Spec.NodeConfig.VMPasswordSaltHash, old.Spec.NodeConfig.VMPasswordSaltHash},
+		{"spec.common_node_config.setup_commands", r.Spec.NodeConfig.SetupCommands, old.Spec.NodeConfig.SetupCommands},
+		{"spec.common_node_config.initialization_commands", r.Spec.NodeConfig.InitializationCommands, old.Spec.NodeConfig.InitializationCommands},
+		{"spec.head_node.setup_commands", r.Spec.HeadNode.SetupCommands, old.Spec.HeadNode.SetupCommands},
+		{"spec.worker_node.setup_commands", r.Spec.WorkerNode.SetupCommands, old.Spec.WorkerNode.SetupCommands},
+	}
+	for _, f := range fields {
+		if !reflect.DeepEqual(f.new, f.old) {
+			changed = append(changed, f.path)
+		}
+	}
+	return changed
+}
+
+// changedZones returns paths of the changed zone placement fields, common & of node types.
+func changedZones(r, old *VMRayCluster) []string {
+	changed := []string{}
+	if !reflect.DeepEqual(r.Spec.NodeConfig.Zones, old.Spec.NodeConfig.Zones) {
+		changed = append(changed, "spec.common_node_config.zones")
+	}
+	if r.Spec.NodeConfig.ZoneSpread != old.Spec.NodeConfig.ZoneSpread {
+		changed = append(changed, "spec.common_node_config.zone_spread")
+	}
+	names := make([]string, 0, len(r.Spec.NodeConfig.NodeTypes))
+	for name := range r.Spec.NodeConfig.NodeTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		oldnt, ok := old.Spec.NodeConfig.NodeTypes[name]
+		if ok && !reflect.DeepEqual(r.Spec.NodeConfig.NodeTypes[name].Zones, oldnt.Zones) {
+			changed = append(changed, fmt.Sprintf("spec.common_node_config.available_node_types[%s].zones", name))
+		}
+	}
+	return changed
+}
+
+// getWorkersOfNodeType returns sorted names of the desired & current workers of the node type.
+func getWorkersOfNodeType(r, old *VMRayCluster, nodeType string) []string {
+	workers := make(map[string]bool)
+	for name, nt := range r.Spec.AutoscalerDesiredWorkers {
+		if nt == nodeType {
+			workers[name] = true
+		}
+	}
+	for name, status := range old.Status.CurrentWorkers {
+		if status.NodeType == nodeType {
+			workers[name] = true
+		}
+	}
+	names := make([]string, 0, len(workers))
+	for name := range workers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// getHeadPort returns the effective head port, clusters stored before
+// defaulting may not have one.
+func getHeadPort(r *VMRayCluster) uint {
+	if r.Spec.HeadNode.Port == nil {
+		return DefaultHeadPort
+	}
+	return *r.Spec.HeadNode.Port
+}
+
+// getVMUser returns the effective VM user, clusters stored before
+// defaulting may not have one.
+func getVMUser(r *VMRayCluster) string {
+	if r.Spec.NodeConfig.VMUser == "" {
+		return DefaultVMUser
+	}
+	return r.Spec.NodeConfig.VMUser
+}
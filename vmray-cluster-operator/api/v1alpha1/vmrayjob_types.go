@@ -0,0 +1,154 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// Conditions which could be observed on a VMRayJob.
+	VMRayJobConditionClusterReady  = "ClusterReady"
+	VMRayJobConditionJobSubmitted  = "JobSubmitted"
+	VMRayJobConditionClusterDelete = "DeleteCluster"
+
+	// List of reasons for the observed VMRayJob conditions.
+	InvalidJobSpecReason          = "InvalidJobSpec"
+	FailureToCreateClusterReason  = "FailureToCreateCluster"
+	FailureToSubmitJobReason      = "FailureToSubmitJob"
+	FailureToFetchJobStatusReason = "FailureToFetchJobStatus"
+	FailureToDeleteClusterReason  = "FailureToDeleteCluster"
+	ClusterNotReadyReason         = "ClusterNotReady"
+	ClusterNotOwnedReason         = "ClusterNotOwned"
+	JobSubmittedReason            = "JobSubmitted"
+	EphemeralClusterDeletedReason = "EphemeralClusterDeleted"
+
+	// VMRayJobCreatedByLabel is set on ephemeral clusters with name of the owning VMRayJob.
+	VMRayJobCreatedByLabel = "vmray.kubernetes.io/created-by-job"
+
+	vmrayJobEphemeralClusterPostfix = "-cluster"
+)
+
+// VMRayJobSpec defines the desired state of VMRayJob
+// +kubebuilder:validation:XValidation:rule="has(self.cluster_ref) != has(self.cluster_spec)",message="exactly one of cluster_ref or cluster_spec must be set"
+type VMRayJobSpec struct {
+	// Name of an existing VMRayCluster in the same namespace to submit the job to.
+	// +optional
+	ClusterRef string `json:"cluster_ref,omitempty"`
+	// Spec of an ephemeral VMRayCluster created for this job and
+	// deleted once the job finishes and ttl_seconds_after_finished elapses.
+	// +optional
+	ClusterSpec *VMRayClusterSpec `json:"cluster_spec,omitempty"`
+	// Command executed on the ray head node to run the job, e.g. `python main.py`.
+	Entrypoint string `json:"entrypoint"`
+	// Ray runtime environment of the job, e.g. working_dir, pip or env_vars.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +optional
+	RuntimeEnv *runtime.RawExtension `json:"runtime_env,omitempty"`
+	// Arbitrary metadata attached to the ray job.
+	// +optional
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Number of seconds after the job finishes after which the ephemeral cluster
+	// is deleted. Clusters referenced via cluster_ref are never deleted.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TTLSecondsAfterFinished int32 `json:"ttl_seconds_after_finished,omitempty"`
+}
+
+// RayJobStatus mirrors ray's job status.
+type RayJobStatus string
+
+const (
+	RayJobPending   RayJobStatus = "PENDING"
+	RayJobRunning   RayJobStatus = "RUNNING"
+	RayJobStopped   RayJobStatus = "STOPPED"
+	RayJobSucceeded RayJobStatus = "SUCCEEDED"
+	RayJobFailed    RayJobStatus = "FAILED"
+)
+
+// VMRayJobDeploymentState tracks the lifecycle of VMRayJob itself, i.e.
+// bringing up the cluster, running the job & cleaning up afterwards.
+type VMRayJobDeploymentState string
+
+const (
+	JOB_INITIALIZING        VMRayJobDeploymentState = "initializing"
+	JOB_WAITING_FOR_CLUSTER VMRayJobDeploymentState = "waiting_for_cluster"
+	JOB_RUNNING             VMRayJobDeploymentState = "running"
+	JOB_COMPLETE            VMRayJobDeploymentState = "complete"
+	JOB_FAILED              VMRayJobDeploymentState = "failed"
+)
+
+// VMRayJobStatus defines the observed state of VMRayJob
+type VMRayJobStatus struct {
+	// Lifecycle state of the VMRayJob.
+	JobDeploymentState VMRayJobDeploymentState `json:"job_deployment_state,omitempty"`
+	// Name of the VMRayCluster the job is submitted to.
+	ClusterName string `json:"cluster_name,omitempty"`
+	// Submission ID of the job in ray.
+	SubmissionID string `json:"submission_id,omitempty"`
+	// Status of the job as reported by ray jobs API.
+	JobStatus RayJobStatus `json:"job_status,omitempty"`
+	// Message reported by ray for the job.
+	Message string `json:"message,omitempty"`
+	// Tail of the job's logs.
+	Logs string `json:"logs,omitempty"`
+	// Address of the ray dashboard the job was submitted to.
+	DashboardURL string `json:"dashboard_url,omitempty"`
+	// Time at which the job was submitted.
+	StartTime *metav1.Time `json:"start_time,omitempty"`
+	// Time at which the job reached a terminal status.
+	EndTime *metav1.Time `json:"end_time,omitempty"`
+	// Conditions describes the observed conditions of the VMRayJob.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// IsTerminal returns true if ray job will not transition to any other status.
+func (s RayJobStatus) IsTerminal() bool {
+	return s == RayJobStopped || s == RayJobSucceeded || s == RayJobFailed
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=`.status.cluster_name`
+// +kubebuilder:printcolumn:name="Job Status",type=string,JSONPath=`.status.job_status`
+// +kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.job_deployment_state`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// VMRayJob is the Schema for the vmrayjobs API
+type VMRayJob struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// The configuration of the ray job
+	Spec VMRayJobSpec `json:"spec,omitempty"`
+	// The ray job status
+	Status VMRayJobStatus `json:"status,omitempty"`
+}
+
+// GetClusterName returns name of the VMRayCluster the job runs on,
+// either the referenced one or the job's ephemeral cluster.
+func (j *VMRayJob) GetClusterName() string {
+	if j.Spec.ClusterRef != "" {
+		return j.Spec.ClusterRef
+	}
+	return j.ObjectMeta.Name + vmrayJobEphemeralClusterPostfix
+}
+
+// +kubebuilder:object:root=true
+
+// VMRayJobList contains a list of VMRayJob
+type VMRayJobList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	// The list of ray jobs
+	Items []VMRayJob `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VMRayJob{}, &VMRayJobList{})
+}
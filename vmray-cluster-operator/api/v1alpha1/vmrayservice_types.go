@@ -0,0 +1,133 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// Conditions which could be observed on a VMRayService.
+	VMRayServiceConditionServeReady     = "ServeReady"
+	VMRayServiceConditionServiceDeploy  = "DeployServeService"
+	VMRayServiceConditionClusterUpgrade = "UpgradeCluster"
+
+	// List of reasons for the observed VMRayService conditions.
+	FailureToApplyServeConfigReason   = "FailureToApplyServeConfig"
+	FailureToFetchServeStatusReason   = "FailureToFetchServeStatus"
+	FailureToDeployServeServiceReason = "FailureToDeployServeService"
+	ServeApplicationsUnhealthyReason  = "ServeApplicationsUnhealthy"
+
+	// VMRayServiceCreatedByLabel is set on clusters with name of the owning VMRayService.
+	VMRayServiceCreatedByLabel = "vmray.kubernetes.io/created-by-service"
+	// ServeConfigHashAnnotation holds hash of the cluster & serve config a cluster was created for.
+	ServeConfigHashAnnotation = "vmray.kubernetes.io/serve-config-hash"
+	// ServeConfigAppliedAnnotation is set on a cluster once serve config is applied to it.
+	ServeConfigAppliedAnnotation = "vmray.kubernetes.io/serve-config-applied"
+
+	vmrayServiceServePostfix = "-serve"
+)
+
+// VMRayServiceSpec defines the desired state of VMRayService
+type VMRayServiceSpec struct {
+	// Spec of the VMRayCluster serve applications run on. Any change to it, or to
+	// serve_config, brings up a new cluster which replaces the current one once
+	// its serve applications are healthy.
+	ClusterSpec VMRayClusterSpec `json:"cluster_spec"`
+	// Ray serve config, i.e. body of ray dashboard's `PUT /api/serve/applications/`.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	ServeConfig runtime.RawExtension `json:"serve_config"`
+}
+
+// ServeDeploymentStatus captures status of a single serve deployment.
+type ServeDeploymentStatus struct {
+	// Status of deployment as reported by ray serve, e.g. HEALTHY.
+	Status string `json:"status,omitempty"`
+	// Message reported by ray serve for the deployment.
+	Message string `json:"message,omitempty"`
+}
+
+// ServeApplicationStatus captures status of a serve application and its deployments.
+type ServeApplicationStatus struct {
+	// Status of application as reported by ray serve, e.g. RUNNING.
+	Status string `json:"status,omitempty"`
+	// Message reported by ray serve for the application.
+	Message string `json:"message,omitempty"`
+	// Statuses of application's deployments keyed by deployment name.
+	Deployments map[string]ServeDeploymentStatus `json:"deployments,omitempty"`
+}
+
+// VMRayServiceClusterStatus captures state of a cluster serving or about to serve applications.
+type VMRayServiceClusterStatus struct {
+	// Name of the VMRayCluster.
+	ClusterName string `json:"cluster_name,omitempty"`
+	// Hash of the cluster & serve config the cluster was created for.
+	ConfigHash string `json:"config_hash,omitempty"`
+	// Statuses of serve applications keyed by application name.
+	Applications map[string]ServeApplicationStatus `json:"applications,omitempty"`
+}
+
+type VMRayServiceState string
+
+const (
+	SERVICE_PENDING   VMRayServiceState = "pending"
+	SERVICE_RUNNING   VMRayServiceState = "running"
+	SERVICE_UPGRADING VMRayServiceState = "upgrading"
+	SERVICE_UNHEALTHY VMRayServiceState = "unhealthy"
+)
+
+// VMRayServiceStatus defines the observed state of VMRayService
+type VMRayServiceStatus struct {
+	// Overall state of the service.
+	ServiceState VMRayServiceState `json:"service_state,omitempty"`
+	// Cluster currently receiving serve traffic.
+	ActiveCluster VMRayServiceClusterStatus `json:"active_cluster,omitempty"`
+	// Cluster being brought up to replace the active cluster.
+	PendingCluster VMRayServiceClusterStatus `json:"pending_cluster,omitempty"`
+	// Address of ray serve's HTTP endpoint, e.g. `http://10.0.0.1:8000`.
+	ServeEndpoint string `json:"serve_endpoint,omitempty"`
+	// Conditions describes the observed conditions of the VMRayService.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.service_state`
+// +kubebuilder:printcolumn:name="Active Cluster",type=string,JSONPath=`.status.active_cluster.cluster_name`
+// +kubebuilder:printcolumn:name="Endpoint",type=string,JSONPath=`.status.serve_endpoint`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// VMRayService is the Schema for the vmrayservices API
+type VMRayService struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// The configuration of the ray serve service
+	Spec VMRayServiceSpec `json:"spec,omitempty"`
+	// The ray serve service status
+	Status VMRayServiceStatus `json:"status,omitempty"`
+}
+
+// GetServeServiceName returns name of the stable VM service exposing ray serve.
+func (s *VMRayService) GetServeServiceName() string {
+	return s.ObjectMeta.Name + vmrayServiceServePostfix
+}
+
+// +kubebuilder:object:root=true
+
+// VMRayServiceList contains a list of VMRayService
+type VMRayServiceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	// The list of ray serve services
+	Items []VMRayService `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VMRayService{}, &VMRayServiceList{})
+}
@@ -9,6 +9,7 @@ package v1alpha1
 
 import (
 	"github.com/vmware-tanzu/vm-operator/api/v1alpha2"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
@@ -40,7 +41,7 @@ func (in *CommonNodeConfig) DeepCopyInto(out *CommonNodeConfig) {
 		in, out := &in.NodeTypes, &out.NodeTypes
 		*out = make(map[string]NodeType, len(*in))
 		for key, val := range *in {
-			(*out)[key] = val
+			(*out)[key] = *val.DeepCopy()
 		}
 	}
 	if in.SetupCommands != nil {
@@ -53,6 +54,16 @@ func (in *CommonNodeConfig) DeepCopyInto(out *CommonNodeConfig) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Zones != nil {
+		in, out := &in.Zones, &out.Zones
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ResourcePolicy != nil {
+		in, out := &in.ResourcePolicy, &out.ResourcePolicy
+		*out = new(ResourcePolicyReference)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CommonNodeConfig.
@@ -108,6 +119,28 @@ func (in *HeadNodeConfig) DeepCopy() *HeadNodeConfig {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NodeResource) DeepCopyInto(out *NodeResource) {
 	*out = *in
+	if in.CPU != nil {
+		in, out := &in.CPU, &out.CPU
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Memory != nil {
+		in, out := &in.Memory, &out.Memory
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.GPU != nil {
+		in, out := &in.GPU, &out.GPU
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.CustomResources != nil {
+		in, out := &in.CustomResources, &out.CustomResources
+		*out = make(map[string]resource.Quantity, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeResource.
@@ -123,7 +156,12 @@ func (in *NodeResource) DeepCopy() *NodeResource {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NodeType) DeepCopyInto(out *NodeType) {
 	*out = *in
-	out.Resources = in.Resources
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.Zones != nil {
+		in, out := &in.Zones, &out.Zones
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeType.
@@ -136,6 +174,83 @@ func (in *NodeType) DeepCopy() *NodeType {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourcePolicyReference) DeepCopyInto(out *ResourcePolicyReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourcePolicyReference.
+func (in *ResourcePolicyReference) DeepCopy() *ResourcePolicyReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourcePolicyReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServeApplicationStatus) DeepCopyInto(out *ServeApplicationStatus) {
+	*out = *in
+	if in.Deployments != nil {
+		in, out := &in.Deployments, &out.Deployments
+		*out = make(map[string]ServeDeploymentStatus, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServeApplicationStatus.
+func (in *ServeApplicationStatus) DeepCopy() *ServeApplicationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ServeApplicationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServeDeploymentStatus) DeepCopyInto(out *ServeDeploymentStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServeDeploymentStatus.
+func (in *ServeDeploymentStatus) DeepCopy() *ServeDeploymentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ServeDeploymentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeStrategy) DeepCopyInto(out *UpgradeStrategy) {
+	*out = *in
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxSurge != nil {
+		in, out := &in.MaxSurge, &out.MaxSurge
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeStrategy.
+func (in *UpgradeStrategy) DeepCopy() *UpgradeStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VMRayCluster) DeepCopyInto(out *VMRayCluster) {
 	*out = *in
@@ -210,6 +325,16 @@ func (in *VMRayClusterSpec) DeepCopyInto(out *VMRayClusterSpec) {
 		}
 	}
 	out.DockerConfig = in.DockerConfig
+	if in.TTLSecondsAfterIdle != nil {
+		in, out := &in.TTLSecondsAfterIdle, &out.TTLSecondsAfterIdle
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+	in.UpgradeStrategy.DeepCopyInto(&out.UpgradeStrategy)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VMRayClusterSpec.
@@ -241,6 +366,15 @@ func (in *VMRayClusterStatus) DeepCopyInto(out *VMRayClusterStatus) {
 		}
 	}
 	out.VMServiceStatus = in.VMServiceStatus
+	if in.LastActivityTime != nil {
+		in, out := &in.LastActivityTime, &out.LastActivityTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(VMRayClusterUpgradeStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VMRayClusterStatus.
@@ -253,6 +387,206 @@ func (in *VMRayClusterStatus) DeepCopy() *VMRayClusterStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VMRayClusterUpgradeStatus) DeepCopyInto(out *VMRayClusterUpgradeStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Replacements != nil {
+		in, out := &in.Replacements, &out.Replacements
+		*out = make(map[string]VMRayWorkerReplacement, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VMRayClusterUpgradeStatus.
+func (in *VMRayClusterUpgradeStatus) DeepCopy() *VMRayClusterUpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VMRayClusterUpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VMRayJob) DeepCopyInto(out *VMRayJob) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VMRayJob.
+func (in *VMRayJob) DeepCopy() *VMRayJob {
+	if in == nil {
+		return nil
+	}
+	out := new(VMRayJob)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VMRayJob) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VMRayJobList) DeepCopyInto(out *VMRayJobList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VMRayJob, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VMRayJobList.
+func (in *VMRayJobList) DeepCopy() *VMRayJobList {
+	if in == nil {
+		return nil
+	}
+	out := new(VMRayJobList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VMRayJobList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VMRayJobSpec) DeepCopyInto(out *VMRayJobSpec) {
+	*out = *in
+	if in.ClusterSpec != nil {
+		in, out := &in.ClusterSpec, &out.ClusterSpec
+		*out = new(VMRayClusterSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RuntimeEnv != nil {
+		in, out := &in.RuntimeEnv, &out.RuntimeEnv
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Metadata != nil {
+		in, out := &in.Metadata, &out.Metadata
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VMRayJobSpec.
+func (in *VMRayJobSpec) DeepCopy() *VMRayJobSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VMRayJobSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VMRayJobStatus) DeepCopyInto(out *VMRayJobStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.EndTime != nil {
+		in, out := &in.EndTime, &out.EndTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VMRayJobStatus.
+func (in *VMRayJobStatus) DeepCopy() *VMRayJobStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VMRayJobStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VMRayNodeDrainStatus) DeepCopyInto(out *VMRayNodeDrainStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VMRayNodeDrainStatus.
+func (in *VMRayNodeDrainStatus) DeepCopy() *VMRayNodeDrainStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VMRayNodeDrainStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VMRayNodeGPUStatus) DeepCopyInto(out *VMRayNodeGPUStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VMRayNodeGPUStatus.
+func (in *VMRayNodeGPUStatus) DeepCopy() *VMRayNodeGPUStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VMRayNodeGPUStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VMRayNodeResizeStatus) DeepCopyInto(out *VMRayNodeResizeStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VMRayNodeResizeStatus.
+func (in *VMRayNodeResizeStatus) DeepCopy() *VMRayNodeResizeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VMRayNodeResizeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VMRayNodeStatus) DeepCopyInto(out *VMRayNodeStatus) {
 	*out = *in
@@ -263,6 +597,36 @@ func (in *VMRayNodeStatus) DeepCopyInto(out *VMRayNodeStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.CreationTime != nil {
+		in, out := &in.CreationTime, &out.CreationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ReadyTime != nil {
+		in, out := &in.ReadyTime, &out.ReadyTime
+		*out = (*in).DeepCopy()
+	}
+	if in.RayResources != nil {
+		in, out := &in.RayResources, &out.RayResources
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Drain != nil {
+		in, out := &in.Drain, &out.Drain
+		*out = new(VMRayNodeDrainStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Resize != nil {
+		in, out := &in.Resize, &out.Resize
+		*out = new(VMRayNodeResizeStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GPU != nil {
+		in, out := &in.GPU, &out.GPU
+		*out = new(VMRayNodeGPUStatus)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VMRayNodeStatus.
@@ -275,6 +639,143 @@ func (in *VMRayNodeStatus) DeepCopy() *VMRayNodeStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VMRayService) DeepCopyInto(out *VMRayService) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VMRayService.
+func (in *VMRayService) DeepCopy() *VMRayService {
+	if in == nil {
+		return nil
+	}
+	out := new(VMRayService)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VMRayService) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VMRayServiceClusterStatus) DeepCopyInto(out *VMRayServiceClusterStatus) {
+	*out = *in
+	if in.Applications != nil {
+		in, out := &in.Applications, &out.Applications
+		*out = make(map[string]ServeApplicationStatus, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VMRayServiceClusterStatus.
+func (in *VMRayServiceClusterStatus) DeepCopy() *VMRayServiceClusterStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VMRayServiceClusterStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VMRayServiceList) DeepCopyInto(out *VMRayServiceList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VMRayService, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VMRayServiceList.
+func (in *VMRayServiceList) DeepCopy() *VMRayServiceList {
+	if in == nil {
+		return nil
+	}
+	out := new(VMRayServiceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VMRayServiceList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VMRayServiceSpec) DeepCopyInto(out *VMRayServiceSpec) {
+	*out = *in
+	in.ClusterSpec.DeepCopyInto(&out.ClusterSpec)
+	in.ServeConfig.DeepCopyInto(&out.ServeConfig)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VMRayServiceSpec.
+func (in *VMRayServiceSpec) DeepCopy() *VMRayServiceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VMRayServiceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VMRayServiceStatus) DeepCopyInto(out *VMRayServiceStatus) {
+	*out = *in
+	in.ActiveCluster.DeepCopyInto(&out.ActiveCluster)
+	in.PendingCluster.DeepCopyInto(&out.PendingCluster)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VMRayServiceStatus.
+func (in *VMRayServiceStatus) DeepCopy() *VMRayServiceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VMRayServiceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VMRayWorkerReplacement) DeepCopyInto(out *VMRayWorkerReplacement) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VMRayWorkerReplacement.
+func (in *VMRayWorkerReplacement) DeepCopy() *VMRayWorkerReplacement {
+	if in == nil {
+		return nil
+	}
+	out := new(VMRayWorkerReplacement)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VMServiceStatus) DeepCopyInto(out *VMServiceStatus) {
 	*out = *in
@@ -298,6 +799,11 @@ func (in *WorkerNodeConfig) DeepCopyInto(out *WorkerNodeConfig) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.DrainGracePeriodSeconds != nil {
+		in, out := &in.DrainGracePeriodSeconds, &out.DrainGracePeriodSeconds
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkerNodeConfig.
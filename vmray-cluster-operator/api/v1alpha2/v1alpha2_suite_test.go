@@ -0,0 +1,18 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha2_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestConversion(t *testing.T) {
+	RegisterFailHandler(Fail)
+	Describe("VMRayCluster conversion", conversionTests)
+
+	RunSpecs(t, "Unit testcases to validate conversion of v1alpha2 API")
+}
@@ -0,0 +1,418 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha2
+
+import (
+	"math"
+
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+)
+
+/*
+Clusters are converted to & from v1alpha1, the hub version, by the conversion webhook
+served at /convert. Both versions hold the same information, so conversions round trip
+except for counts outside of the range of the other version's integers, which are
+rejected by v1alpha2's schema or are beyond any cluster's size. Phases are mapped by
+their meaning, values unknown to the version converted from are kept as they are.
+*/
+
+var _ conversion.Convertible = &VMRayCluster{}
+
+var (
+	zoneSpreads = map[ZoneSpread]v1alpha1.ZoneSpreadStrategy{
+		ZoneSpreadCluster:  v1alpha1.ZoneSpreadCluster,
+		ZoneSpreadNodeType: v1alpha1.ZoneSpreadNodeType,
+	}
+	reclaimActions = map[ReclaimAction]v1alpha1.ReclaimAction{
+		ReclaimActionSuspend: v1alpha1.ReclaimActionSuspend,
+		ReclaimActionDelete:  v1alpha1.ReclaimActionDelete,
+	}
+	clusterPhases = map[VMRayClusterPhase]v1alpha1.VMRayClusterState{
+		VMRayClusterPhaseHealthy:   v1alpha1.HEALTHY,
+		VMRayClusterPhaseUnhealthy: v1alpha1.UNHEALTHY,
+		VMRayClusterPhaseSuspended: v1alpha1.SUSPENDED,
+	}
+	vmPhases = map[VMPhase]v1alpha1.VMNodeStatus{
+		VMPhaseInitialized: v1alpha1.INITIALIZED,
+		VMPhaseRunning:     v1alpha1.RUNNING,
+		VMPhaseFailed:      v1alpha1.FAIL,
+	}
+	rayPhases = map[RayPhase]v1alpha1.RayProcessStatus{
+		RayPhaseInitialized: v1alpha1.RAY_INITIALIZED,
+		RayPhaseRunning:     v1alpha1.RAY_RUNNING,
+		RayPhaseFailed:      v1alpha1.RAY_FAIL,
+	}
+	drainPhases = map[DrainPhase]v1alpha1.NodeDrainState{
+		DrainPhaseDraining: v1alpha1.NODE_DRAINING,
+		DrainPhaseDrained:  v1alpha1.NODE_DRAINED,
+		DrainPhaseTimedOut: v1alpha1.NODE_DRAIN_TIMEOUT,
+	}
+	resizePhases = map[ResizePhase]v1alpha1.NodeResizeState{
+		ResizePhaseResizing:      v1alpha1.NODE_RESIZING,
+		ResizePhaseRestartingRay: v1alpha1.NODE_RAY_RESTARTING,
+	}
+	gpuPhases = map[GPUPhase]v1alpha1.NodeGPUState{
+		GPUPhasePending:  v1alpha1.NODE_GPU_PENDING,
+		GPUPhaseReady:    v1alpha1.NODE_GPU_READY,
+		GPUPhaseNotReady: v1alpha1.NODE_GPU_NOT_READY,
+	}
+	upgradePhases = map[UpgradePhase]v1alpha1.UpgradeState{
+		UpgradePhaseUpgradingWorkers: v1alpha1.UPGRADE_WORKERS,
+		UpgradePhaseHeadPending:      v1alpha1.UPGRADE_HEAD_PENDING,
+		UpgradePhaseUpgradingHead:    v1alpha1.UPGRADE_HEAD,
+	}
+)
+
+// ConvertTo converts the cluster to the hub version, v1alpha1.
+func (src *VMRayCluster) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha1.VMRayCluster)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = convertSpecTo(src.Spec)
+	dst.Spec.AutoscalerDesiredWorkers = src.Autoscaler.DesiredWorkers
+	dst.Status = convertStatusTo(src.Status)
+	return nil
+}
+
+// ConvertFrom converts the cluster from the hub version, v1alpha1.
+func (dst *VMRayCluster) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha1.VMRayCluster)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = convertSpecFrom(src.Spec)
+	dst.Autoscaler.DesiredWorkers = src.Spec.AutoscalerDesiredWorkers
+	dst.Status = convertStatusFrom(src.Status)
+	return nil
+}
+
+func convertSpecTo(src VMRayClusterSpec) v1alpha1.VMRayClusterSpec {
+	dst := v1alpha1.VMRayClusterSpec{
+		Image: src.Image,
+		ApiServer: v1alpha1.ApiServerInfo{
+			CaCert:   src.APIServer.CACert,
+			Location: src.APIServer.Location,
+		},
+		HeadNode: v1alpha1.HeadNodeConfig{
+			SetupCommands: src.HeadNode.SetupCommands,
+			NodeType:      src.HeadNode.NodeType,
+		},
+		WorkerNode: v1alpha1.WorkerNodeConfig{
+			SetupCommands:           src.WorkerNode.SetupCommands,
+			DrainGracePeriodSeconds: src.WorkerNode.DrainGracePeriodSeconds,
+		},
+		NodeConfig: v1alpha1.CommonNodeConfig{
+			VMImage:                src.NodeConfig.VMImage,
+			StorageClass:           src.NodeConfig.StorageClass,
+			VMUser:                 src.NodeConfig.VMUser,
+			VMPasswordSaltHash:     src.NodeConfig.VMPasswordSaltHash,
+			Network:                src.NodeConfig.Network,
+			MaxWorkers:             toUint(src.NodeConfig.MaxWorkers),
+			IdleTimeoutMinutes:     toUint(src.NodeConfig.IdleTimeoutMinutes),
+			SetupCommands:          src.NodeConfig.SetupCommands,
+			InitializationCommands: src.NodeConfig.InitializationCommands,
+			Zones:                  src.NodeConfig.Zones,
+			ZoneSpread:             convertEnum(src.NodeConfig.ZoneSpread, zoneSpreads),
+		},
+		EnableTLS:           src.EnableTLS,
+		DockerConfig:        v1alpha1.DockerRegistryConfig{AuthSecretName: src.DockerConfig.AuthSecretName},
+		TTLSecondsAfterIdle: src.TTLSecondsAfterIdle,
+		ExpiresAt:           src.ExpiresAt,
+		ReclaimAction:       convertEnum(src.ReclaimAction, reclaimActions),
+		Suspend:             src.Suspend,
+		UpgradeStrategy: v1alpha1.UpgradeStrategy{
+			MaxUnavailable: src.UpgradeStrategy.MaxUnavailable,
+			MaxSurge:       src.UpgradeStrategy.MaxSurge,
+			UpgradeHead:    src.UpgradeStrategy.UpgradeHead,
+			ResizePolicy:   v1alpha1.ResizePolicy(src.UpgradeStrategy.ResizePolicy),
+		},
+	}
+	if src.HeadNode.Port != nil {
+		port := toUint(*src.HeadNode.Port)
+		dst.HeadNode.Port = &port
+	}
+	if policy := src.NodeConfig.ResourcePolicy; policy != nil {
+		dst.NodeConfig.ResourcePolicy = &v1alpha1.ResourcePolicyReference{
+			Name:               policy.Name,
+			ClusterModuleGroup: policy.ClusterModuleGroup,
+		}
+	}
+	if src.NodeConfig.NodeTypes != nil {
+		dst.NodeConfig.NodeTypes = make(map[string]v1alpha1.NodeType, len(src.NodeConfig.NodeTypes))
+		for name, nt := range src.NodeConfig.NodeTypes {
+			dst.NodeConfig.NodeTypes[name] = v1alpha1.NodeType{
+				VMClass:    nt.VMClass,
+				MinWorkers: toUint(nt.MinWorkers),
+				MaxWorkers: toUint(nt.MaxWorkers),
+				Resources: v1alpha1.NodeResource{
+					CPU:             nt.Resources.CPU,
+					Memory:          nt.Resources.Memory,
+					GPU:             nt.Resources.GPU,
+					CustomResources: nt.Resources.CustomResources,
+				},
+				Zones: nt.Zones,
+			}
+		}
+	}
+	return dst
+}
+
+func convertSpecFrom(src v1alpha1.VMRayClusterSpec) VMRayClusterSpec {
+	dst := VMRayClusterSpec{
+		Image: src.Image,
+		APIServer: APIServerInfo{
+			CACert:   src.ApiServer.CaCert,
+			Location: src.ApiServer.Location,
+		},
+		HeadNode: HeadNodeConfig{
+			SetupCommands: src.HeadNode.SetupCommands,
+			NodeType:      src.HeadNode.NodeType,
+		},
+		WorkerNode: WorkerNodeConfig{
+			SetupCommands:           src.WorkerNode.SetupCommands,
+			DrainGracePeriodSeconds: src.WorkerNode.DrainGracePeriodSeconds,
+		},
+		NodeConfig: CommonNodeConfig{
+			VMImage:                src.NodeConfig.VMImage,
+			StorageClass:           src.NodeConfig.StorageClass,
+			VMUser:                 src.NodeConfig.VMUser,
+			VMPasswordSaltHash:     src.NodeConfig.VMPasswordSaltHash,
+			Network:                src.NodeConfig.Network,
+			MaxWorkers:             toInt32(src.NodeConfig.MaxWorkers),
+			IdleTimeoutMinutes:     toInt32(src.NodeConfig.IdleTimeoutMinutes),
+			SetupCommands:          src.NodeConfig.SetupCommands,
+			InitializationCommands: src.NodeConfig.InitializationCommands,
+			Zones:                  src.NodeConfig.Zones,
+			ZoneSpread:             convertEnum(src.NodeConfig.ZoneSpread, invert(zoneSpreads)),
+		},
+		EnableTLS:           src.EnableTLS,
+		DockerConfig:        DockerRegistryConfig{AuthSecretName: src.DockerConfig.AuthSecretName},
+		TTLSecondsAfterIdle: src.TTLSecondsAfterIdle,
+		ExpiresAt:           src.ExpiresAt,
+		ReclaimAction:       convertEnum(src.ReclaimAction, invert(reclaimActions)),
+		Suspend:             src.Suspend,
+		UpgradeStrategy: UpgradeStrategy{
+			MaxUnavailable: src.UpgradeStrategy.MaxUnavailable,
+			MaxSurge:       src.UpgradeStrategy.MaxSurge,
+			UpgradeHead:    src.UpgradeStrategy.UpgradeHead,
+			ResizePolicy:   ResizePolicy(src.UpgradeStrategy.ResizePolicy),
+		},
+	}
+	if src.HeadNode.Port != nil {
+		port := toInt32(*src.HeadNode.Port)
+		dst.HeadNode.Port = &port
+	}
+	if policy := src.NodeConfig.ResourcePolicy; policy != nil {
+		dst.NodeConfig.ResourcePolicy = &ResourcePolicyReference{
+			Name:               policy.Name,
+			ClusterModuleGroup: policy.ClusterModuleGroup,
+		}
+	}
+	if src.NodeConfig.NodeTypes != nil {
+		dst.NodeConfig.NodeTypes = make(map[string]NodeType, len(src.NodeConfig.NodeTypes))
+		for name, nt := range src.NodeConfig.NodeTypes {
+			dst.NodeConfig.NodeTypes[name] = NodeType{
+				VMClass:    nt.VMClass,
+				MinWorkers: toInt32(nt.MinWorkers),
+				MaxWorkers: toInt32(nt.MaxWorkers),
+				Resources: NodeResource{
+					CPU:             nt.Resources.CPU,
+					Memory:          nt.Resources.Memory,
+					GPU:             nt.Resources.GPU,
+					CustomResources: nt.Resources.CustomResources,
+				},
+				Zones: nt.Zones,
+			}
+		}
+	}
+	return dst
+}
+
+func convertStatusTo(src VMRayClusterStatus) v1alpha1.VMRayClusterStatus {
+	dst := v1alpha1.VMRayClusterStatus{
+		HeadNodeStatus:   convertNodeStatusTo(src.HeadNode),
+		ClusterState:     convertEnum(src.Phase, clusterPhases),
+		Conditions:       src.Conditions,
+		VMServiceStatus:  v1alpha1.VMServiceStatus{Ip: src.VMService.IP},
+		LastActivityTime: src.LastActivityTime,
+	}
+	if src.CurrentWorkers != nil {
+		dst.CurrentWorkers = make(map[string]v1alpha1.VMRayNodeStatus, len(src.CurrentWorkers))
+		for name, status := range src.CurrentWorkers {
+			dst.CurrentWorkers[name] = convertNodeStatusTo(status)
+		}
+	}
+	if upgrade := src.Upgrade; upgrade != nil {
+		dst.Upgrade = &v1alpha1.VMRayClusterUpgradeStatus{
+			State:           convertEnum(upgrade.State, upgradePhases),
+			StartTime:       upgrade.StartTime,
+			UpdatedWorkers:  upgrade.UpdatedWorkers,
+			OutdatedWorkers: upgrade.OutdatedWorkers,
+			ResizingWorkers: upgrade.ResizingWorkers,
+			HeadOutdated:    upgrade.HeadOutdated,
+		}
+		if upgrade.Replacements != nil {
+			dst.Upgrade.Replacements = make(map[string]v1alpha1.VMRayWorkerReplacement, len(upgrade.Replacements))
+			for name, replacement := range upgrade.Replacements {
+				dst.Upgrade.Replacements[name] = v1alpha1.VMRayWorkerReplacement{
+					Worker:     replacement.Worker,
+					NodeType:   replacement.NodeType,
+					Recreating: replacement.Recreating,
+				}
+			}
+		}
+	}
+	return dst
+}
+
+func convertStatusFrom(src v1alpha1.VMRayClusterStatus) VMRayClusterStatus {
+	dst := VMRayClusterStatus{
+		HeadNode:         convertNodeStatusFrom(src.HeadNodeStatus),
+		Phase:            convertEnum(src.ClusterState, invert(clusterPhases)),
+		Conditions:       src.Conditions,
+		VMService:        VMServiceStatus{IP: src.VMServiceStatus.Ip},
+		LastActivityTime: src.LastActivityTime,
+	}
+	if src.CurrentWorkers != nil {
+		dst.CurrentWorkers = make(map[string]VMRayNodeStatus, len(src.CurrentWorkers))
+		for name, status := range src.CurrentWorkers {
+			dst.CurrentWorkers[name] = convertNodeStatusFrom(status)
+		}
+	}
+	if upgrade := src.Upgrade; upgrade != nil {
+		dst.Upgrade = &VMRayClusterUpgradeStatus{
+			State:           convertEnum(upgrade.State, invert(upgradePhases)),
+			StartTime:       upgrade.StartTime,
+			UpdatedWorkers:  upgrade.UpdatedWorkers,
+			OutdatedWorkers: upgrade.OutdatedWorkers,
+			ResizingWorkers: upgrade.ResizingWorkers,
+			HeadOutdated:    upgrade.HeadOutdated,
+		}
+		if upgrade.Replacements != nil {
+			dst.Upgrade.Replacements = make(map[string]VMRayWorkerReplacement, len(upgrade.Replacements))
+			for name, replacement := range upgrade.Replacements {
+				dst.Upgrade.Replacements[name] = VMRayWorkerReplacement{
+					Worker:     replacement.Worker,
+					NodeType:   replacement.NodeType,
+					Recreating: replacement.Recreating,
+				}
+			}
+		}
+	}
+	return dst
+}
+
+func convertNodeStatusTo(src VMRayNodeStatus) v1alpha1.VMRayNodeStatus {
+	dst := v1alpha1.VMRayNodeStatus{
+		Ip:                  src.IP,
+		Conditions:          src.Conditions,
+		VmStatus:            convertEnum(src.VMPhase, vmPhases),
+		RayStatus:           convertEnum(src.RayPhase, rayPhases),
+		NodeType:            src.NodeType,
+		VMClass:             src.VMClass,
+		CreationTime:        src.CreationTime,
+		ReadyTime:           src.ReadyTime,
+		RayNodeID:           src.RayNodeID,
+		RayNodeState:        v1alpha1.RayNodeState(src.RayNodeState),
+		RayNodeStateMessage: src.RayNodeStateMessage,
+		RayResources:        src.RayResources,
+		ConfigHash:          src.ConfigHash,
+		Zone:                src.Zone,
+		Host:                src.Host,
+		Adopted:             src.Adopted,
+	}
+	if drain := src.Drain; drain != nil {
+		dst.Drain = &v1alpha1.VMRayNodeDrainStatus{
+			State:        convertEnum(drain.State, drainPhases),
+			StartTime:    drain.StartTime,
+			RunningTasks: drain.RunningTasks,
+			Message:      drain.Message,
+		}
+	}
+	if resize := src.Resize; resize != nil {
+		dst.Resize = &v1alpha1.VMRayNodeResizeStatus{
+			State:     convertEnum(resize.State, resizePhases),
+			VMClass:   resize.VMClass,
+			StartTime: resize.StartTime,
+			Message:   resize.Message,
+		}
+	}
+	if gpu := src.GPU; gpu != nil {
+		dst.GPU = &v1alpha1.VMRayNodeGPUStatus{
+			State:   convertEnum(gpu.State, gpuPhases),
+			Devices: gpu.Devices,
+			Message: gpu.Message,
+		}
+	}
+	return dst
+}
+
+func convertNodeStatusFrom(src v1alpha1.VMRayNodeStatus) VMRayNodeStatus {
+	dst := VMRayNodeStatus{
+		IP:                  src.Ip,
+		Conditions:          src.Conditions,
+		VMPhase:             convertEnum(src.VmStatus, invert(vmPhases)),
+		RayPhase:            convertEnum(src.RayStatus, invert(rayPhases)),
+		NodeType:            src.NodeType,
+		VMClass:             src.VMClass,
+		CreationTime:        src.CreationTime,
+		ReadyTime:           src.ReadyTime,
+		RayNodeID:           src.RayNodeID,
+		RayNodeState:        RayNodeState(src.RayNodeState),
+		RayNodeStateMessage: src.RayNodeStateMessage,
+		RayResources:        src.RayResources,
+		ConfigHash:          src.ConfigHash,
+		Zone:                src.Zone,
+		Host:                src.Host,
+		Adopted:             src.Adopted,
+	}
+	if drain := src.Drain; drain != nil {
+		dst.Drain = &VMRayNodeDrainStatus{
+			State:        convertEnum(drain.State, invert(drainPhases)),
+			StartTime:    drain.StartTime,
+			RunningTasks: drain.RunningTasks,
+			Message:      drain.Message,
+		}
+	}
+	if resize := src.Resize; resize != nil {
+		dst.Resize = &VMRayNodeResizeStatus{
+			State:     convertEnum(resize.State, invert(resizePhases)),
+			VMClass:   resize.VMClass,
+			StartTime: resize.StartTime,
+			Message:   resize.Message,
+		}
+	}
+	if gpu := src.GPU; gpu != nil {
+		dst.GPU = &VMRayNodeGPUStatus{
+			State:   convertEnum(gpu.State, invert(gpuPhases)),
+			Devices: gpu.Devices,
+			Message: gpu.Message,
+		}
+	}
+	return dst
+}
+
+// convertEnum returns the value's counterpart in values, values without
+// one are kept as they are.
+func convertEnum[From, To ~string](value From, values map[From]To) To {
+	if converted, ok := values[value]; ok {
+		return converted
+	}
+	return To(value)
+}
+
+func invert[From, To comparable](values map[From]To) map[To]From {
+	inverted := make(map[To]From, len(values))
+	for k, v := range values {
+		inverted[v] = k
+	}
+	return inverted
+}
+
+func toInt32(v uint) int32 {
+	return int32(min(v, math.MaxInt32))
+}
+
+func toUint(v int32) uint {
+	return uint(max(v, 0))
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha2_test
+
+import (
+	"math/rand"
+
+	fuzz "github.com/google/gofuzz"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/api/apitesting/fuzzer"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metafuzzer "k8s.io/apimachinery/pkg/apis/meta/fuzzer"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/diff"
+
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha2"
+)
+
+const fuzzIterations = 1000
+
+// fuzzerFuncs keeps fuzzed values within what both versions can hold, i.e.
+// counts which are non-negative & fit into an int32.
+func fuzzerFuncs(_ runtimeserializer.CodecFactory) []interface{} {
+	return []interface{}{
+		func(v *uint, c fuzz.Continue) {
+			*v = uint(c.Int31())
+		},
+		func(v *int32, c fuzz.Continue) {
+			*v = c.Int31()
+		},
+		func(q *resource.Quantity, c fuzz.Continue) {
+			*q = *resource.NewQuantity(c.Int63n(1<<40), resource.BinarySI)
+		},
+	}
+}
+
+func newFuzzer() *fuzz.Fuzzer {
+	scheme := runtime.NewScheme()
+	Expect(v1alpha1.AddToScheme(scheme)).To(Succeed())
+	Expect(v1alpha2.AddToScheme(scheme)).To(Succeed())
+	return fuzzer.FuzzerFor(fuzzer.MergeFuzzerFuncs(metafuzzer.Funcs, fuzzerFuncs),
+		rand.NewSource(GinkgoRandomSeed()), runtimeserializer.NewCodecFactory(scheme))
+}
+
+func conversionTests() {
+	Context("Round trip", func() {
+		It("should keep v1alpha1 clusters through v1alpha2", func() {
+			f := newFuzzer()
+			for i := 0; i < fuzzIterations; i++ {
+				hub := &v1alpha1.VMRayCluster{}
+				f.Fuzz(hub)
+
+				spoke := &v1alpha2.VMRayCluster{}
+				Expect(spoke.ConvertFrom(hub.DeepCopy())).To(Succeed())
+				converted := &v1alpha1.VMRayCluster{}
+				Expect(spoke.ConvertTo(converted)).To(Succeed())
+
+				Expect(apiequality.Semantic.DeepEqual(hub, converted)).To(BeTrue(), diff.ObjectReflectDiff(hub, converted))
+			}
+		})
+
+		It("should keep v1alpha2 clusters through v1alpha1", func() {
+			f := newFuzzer()
+			for i := 0; i < fuzzIterations; i++ {
+				spoke := &v1alpha2.VMRayCluster{}
+				f.Fuzz(spoke)
+
+				hub := &v1alpha1.VMRayCluster{}
+				Expect(spoke.DeepCopy().ConvertTo(hub)).To(Succeed())
+				converted := &v1alpha2.VMRayCluster{}
+				Expect(converted.ConvertFrom(hub)).To(Succeed())
+
+				Expect(apiequality.Semantic.DeepEqual(spoke, converted)).To(BeTrue(), diff.ObjectReflectDiff(spoke, converted))
+			}
+		})
+	})
+
+	Context("Field mapping", func() {
+		It("should move desired workers & map phases", func() {
+			hub := &v1alpha1.VMRayCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "namespace"},
+				Spec: v1alpha1.VMRayClusterSpec{
+					Image:                    "rayproject/ray:2.9.0",
+					AutoscalerDesiredWorkers: map[string]string{"cluster-w-1": "worker_1"},
+					ReclaimAction:            v1alpha1.ReclaimActionDelete,
+					NodeConfig: v1alpha1.CommonNodeConfig{
+						MaxWorkers: 3,
+						NodeTypes: map[string]v1alpha1.NodeType{
+							"worker_1": {VMClass: "best-effort-small", MaxWorkers: 2},
+						},
+					},
+				},
+				Status: v1alpha1.VMRayClusterStatus{
+					ClusterState:   v1alpha1.HEALTHY,
+					HeadNodeStatus: v1alpha1.VMRayNodeStatus{Ip: "10.0.0.1", VmStatus: v1alpha1.RUNNING},
+					CurrentWorkers: map[string]v1alpha1.VMRayNodeStatus{
+						"cluster-w-1": {
+							VmStatus:  v1alpha1.INITIALIZED,
+							RayStatus: v1alpha1.RAY_FAIL,
+							Drain:     &v1alpha1.VMRayNodeDrainStatus{State: v1alpha1.NODE_DRAIN_TIMEOUT},
+						},
+					},
+					Upgrade: &v1alpha1.VMRayClusterUpgradeStatus{State: v1alpha1.UPGRADE_HEAD_PENDING},
+				},
+			}
+
+			spoke := &v1alpha2.VMRayCluster{}
+			Expect(spoke.ConvertFrom(hub)).To(Succeed())
+			Expect(spoke.Name).To(Equal("cluster"))
+			Expect(spoke.Spec.Image).To(Equal("rayproject/ray:2.9.0"))
+			Expect(spoke.Autoscaler.DesiredWorkers).To(Equal(map[string]string{"cluster-w-1": "worker_1"}))
+			Expect(spoke.Spec.ReclaimAction).To(Equal(v1alpha2.ReclaimActionDelete))
+			Expect(spoke.Spec.NodeConfig.MaxWorkers).To(Equal(int32(3)))
+			Expect(spoke.Spec.NodeConfig.NodeTypes["worker_1"].MaxWorkers).To(Equal(int32(2)))
+			Expect(spoke.Status.Phase).To(Equal(v1alpha2.VMRayClusterPhaseHealthy))
+			Expect(spoke.Status.HeadNode.IP).To(Equal("10.0.0.1"))
+			Expect(spoke.Status.HeadNode.VMPhase).To(Equal(v1alpha2.VMPhaseRunning))
+			worker := spoke.Status.CurrentWorkers["cluster-w-1"]
+			Expect(worker.VMPhase).To(Equal(v1alpha2.VMPhaseInitialized))
+			Expect(worker.RayPhase).To(Equal(v1alpha2.RayPhaseFailed))
+			Expect(worker.Drain.State).To(Equal(v1alpha2.DrainPhaseTimedOut))
+			Expect(spoke.Status.Upgrade.State).To(Equal(v1alpha2.UpgradePhaseHeadPending))
+		})
+
+		It("should keep values unknown to the other version", func() {
+			spoke := &v1alpha2.VMRayCluster{
+				Status: v1alpha2.VMRayClusterStatus{Phase: "Degraded"},
+			}
+
+			hub := &v1alpha1.VMRayCluster{}
+			Expect(spoke.ConvertTo(hub)).To(Succeed())
+			Expect(hub.Status.ClusterState).To(Equal(v1alpha1.VMRayClusterState("Degraded")))
+		})
+	})
+}
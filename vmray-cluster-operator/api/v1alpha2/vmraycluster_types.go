@@ -0,0 +1,529 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha2
+
+import (
+	vmopv1 "github.com/vmware-tanzu/vm-operator/api/v1alpha2"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+/*
+v1alpha2 is served alongside v1alpha1, which remains the version clusters are stored
+in & the controllers work with, see vmraycluster_conversion.go. Compared to v1alpha1:
+
+  - Fields are camelCase, e.g. spec.common_node_config is spec.nodeConfig.
+  - Workers requested by ray autoscaler are in autoscaler.desiredWorkers instead of
+    the spec, as they're owned by the autoscaler rather than the cluster's owner.
+  - States of the cluster, its nodes & their upgrade are typed CamelCase phases.
+  - Counts are int32 instead of unsigned integers.
+*/
+
+// ReclaimAction describes what the operator does with a
+// cluster once its idle or expiry policy is triggered.
+// +kubebuilder:validation:Enum=Suspend;Delete
+type ReclaimAction string
+
+const (
+	ReclaimActionSuspend ReclaimAction = "Suspend"
+	ReclaimActionDelete  ReclaimAction = "Delete"
+)
+
+// VMRayClusterSpec defines the desired state of VMRayCluster
+type VMRayClusterSpec struct {
+	// Name of ray's docker image the cluster's nodes run.
+	Image string `json:"image"`
+	// Information about the API server ray autoscaler talks to.
+	APIServer APIServerInfo `json:"apiServer"`
+	// Configuration for the head node.
+	HeadNode HeadNodeConfig `json:"headNode"`
+	// Configuration for the worker nodes.
+	// +optional
+	WorkerNode WorkerNodeConfig `json:"workerNode,omitempty"`
+	// Configuration common to each VM i.e. ray head or worker node.
+	NodeConfig CommonNodeConfig `json:"nodeConfig"`
+	// Enable/Disable TLS on Ray gRPC channels
+	// +kubebuilder:default=true
+	// +optional
+	EnableTLS bool `json:"enableTLS"`
+	// Docker configuration of the nodes, such as authentication details with registry.
+	// +optional
+	DockerConfig DockerRegistryConfig `json:"dockerConfig,omitempty"`
+	// Number of seconds the whole cluster may stay idle, i.e. no running jobs and
+	// no client connections as reported by ray dashboard, before it is reclaimed.
+	// +optional
+	TTLSecondsAfterIdle *int32 `json:"ttlSecondsAfterIdle,omitempty"`
+	// Absolute time after which the cluster is reclaimed regardless of its activity.
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+	// Action performed once ttlSecondsAfterIdle or expiresAt is reached.
+	// +kubebuilder:default=Suspend
+	// +optional
+	ReclaimAction ReclaimAction `json:"reclaimAction,omitempty"`
+	// When set, all ray nodes of the cluster are torn down while the cluster
+	// object & its secrets are retained. Unset it to bring the cluster back.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+	// Defines how ray nodes are replaced when their configuration, i.e. image,
+	// vmClass of their node type or setup commands, is changed on a live cluster.
+	// +optional
+	UpgradeStrategy UpgradeStrategy `json:"upgradeStrategy,omitempty"`
+}
+
+type UpgradeStrategy struct {
+	// Maximum number of outdated workers deleted before their replacement is ready. Defaults to 1.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxUnavailable *int32 `json:"maxUnavailable,omitempty"`
+	// Maximum number of replacement workers created above the desired number of workers. Defaults to 1.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxSurge *int32 `json:"maxSurge,omitempty"`
+	// Replace the head node once all workers are upgraded, the
+	// cluster is unavailable while its head node is being replaced.
+	// +optional
+	UpgradeHead bool `json:"upgradeHead,omitempty"`
+	// How workers are upgraded when only vmClass of their node type changed. With InPlace
+	// the worker's VM is powered off, switched to the new VM class & powered back on.
+	// +kubebuilder:default=Replace
+	// +optional
+	ResizePolicy ResizePolicy `json:"resizePolicy,omitempty"`
+}
+
+// ResizePolicy describes how a worker is upgraded when only its VM class changes.
+// +kubebuilder:validation:Enum=Replace;InPlace
+type ResizePolicy string
+
+const (
+	ResizePolicyReplace ResizePolicy = "Replace"
+	ResizePolicyInPlace ResizePolicy = "InPlace"
+)
+
+type APIServerInfo struct {
+	// Base64 value of CA cert of API server.
+	// +optional
+	CACert string `json:"caCert,omitempty"`
+	// IP or domain name of supervisor cluster's master node.
+	Location string `json:"location"`
+}
+
+type HeadNodeConfig struct {
+	// These setup commands are executed in head node's Ray container before starting ray process.
+	// +optional
+	SetupCommands []string `json:"setupCommands,omitempty"`
+	// Port of the head ray process running in VM.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port *int32 `json:"port,omitempty"`
+	// Key of the node type in nodeConfig.nodeTypes the head node is launched as.
+	NodeType string `json:"nodeType"`
+}
+
+type WorkerNodeConfig struct {
+	// These setup commands are executed in worker node's Ray container before starting ray process.
+	// +optional
+	SetupCommands []string `json:"setupCommands,omitempty"`
+	// Maximum number of seconds to wait for a worker's ray node to drain i.e. finish
+	// its running tasks, before its VM is deleted. Defaults to 300, 0 disables draining.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	DrainGracePeriodSeconds *int32 `json:"drainGracePeriodSeconds,omitempty"`
+}
+
+type DockerRegistryConfig struct {
+	// Name of the secret containing registry credentials.
+	AuthSecretName string `json:"authSecretName"`
+}
+
+type CommonNodeConfig struct {
+	// Name of VirtualMachineImage of type ovf used to create ray nodes i.e. mapped against content library item.
+	VMImage string `json:"vmImage"`
+	// Storage class assigned to the namespace in supervisor cluster.
+	StorageClass string `json:"storageClass"`
+	// Name of the user created to run Ray Process in VM.
+	// +optional
+	VMUser string `json:"vmUser,omitempty"`
+	// Value of password's SHA-512 salt hash to be set for vmUser in ray VM.
+	VMPasswordSaltHash string `json:"vmPasswordSaltHash"`
+	// Network describes the desired network configuration for the VM.
+	// +optional
+	Network *vmopv1.VirtualMachineNetworkSpec `json:"network,omitempty"`
+	// Node types describe type of ray node configuration that can be deployed.
+	NodeTypes map[string]NodeType `json:"nodeTypes"`
+	// The maximum number of workers
+	// +kubebuilder:validation:Minimum=0
+	MaxWorkers int32 `json:"maxWorkers"`
+	// If the worker node stays idle for this time then bring it down.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	IdleTimeoutMinutes int32 `json:"idleTimeoutMinutes,omitempty"`
+	// Common setup commands executed in Ray container before starting ray process in both head & worker nodes.
+	// +optional
+	SetupCommands []string `json:"setupCommands,omitempty"`
+	// These commands will run outside the container in ray's VM node before docker container starts.
+	// +optional
+	InitializationCommands []string `json:"initializationCommands,omitempty"`
+	// vSphere zones ray nodes are spread across, each node is deployed to a single zone.
+	// +optional
+	Zones []string `json:"zones,omitempty"`
+	// Nodes which are spread evenly across zones, either all nodes of the cluster, the
+	// default, or the nodes of each node type separately.
+	// +optional
+	ZoneSpread ZoneSpread `json:"zoneSpread,omitempty"`
+	// VirtualMachineSetResourcePolicy of the namespace ray nodes' VMs are deployed with,
+	// e.g. to keep them on separate hosts.
+	// +optional
+	ResourcePolicy *ResourcePolicyReference `json:"resourcePolicy,omitempty"`
+}
+
+// ZoneSpread describes which nodes are spread evenly across zones.
+// +kubebuilder:validation:Enum=Cluster;NodeType
+type ZoneSpread string
+
+const (
+	ZoneSpreadCluster  ZoneSpread = "Cluster"
+	ZoneSpreadNodeType ZoneSpread = "NodeType"
+)
+
+// ResourcePolicyReference references a VirtualMachineSetResourcePolicy.
+type ResourcePolicyReference struct {
+	// Name of the VirtualMachineSetResourcePolicy.
+	Name string `json:"name"`
+	// Cluster module group of the policy ray nodes' VMs join, VMs of a cluster
+	// module are kept on separate hosts of a vSphere cluster.
+	// +optional
+	ClusterModuleGroup string `json:"clusterModuleGroup,omitempty"`
+}
+
+type NodeType struct {
+	// The VM class for Ray nodes
+	VMClass string `json:"vmClass"`
+	// The minimum number of workers
+	// +kubebuilder:validation:Minimum=0
+	MinWorkers int32 `json:"minWorkers"`
+	// The maximum number of workers
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxWorkers int32 `json:"maxWorkers,omitempty"`
+	// Resources ray advertises for the node type's nodes.
+	// +optional
+	Resources NodeResource `json:"resources,omitempty"`
+	// vSphere zones nodes of the node type are spread across instead of nodeConfig's zones.
+	// +optional
+	Zones []string `json:"zones,omitempty"`
+}
+
+// NodeResource describes the resources ray advertises for a node, resources which
+// aren't set are detected by ray itself.
+type NodeResource struct {
+	// CPU limit to be used by the node, a whole number of CPUs.
+	// +optional
+	CPU *resource.Quantity `json:"cpu,omitempty"`
+	// Memory limit to be used by the node, e.g. 16Gi.
+	// +optional
+	Memory *resource.Quantity `json:"memory,omitempty"`
+	// GPU limit to be used by the node, a whole number of GPUs.
+	// +optional
+	GPU *resource.Quantity `json:"gpu,omitempty"`
+	// Custom ray resources of the node, e.g. {"TPU": 1, "accelerator_type:A100": 1}.
+	// +optional
+	CustomResources map[string]resource.Quantity `json:"customResources,omitempty"`
+}
+
+// VMRayClusterAutoscaler holds the requests of ray autoscaler running on the head node.
+type VMRayClusterAutoscaler struct {
+	// Names of the workers ray autoscaler wants, with their node types.
+	// +optional
+	DesiredWorkers map[string]string `json:"desiredWorkers,omitempty"`
+}
+
+// VMRayClusterPhase is the overall state of the ray cluster.
+type VMRayClusterPhase string
+
+const (
+	VMRayClusterPhaseHealthy   VMRayClusterPhase = "Healthy"
+	VMRayClusterPhaseUnhealthy VMRayClusterPhase = "Unhealthy"
+	VMRayClusterPhaseSuspended VMRayClusterPhase = "Suspended"
+)
+
+// VMPhase is the state of a ray node's VM.
+type VMPhase string
+
+const (
+	// VM was requested & waits for its IP.
+	VMPhaseInitialized VMPhase = "Initialized"
+	VMPhaseRunning     VMPhase = "Running"
+	VMPhaseFailed      VMPhase = "Failed"
+)
+
+// RayPhase is the state of the ray process of a ray node.
+type RayPhase string
+
+const (
+	RayPhaseInitialized RayPhase = "Initialized"
+	RayPhaseRunning     RayPhase = "Running"
+	RayPhaseFailed      RayPhase = "Failed"
+)
+
+// RayNodeState mirrors ray's node state as reported by ray dashboard.
+type RayNodeState string
+
+const (
+	RayNodeAlive RayNodeState = "ALIVE"
+	RayNodeDead  RayNodeState = "DEAD"
+)
+
+type VMRayNodeStatus struct {
+	// Observed primary IP of VirtualMachine.
+	// +optional
+	IP string `json:"ip,omitempty"`
+	// Conditions describes the observed conditions of the VirtualMachine.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// State of the node's VM.
+	// +optional
+	VMPhase VMPhase `json:"vmPhase,omitempty"`
+	// State of the node's ray process.
+	// +optional
+	RayPhase RayPhase `json:"rayPhase,omitempty"`
+	// Name of the node type, from nodeConfig.nodeTypes, the node is deployed as.
+	// +optional
+	NodeType string `json:"nodeType,omitempty"`
+	// VM class the node's VirtualMachine is deployed with.
+	// +optional
+	VMClass string `json:"vmClass,omitempty"`
+	// Time at which node's VirtualMachine was requested.
+	// +optional
+	CreationTime *metav1.Time `json:"creationTime,omitempty"`
+	// Time at which node's VirtualMachine got its IP assigned.
+	// +optional
+	ReadyTime *metav1.Time `json:"readyTime,omitempty"`
+	// ID of the ray node running on the VM, as reported by ray dashboard.
+	// +optional
+	RayNodeID string `json:"rayNodeID,omitempty"`
+	// State of the ray node as reported by ray dashboard i.e. ALIVE or DEAD.
+	// +optional
+	RayNodeState RayNodeState `json:"rayNodeState,omitempty"`
+	// Reason reported by ray for the ray node's state, e.g. why it is dead.
+	// +optional
+	RayNodeStateMessage string `json:"rayNodeStateMessage,omitempty"`
+	// Total resources of the ray node as reported by ray dashboard, e.g. CPU: "4".
+	// +optional
+	RayResources map[string]string `json:"rayResources,omitempty"`
+	// Progress of draining the ray node before its VM is deleted.
+	// +optional
+	Drain *VMRayNodeDrainStatus `json:"drain,omitempty"`
+	// Hash of the configuration node's VirtualMachine was deployed with.
+	// +optional
+	ConfigHash string `json:"configHash,omitempty"`
+	// Progress of resizing node's VirtualMachine in place to a new VM class.
+	// +optional
+	Resize *VMRayNodeResizeStatus `json:"resize,omitempty"`
+	// Readiness of the node's GPUs, set for nodes of node types with GPUs only.
+	// +optional
+	GPU *VMRayNodeGPUStatus `json:"gpu,omitempty"`
+	// vSphere zone node's VirtualMachine is deployed to.
+	// +optional
+	Zone string `json:"zone,omitempty"`
+	// Host node's VirtualMachine runs on, as reported by vm-operator.
+	// +optional
+	Host string `json:"host,omitempty"`
+	// Set on adopted workers till the autoscaler lists them in its desired workers,
+	// adopted workers aren't deleted for missing from desired workers till then.
+	// +optional
+	Adopted bool `json:"adopted,omitempty"`
+}
+
+// GPUPhase is the readiness of a ray node's GPUs.
+type GPUPhase string
+
+const (
+	// NVIDIA driver & container toolkit are being set up or ray hasn't started yet.
+	GPUPhasePending GPUPhase = "Pending"
+	// Ray runs in a container with access to the node's GPUs.
+	GPUPhaseReady GPUPhase = "Ready"
+	// Ray didn't start with access to the node's GPUs within the GPU setup timeout.
+	GPUPhaseNotReady GPUPhase = "NotReady"
+)
+
+// VMRayNodeGPUStatus captures readiness of a ray node's GPUs.
+type VMRayNodeGPUStatus struct {
+	// State of the node's GPUs.
+	// +optional
+	State GPUPhase `json:"state,omitempty"`
+	// Number of GPUs ray reports for the node.
+	// +optional
+	Devices int32 `json:"devices,omitempty"`
+	// Details of why the node's GPUs aren't ready, if any.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// ResizePhase is the state of resizing a ray node's VM in place.
+type ResizePhase string
+
+const (
+	// VM is being powered off, switched to the new VM class & powered back on.
+	ResizePhaseResizing ResizePhase = "Resizing"
+	// Ray is being restarted on the resized VM to advertise its new resources.
+	ResizePhaseRestartingRay ResizePhase = "RestartingRay"
+)
+
+// VMRayNodeResizeStatus captures progress of resizing a ray node's VM in place.
+type VMRayNodeResizeStatus struct {
+	// State of the resize.
+	// +optional
+	State ResizePhase `json:"state,omitempty"`
+	// VM class the node's VirtualMachine is being resized to.
+	// +optional
+	VMClass string `json:"vmClass,omitempty"`
+	// Time at which resize of the node started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// Details of the last error observed while resizing the node, if any.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// DrainPhase is the state of draining a ray node.
+type DrainPhase string
+
+const (
+	// Ray is asked to drain the node and operator waits for its running tasks to finish.
+	DrainPhaseDraining DrainPhase = "Draining"
+	// Node has no running tasks and its VM can be deleted.
+	DrainPhaseDrained DrainPhase = "Drained"
+	// Grace period elapsed before node finished its running tasks.
+	DrainPhaseTimedOut DrainPhase = "TimedOut"
+)
+
+// VMRayNodeDrainStatus captures progress of draining a ray node.
+type VMRayNodeDrainStatus struct {
+	// State of the drain.
+	// +optional
+	State DrainPhase `json:"state,omitempty"`
+	// Time at which draining of the node started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// Number of tasks still running on the node when it was last checked.
+	// +optional
+	RunningTasks int32 `json:"runningTasks,omitempty"`
+	// Details of the last error observed while draining the node, if any.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// UpgradePhase is the state of replacing outdated ray nodes.
+type UpgradePhase string
+
+const (
+	// Outdated workers are being replaced.
+	UpgradePhaseUpgradingWorkers UpgradePhase = "UpgradingWorkers"
+	// Workers are upgraded, head node is outdated and upgradeHead isn't set.
+	UpgradePhaseHeadPending UpgradePhase = "HeadPending"
+	// Head node is being replaced.
+	UpgradePhaseUpgradingHead UpgradePhase = "UpgradingHead"
+)
+
+// VMRayWorkerReplacement tracks replacement of an outdated worker.
+type VMRayWorkerReplacement struct {
+	// Name of the outdated worker being replaced.
+	Worker string `json:"worker"`
+	// Node type of the outdated worker.
+	NodeType string `json:"nodeType"`
+	// Set once VM of the outdated worker is being deleted, the worker is
+	// re-deployed under its name with the current configuration once it's gone.
+	// +optional
+	Recreating bool `json:"recreating,omitempty"`
+}
+
+// VMRayClusterUpgradeStatus captures progress of replacing ray nodes whose configuration changed.
+type VMRayClusterUpgradeStatus struct {
+	// State of the upgrade.
+	// +optional
+	State UpgradePhase `json:"state,omitempty"`
+	// Time at which configuration drift was first detected.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// Number of desired workers running with the current configuration.
+	UpdatedWorkers int32 `json:"updatedWorkers"`
+	// Number of desired workers running with an outdated configuration.
+	OutdatedWorkers int32 `json:"outdatedWorkers"`
+	// Number of workers being resized in place.
+	// +optional
+	ResizingWorkers int32 `json:"resizingWorkers,omitempty"`
+	// Whether the head node runs with an outdated configuration.
+	// +optional
+	HeadOutdated bool `json:"headOutdated,omitempty"`
+	// Outdated workers being replaced, keyed by name of the surge worker standing
+	// in for them while they're re-deployed, or by their own name without surge.
+	// +optional
+	Replacements map[string]VMRayWorkerReplacement `json:"replacements,omitempty"`
+}
+
+type VMServiceStatus struct {
+	// First ingress IP of vm service associated with head VirtualMachine.
+	// +optional
+	IP string `json:"ip,omitempty"`
+}
+
+// VMRayClusterStatus defines the observed state of VMRayCluster
+type VMRayClusterStatus struct {
+	// Status of ray head node.
+	// +optional
+	HeadNode VMRayNodeStatus `json:"headNode,omitempty"`
+	// Statuses of each of the current workers
+	// +optional
+	CurrentWorkers map[string]VMRayNodeStatus `json:"currentWorkers,omitempty"`
+	// Overall state of the Ray cluster
+	// +optional
+	Phase VMRayClusterPhase `json:"phase,omitempty"`
+	// Conditions describes the observed conditions of the VMRayCluster.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Status of VM service associated with head VirtualMachine.
+	// +optional
+	VMService VMServiceStatus `json:"vmService,omitempty"`
+	// Last time ray dashboard reported running jobs or client connections.
+	// +optional
+	LastActivityTime *metav1.Time `json:"lastActivityTime,omitempty"`
+	// Progress of replacing ray nodes whose configuration changed, unset when all nodes are up to date.
+	// +optional
+	Upgrade *VMRayClusterUpgradeStatus `json:"upgrade,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Head IP",type=string,JSONPath=`.status.headNode.ip`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// VMRayCluster is the Schema for the vmrayclusters API
+type VMRayCluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// The configuration of the Ray cluster
+	Spec VMRayClusterSpec `json:"spec,omitempty"`
+	// Requests of ray autoscaler, they're written by the autoscaler rather than the cluster's owner.
+	// +optional
+	Autoscaler VMRayClusterAutoscaler `json:"autoscaler,omitempty"`
+	// The Ray cluster status
+	Status VMRayClusterStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// VMRayClusterList contains a list of VMRayCluster
+type VMRayClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	// The list of ray clusters
+	Items []VMRayCluster `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VMRayCluster{}, &VMRayClusterList{})
+}
@@ -4,18 +4,23 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"flag"
+	"fmt"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
@@ -23,8 +28,14 @@ import (
 
 	vmopv1 "github.com/vmware-tanzu/vm-operator/api/v1alpha2"
 	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+	vmrayv1alpha2 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha2"
 	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/internal/controller"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/internal/controller/gc"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/internal/controller/migration"
+	vmprovider "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/pod"
 	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/vmop"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/raydashboard"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -37,8 +48,11 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(vmrayv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(vmrayv1alpha2.AddToScheme(scheme))
 
 	utilruntime.Must(vmopv1.AddToScheme(scheme))
+
+	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
 }
 
@@ -48,6 +62,9 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var providerName string
+	var gcOptions gc.Options
+	var migrateStorageVersion bool
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
@@ -57,6 +74,17 @@ func main() {
 		"If set the metrics endpoint is served securely")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.StringVar(&providerName, "provider", "vmop",
+		"Provider used to deploy ray nodes, \"vmop\" deploys vm-operator VirtualMachines "+
+			"& \"pod\" deploys plain pods, e.g. for development & CI on kind clusters.")
+	flag.DurationVar(&gcOptions.Interval, "gc-interval", 10*time.Minute,
+		"Interval at which objects orphaned by deleted ray clusters are garbage collected, 0 disables it.")
+	flag.DurationVar(&gcOptions.GracePeriod, "gc-grace-period", time.Hour,
+		"Duration an object has to stay orphaned for before it's garbage collected.")
+	flag.BoolVar(&gcOptions.DryRun, "gc-dry-run", false,
+		"If set orphaned objects are only reported by events & metrics, without being deleted.")
+	flag.BoolVar(&migrateStorageVersion, "migrate-storage-version", true,
+		"If set objects stored in an outdated version of their CRD are migrated to its storage version on start.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -114,15 +142,82 @@ func main() {
 	}
 
 	// Setup reconciler.
+	var provider vmprovider.VmProvider
+	var managedObjectLists []client.ObjectList
+	switch providerName {
+	case "vmop":
+		vmopProvider := vmop.NewVmOperatorProvider(mgr.GetClient())
+		err = vmopProvider.SetupFieldIndexer(context.Background(), mgr.GetFieldIndexer())
+		provider = vmopProvider
+		managedObjectLists = vmopProvider.ManagedObjectLists()
+	case "pod":
+		podProvider := pod.NewPodProvider(mgr.GetClient())
+		err = podProvider.SetupFieldIndexer(context.Background(), mgr.GetFieldIndexer())
+		provider = podProvider
+		managedObjectLists = podProvider.ManagedObjectLists()
+	default:
+		err = fmt.Errorf("unknown provider `%s`", providerName)
+	}
+	if err != nil {
+		setupLog.Error(err, "unable to create provider")
+		os.Exit(1)
+	}
 	clusterReconciler := controller.NewVMRayClusterReconciler(mgr.GetClient(),
 		mgr.GetScheme(),
-		vmop.NewVmOperatorProvider(mgr.GetClient()),
+		provider,
 	)
 	if err = (clusterReconciler).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "VMRayCluster")
 		os.Exit(1)
 	}
 
+	jobReconciler := controller.NewVMRayJobReconciler(mgr.GetClient(),
+		mgr.GetScheme(),
+		raydashboard.GetDashboardAddress,
+	)
+	if err = (jobReconciler).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "VMRayJob")
+		os.Exit(1)
+	}
+
+	serviceReconciler := controller.NewVMRayServiceReconciler(mgr.GetClient(),
+		mgr.GetScheme(),
+		provider,
+		raydashboard.GetDashboardAddress,
+	)
+	if err = (serviceReconciler).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "VMRayService")
+		os.Exit(1)
+	}
+
+	// Setup garbage collector of orphaned objects.
+	if gcOptions.Interval > 0 {
+		garbageCollector := gc.NewGarbageCollector(mgr.GetClient(),
+			mgr.GetAPIReader(),
+			mgr.GetEventRecorderFor(gc.EventRecorderName),
+			managedObjectLists,
+			gcOptions,
+		)
+		if err = mgr.Add(garbageCollector); err != nil {
+			setupLog.Error(err, "unable to create garbage collector")
+			os.Exit(1)
+		}
+	}
+
+	// Setup migration of objects to the storage version of their CRD.
+	if migrateStorageVersion {
+		migrator := migration.NewStorageVersionMigrator(mgr.GetClient(),
+			mgr.GetAPIReader(),
+			"vmrayclusters."+vmrayv1alpha1.GroupVersion.Group,
+			"vmrayjobs."+vmrayv1alpha1.GroupVersion.Group,
+			"vmrayservices."+vmrayv1alpha1.GroupVersion.Group,
+		)
+		if err = mgr.Add(migrator); err != nil {
+			setupLog.Error(err, "unable to create storage version migrator")
+			os.Exit(1)
+		}
+	}
+
 	// Setup webhooks.
 	if err = (&vmrayv1alpha1.VMRayCluster{}).SetupWebhookWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "VMRayCluster")
@@ -0,0 +1,227 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/internal/controller"
+	vmprovider "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider"
+)
+
+/*
+GarbageCollector periodically finds objects the operator created for a ray cluster,
+i.e. VMs, VM services & secrets labeled with their cluster, which are orphaned either
+because their cluster no longer exists or because they were deployed for another
+nounce of the cluster's head node. Such objects are leaked e.g. when a cluster's
+finalizer is removed by hand or its status is lost.
+
+Orphaned objects are reported with a warning event & the vmray_orphaned_objects
+metric once found & are deleted once they stayed orphaned for the grace period,
+unless running in dry-run mode. Clusters being deleted clean up after themselves,
+so their objects aren't considered orphaned.
+*/
+
+const (
+	EventRecorderName     = "vmray-garbage-collector"
+	OrphanedObjectReason  = "OrphanedObject"
+	OrphanDeletedReason   = "OrphanDeleted"
+	orphanedObjectsMetric = "vmray_orphaned_objects"
+)
+
+var orphanedObjects = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: orphanedObjectsMetric,
+	Help: "Number of objects created for ray clusters which are orphaned, by kind.",
+}, []string{"kind"})
+
+func init() {
+	metrics.Registry.MustRegister(orphanedObjects)
+}
+
+type Options struct {
+	// Interval between collections.
+	Interval time.Duration
+	// GracePeriod an object has to stay orphaned for before it's deleted.
+	GracePeriod time.Duration
+	// DryRun only reports orphaned objects, without deleting them.
+	DryRun bool
+}
+
+type orphanKey struct {
+	kind string
+	types.NamespacedName
+}
+
+type GarbageCollector struct {
+	client   client.Client
+	reader   client.Reader
+	recorder record.EventRecorder
+	lists    []client.ObjectList
+	options  Options
+	log      logr.Logger
+	now      func() time.Time
+
+	// Time each orphaned object was first found orphaned at.
+	orphanedSince map[orphanKey]time.Time
+}
+
+// NewGarbageCollector creates garbage collector of the objects of the kinds of
+// the given lists. Objects are listed with reader & deleted with kubeclient.
+func NewGarbageCollector(kubeclient client.Client, reader client.Reader, recorder record.EventRecorder,
+	lists []client.ObjectList, options Options) *GarbageCollector {
+	return &GarbageCollector{
+		client:        kubeclient,
+		reader:        reader,
+		recorder:      recorder,
+		lists:         lists,
+		options:       options,
+		log:           ctrl.Log.WithName("GarbageCollector"),
+		now:           time.Now,
+		orphanedSince: make(map[orphanKey]time.Time),
+	}
+}
+
+// NeedLeaderElection makes only the leading manager collect garbage.
+func (gc *GarbageCollector) NeedLeaderElection() bool {
+	return true
+}
+
+// Start collects garbage every interval till ctx is done.
+func (gc *GarbageCollector) Start(ctx context.Context) error {
+	gc.log.Info("Starting garbage collector", "interval", gc.options.Interval,
+		"grace period", gc.options.GracePeriod, "dry run", gc.options.DryRun)
+	ticker := time.NewTicker(gc.options.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := gc.Collect(ctx); err != nil {
+				gc.log.Error(err, "Failed to collect garbage")
+			}
+		}
+	}
+}
+
+// Collect runs a single collection, reporting newly orphaned objects & deleting
+// those orphaned for longer than the grace period.
+func (gc *GarbageCollector) Collect(ctx context.Context) error {
+	clusterList := &vmrayv1alpha1.VMRayClusterList{}
+	if err := gc.reader.List(ctx, clusterList); err != nil {
+		return err
+	}
+	clusters := make(map[types.NamespacedName]*vmrayv1alpha1.VMRayCluster)
+	for i := range clusterList.Items {
+		cluster := &clusterList.Items[i]
+		clusters[types.NamespacedName{Namespace: cluster.Namespace, Name: cluster.Name}] = cluster
+	}
+
+	now := gc.now()
+	seen := make(map[orphanKey]bool)
+	for _, list := range gc.lists {
+		kind, err := gc.kindOf(list)
+		if err != nil {
+			return err
+		}
+		if err := gc.reader.List(ctx, list, client.HasLabels{vmprovider.ClusterLabel}); err != nil {
+			return err
+		}
+		objs, err := meta.ExtractList(list)
+		if err != nil {
+			return err
+		}
+
+		count := 0
+		for _, o := range objs {
+			obj, ok := o.(client.Object)
+			if !ok || !obj.GetDeletionTimestamp().IsZero() {
+				continue
+			}
+			reason, orphaned := isOrphaned(obj, clusters)
+			if !orphaned {
+				continue
+			}
+			count++
+
+			key := orphanKey{kind: kind, NamespacedName: client.ObjectKeyFromObject(obj)}
+			seen[key] = true
+			since, ok := gc.orphanedSince[key]
+			if !ok {
+				since = now
+				gc.orphanedSince[key] = now
+				gc.log.Info("Found orphaned object", "kind", kind, "namespace", obj.GetNamespace(),
+					"name", obj.GetName(), "reason", reason)
+				gc.recorder.Event(obj, corev1.EventTypeWarning, OrphanedObjectReason,
+					fmt.Sprintf("%s is orphaned as %s", kind, reason))
+			}
+
+			if gc.options.DryRun || now.Sub(since) < gc.options.GracePeriod {
+				continue
+			}
+			if err := gc.client.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+				gc.log.Error(err, "Failed to delete orphaned object", "kind", kind,
+					"namespace", obj.GetNamespace(), "name", obj.GetName())
+				continue
+			}
+			gc.log.Info("Deleted orphaned object", "kind", kind, "namespace", obj.GetNamespace(),
+				"name", obj.GetName())
+			gc.recorder.Event(obj, corev1.EventTypeNormal, OrphanDeletedReason,
+				fmt.Sprintf("Deleted %s orphaned as %s", kind, reason))
+		}
+		orphanedObjects.WithLabelValues(kind).Set(float64(count))
+	}
+
+	// Forget objects which are gone or adopted back.
+	for key := range gc.orphanedSince {
+		if !seen[key] {
+			delete(gc.orphanedSince, key)
+		}
+	}
+	return nil
+}
+
+func (gc *GarbageCollector) kindOf(list client.ObjectList) (string, error) {
+	gvk, err := apiutil.GVKForObject(list, gc.client.Scheme())
+	if err != nil {
+		return "", err
+	}
+	return gvk.Kind[:len(gvk.Kind)-len("List")], nil
+}
+
+// isOrphaned returns whether obj is orphaned & why.
+func isOrphaned(obj client.Object, clusters map[types.NamespacedName]*vmrayv1alpha1.VMRayCluster) (string, bool) {
+	labels := obj.GetLabels()
+	clustername := labels[vmprovider.ClusterLabel]
+	cluster, ok := clusters[types.NamespacedName{Namespace: obj.GetNamespace(), Name: clustername}]
+	if !ok {
+		return fmt.Sprintf("cluster %s no longer exists", clustername), true
+	}
+	if !cluster.ObjectMeta.DeletionTimestamp.IsZero() {
+		return "", false
+	}
+
+	// Only nodes & head node's service are labeled with a nounce, the nounce of a
+	// cluster whose nounce isn't set yet may still be adopted from its head node.
+	nounce, ok := labels[vmprovider.NounceLabel]
+	clusternounce, clusterok := cluster.ObjectMeta.Labels[controller.HeadNodeNounceLabel]
+	if ok && clusterok && nounce != clusternounce {
+		return fmt.Sprintf("its nounce %s doesn't match nounce %s of cluster %s", nounce, clusternounce, clustername), true
+	}
+	return "", false
+}
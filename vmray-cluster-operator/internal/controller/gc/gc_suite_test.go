@@ -0,0 +1,18 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gc
+
+import (
+	"testing"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+func TestGarbageCollector(t *testing.T) {
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	ginkgo.Describe("Unit tests", garbageCollectorTests)
+
+	ginkgo.RunSpecs(t, "Unit testcases to validate garbage collector")
+}
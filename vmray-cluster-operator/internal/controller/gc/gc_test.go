@@ -0,0 +1,198 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gc
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	vmopv1 "github.com/vmware-tanzu/vm-operator/api/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/internal/controller"
+	vmprovider "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/pod"
+)
+
+const namespace = "namespace-gc"
+
+func newCluster(name, nounce string) *vmrayv1alpha1.VMRayCluster {
+	return &vmrayv1alpha1.VMRayCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{controller.HeadNodeNounceLabel: nounce},
+		},
+	}
+}
+
+func newVm(name, clustername, nounce string) *vmopv1.VirtualMachine {
+	return &vmopv1.VirtualMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				vmprovider.ClusterLabel: clustername,
+				vmprovider.NounceLabel:  nounce,
+			},
+		},
+	}
+}
+
+func newSecret(name, clustername string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    vmprovider.GetClusterLabels(clustername),
+		},
+	}
+}
+
+func garbageCollectorTests() {
+
+	var (
+		ctx        context.Context
+		kubeclient client.Client
+		recorder   *record.FakeRecorder
+		now        time.Time
+		lists      []client.ObjectList
+	)
+
+	newGarbageCollector := func(options Options, objs ...client.Object) *GarbageCollector {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(vmopv1.AddToScheme(scheme)).To(Succeed())
+		Expect(vmrayv1alpha1.AddToScheme(scheme)).To(Succeed())
+
+		kubeclient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+		recorder = record.NewFakeRecorder(100)
+		gc := NewGarbageCollector(kubeclient, kubeclient, recorder, lists, options)
+		gc.now = func() time.Time { return now }
+		return gc
+	}
+
+	exists := func(obj client.Object) bool {
+		err := kubeclient.Get(ctx, client.ObjectKeyFromObject(obj), obj)
+		if apierrors.IsNotFound(err) {
+			return false
+		}
+		Expect(err).ToNot(HaveOccurred())
+		return true
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		now = time.Now()
+		lists = []client.ObjectList{
+			&vmopv1.VirtualMachineList{},
+			&corev1.SecretList{},
+		}
+	})
+
+	Context("Orphaned objects", func() {
+		It("Deletes objects of a missing cluster only after the grace period", func() {
+			vm := newVm("gone-h-abcde", "gone", "abcde")
+			secret := newSecret("gone-hsecret", "gone")
+			gc := newGarbageCollector(Options{GracePeriod: time.Hour}, vm, secret)
+
+			Expect(gc.Collect(ctx)).To(Succeed())
+			Expect(exists(vm)).To(BeTrue())
+			Expect(exists(secret)).To(BeTrue())
+			Expect(recorder.Events).To(HaveLen(2))
+			Expect(<-recorder.Events).To(ContainSubstring(OrphanedObjectReason))
+
+			now = now.Add(30 * time.Minute)
+			Expect(gc.Collect(ctx)).To(Succeed())
+			Expect(exists(vm)).To(BeTrue())
+			// Objects are reported once.
+			Expect(recorder.Events).To(HaveLen(1))
+
+			now = now.Add(30 * time.Minute)
+			Expect(gc.Collect(ctx)).To(Succeed())
+			Expect(exists(vm)).To(BeFalse())
+			Expect(exists(secret)).To(BeFalse())
+		})
+
+		It("Deletes nodes whose nounce doesn't match cluster's nounce", func() {
+			cluster := newCluster("cluster", "abcde")
+			current := newVm("cluster-h-abcde", "cluster", "abcde")
+			stale := newVm("cluster-h-fghij", "cluster", "fghij")
+			secret := newSecret("cluster-hsecret", "cluster")
+			gc := newGarbageCollector(Options{}, cluster, current, stale, secret)
+
+			Expect(gc.Collect(ctx)).To(Succeed())
+			Expect(exists(current)).To(BeTrue())
+			Expect(exists(secret)).To(BeTrue())
+			Expect(exists(stale)).To(BeFalse())
+		})
+
+		It("Keeps objects of a cluster whose nounce isn't set yet or which is being deleted", func() {
+			cluster := newCluster("cluster", "")
+			delete(cluster.ObjectMeta.Labels, controller.HeadNodeNounceLabel)
+			deleting := newCluster("deleting", "abcde")
+			deleting.ObjectMeta.Finalizers = []string{"test/finalizer"}
+			vm := newVm("cluster-h-abcde", "cluster", "abcde")
+			deletingVm := newVm("deleting-h-fghij", "deleting", "fghij")
+			gc := newGarbageCollector(Options{}, cluster, deleting, vm, deletingVm)
+			Expect(kubeclient.Delete(ctx, deleting)).To(Succeed())
+
+			Expect(gc.Collect(ctx)).To(Succeed())
+			Expect(exists(vm)).To(BeTrue())
+			Expect(exists(deletingVm)).To(BeTrue())
+			Expect(recorder.Events).To(BeEmpty())
+		})
+
+		It("Only reports orphaned objects in dry-run mode", func() {
+			vm := newVm("gone-h-abcde", "gone", "abcde")
+			gc := newGarbageCollector(Options{DryRun: true}, vm)
+
+			Expect(gc.Collect(ctx)).To(Succeed())
+			now = now.Add(time.Hour)
+			Expect(gc.Collect(ctx)).To(Succeed())
+			Expect(exists(vm)).To(BeTrue())
+			Expect(recorder.Events).To(HaveLen(1))
+		})
+
+		It("Deletes head node's service of a missing cluster deployed by pod provider", func() {
+			lists = pod.NewPodProvider(nil).ManagedObjectLists()
+			gc := newGarbageCollector(Options{})
+
+			_, err := pod.NewPodProvider(kubeclient).DeployVmService(ctx, vmprovider.VmDeploymentRequest{
+				Namespace:   namespace,
+				ClusterName: "gone",
+				Nounce:      "abcde",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      vmprovider.GetHeadNodeName("gone", "abcde"),
+			}}
+			Expect(exists(service)).To(BeTrue())
+
+			Expect(gc.Collect(ctx)).To(Succeed())
+			Expect(exists(service)).To(BeFalse())
+		})
+
+		It("Ignores objects which aren't labeled with a cluster", func() {
+			secret := newSecret("unrelated", "")
+			delete(secret.ObjectMeta.Labels, vmprovider.ClusterLabel)
+			gc := newGarbageCollector(Options{}, secret)
+
+			Expect(gc.Collect(ctx)).To(Succeed())
+			Expect(exists(secret)).To(BeTrue())
+		})
+	})
+}
@@ -13,6 +13,8 @@ import (
 func TestNodeLcm(t *testing.T) {
 	gomega.RegisterFailHandler(ginkgo.Fail)
 	ginkgo.Describe("Unit tests", nodeLifecycleManagerTests)
+	ginkgo.Describe("Ray node unit tests", rayNodeTests)
+	ginkgo.Describe("Placement unit tests", placementTests)
 
 	ginkgo.RunSpecs(t, "Unit testcases to validate node life manager")
 }
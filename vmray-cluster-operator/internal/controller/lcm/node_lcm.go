@@ -10,6 +10,7 @@ import (
 
 	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
 	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -37,6 +38,8 @@ type NodeLcmRequest struct {
 	DockerImage string
 	ApiServer   vmrayv1alpha1.ApiServerInfo
 	EnableTLS   bool
+	// vSphere zone picked for the node, empty when no zones are configured.
+	Zone string
 
 	// Head & common node configs.
 	HeadNodeConfig   vmrayv1alpha1.HeadNodeConfig
@@ -49,33 +52,91 @@ type NodeLcmRequest struct {
 	HeadNodeStatus  *vmrayv1alpha1.VMRayNodeStatus
 	VMServiceStatus *vmrayv1alpha1.VMServiceStatus
 
+	// Cluster's VMs listed at once by the reconciler, keyed by VM name.
+	// Nodes missing from it fall back to fetching their own VM's status.
+	ClusterVms map[string]provider.ClusterVm
+
 	// This specifics what form was leveraged
 	// to submit ray cluster request.
 	RayClusterRequestor provider.RayClusterRequestor
 }
 
+// GetNodeConfigHash returns hash of the configuration the requested node
+// should be deployed with, used to detect nodes with an outdated configuration.
+func GetNodeConfigHash(req NodeLcmRequest) string {
+	return provider.GetNodeConfigHash(NewVmDeploymentRequest(req))
+}
+
+// ResizeNodeVm progresses in place resize of the requested node's VM to
+// the VM class of its node type, returning true once the resize is done.
+func (nlcm *NodeLifecycleManager) ResizeNodeVm(ctx context.Context, req NodeLcmRequest) (bool, error) {
+	return nlcm.pvdr.Resize(ctx, NewVmDeploymentRequest(req))
+}
+
+// NewVmDeploymentRequest translates node lifecycle request to VM provider's deployment request.
+func NewVmDeploymentRequest(req NodeLcmRequest) provider.VmDeploymentRequest {
+	return provider.VmDeploymentRequest{
+		Namespace:           req.Namespace,
+		ClusterName:         req.Clustername,
+		Nounce:              req.Nounce,
+		VmName:              req.Name,
+		NodeType:            req.NodeType,
+		DockerImage:         req.DockerImage,
+		HeadNodeStatus:      req.HeadNodeStatus,
+		ApiServer:           req.ApiServer,
+		HeadNodeConfig:      req.HeadNodeConfig,
+		NodeConfig:          req.NodeConfig,
+		WorkerNodeConfig:    req.WorkerNodeConfig,
+		EnableTLS:           req.EnableTLS,
+		RayClusterRequestor: req.RayClusterRequestor,
+		DockerConfig:        req.DockerConfig,
+		Zone:                req.Zone,
+	}
+}
+
+// ListClusterVms returns all VMs of the cluster keyed by VM name.
+func (nlcm *NodeLifecycleManager) ListClusterVms(ctx context.Context,
+	namespace, clustername string) (map[string]provider.ClusterVm, error) {
+	return nlcm.pvdr.ListClusterVms(ctx, namespace, clustername)
+}
+
+// fetchVmStatus returns status of the node's VM from statuses listed for the whole
+// cluster, VMs missing from the list, e.g. VMs deployed before they were labeled
+// with their cluster or not yet in the cache, are fetched by their name.
+func (nlcm *NodeLifecycleManager) fetchVmStatus(ctx context.Context,
+	req NodeLcmRequest) (*vmrayv1alpha1.VMRayNodeStatus, error) {
+	if vm, ok := req.ClusterVms[req.Name]; ok {
+		return vm.Status, nil
+	}
+	return nlcm.pvdr.FetchVmStatus(ctx, req.Namespace, req.Name)
+}
+
+// updateVmConfig records config hash, VM class & placement found on node's VM, VMs
+// deployed before config hashes were introduced don't carry one and are left as is.
+func updateVmConfig(status, vmStatus *vmrayv1alpha1.VMRayNodeStatus) {
+	if vmStatus.ConfigHash != "" {
+		status.ConfigHash = vmStatus.ConfigHash
+	}
+	if vmStatus.VMClass != "" {
+		status.VMClass = vmStatus.VMClass
+	}
+	if vmStatus.Zone != "" {
+		status.Zone = vmStatus.Zone
+	}
+	if vmStatus.Host != "" {
+		status.Host = vmStatus.Host
+	}
+}
+
 func (nlcm *NodeLifecycleManager) ProcessNodeVmState(ctx context.Context, req NodeLcmRequest) error {
 
 	log := ctrl.LoggerFrom(ctx)
+	req.NodeStatus.NodeType = req.NodeType
+
 	switch req.NodeStatus.VmStatus {
 	case vmrayv1alpha1.EMPTY:
 		// Case where node is not created and request just came in so its status is not set.
-		deploymentRequest := provider.VmDeploymentRequest{
-			Namespace:           req.Namespace,
-			ClusterName:         req.Clustername,
-			Nounce:              req.Nounce,
-			VmName:              req.Name,
-			NodeType:            req.NodeType,
-			DockerImage:         req.DockerImage,
-			HeadNodeStatus:      req.HeadNodeStatus,
-			ApiServer:           req.ApiServer,
-			HeadNodeConfig:      req.HeadNodeConfig,
-			NodeConfig:          req.NodeConfig,
-			WorkerNodeConfig:    req.WorkerNodeConfig,
-			EnableTLS:           req.EnableTLS,
-			RayClusterRequestor: req.RayClusterRequestor,
-			DockerConfig:        req.DockerConfig,
-		}
+		deploymentRequest := NewVmDeploymentRequest(req)
 
 		// Get Fetch or Create VM service construct before deploying head vm.
 		if req.HeadNodeStatus == nil {
@@ -104,11 +165,17 @@ func (nlcm *NodeLifecycleManager) ProcessNodeVmState(ctx context.Context, req No
 
 		// Update node vm status to initialized.
 		log.Info("Deployed node and set its status to INITIALIZED", "VM", req.Name)
+		now := metav1.Now()
+		req.NodeStatus.CreationTime = &now
+		req.NodeStatus.ReadyTime = nil
+		req.NodeStatus.VMClass = req.NodeConfig.NodeTypes[req.NodeType].VMClass
+		req.NodeStatus.ConfigHash = provider.GetNodeConfigHash(deploymentRequest)
+		req.NodeStatus.Zone = req.Zone
 		req.NodeStatus.VmStatus = vmrayv1alpha1.INITIALIZED
 
 	case vmrayv1alpha1.INITIALIZED:
 		// Check if node is created, validate if node IP is assigned.
-		newStatus, err := nlcm.pvdr.FetchVmStatus(ctx, req.Namespace, req.Name)
+		newStatus, err := nlcm.fetchVmStatus(ctx, req)
 		if err != nil {
 			log.Error(err, "Got error when fetching VM status in INITIALIZED node state")
 			req.NodeStatus.VmStatus = vmrayv1alpha1.FAIL
@@ -117,12 +184,15 @@ func (nlcm *NodeLifecycleManager) ProcessNodeVmState(ctx context.Context, req No
 		// Update status as per node's VM crd.
 		req.NodeStatus.Ip = newStatus.Ip
 		req.NodeStatus.Conditions = newStatus.Conditions
+		updateVmConfig(req.NodeStatus, newStatus)
 
 		if req.NodeStatus.Ip == "" {
 			// VM is still not up, keep the current state.
 			return nil
 		}
 		// If IP is assigned move the VM status to RUNNING state.
+		now := metav1.Now()
+		req.NodeStatus.ReadyTime = &now
 		req.NodeStatus.VmStatus = vmrayv1alpha1.RUNNING
 		req.NodeStatus.RayStatus = vmrayv1alpha1.RAY_INITIALIZED
 
@@ -130,7 +200,7 @@ func (nlcm *NodeLifecycleManager) ProcessNodeVmState(ctx context.Context, req No
 
 	case vmrayv1alpha1.RUNNING:
 		// Validate if node IP is still available.
-		newStatus, err := nlcm.pvdr.FetchVmStatus(ctx, req.Namespace, req.Name)
+		newStatus, err := nlcm.fetchVmStatus(ctx, req)
 		if err == nil && newStatus.Ip != "" {
 
 			// TODO: Check ray status on node.
@@ -138,6 +208,7 @@ func (nlcm *NodeLifecycleManager) ProcessNodeVmState(ctx context.Context, req No
 
 			req.NodeStatus.Ip = newStatus.Ip
 			req.NodeStatus.Conditions = newStatus.Conditions
+			updateVmConfig(req.NodeStatus, newStatus)
 			log.Info("VM & Ray process are in RUNNING state.", "VM", req.Name)
 			return nil
 		}
@@ -154,7 +225,7 @@ func (nlcm *NodeLifecycleManager) ProcessNodeVmState(ctx context.Context, req No
 
 	case vmrayv1alpha1.FAIL:
 		// Try to fetch VM CRD, to validate if its available.
-		_, err := nlcm.pvdr.FetchVmStatus(ctx, req.Namespace, req.Name)
+		_, err := nlcm.fetchVmStatus(ctx, req)
 		if err == nil {
 			// Set status to `INITIALIZED` mode.
 			log.Info("VM CRD detected, Status changed from FAIL to INITIALIZED", "VM", req.Name)
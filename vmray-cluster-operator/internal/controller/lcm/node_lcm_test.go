@@ -12,10 +12,13 @@ import (
 
 	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
 	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/internal/controller/lcm"
+	vmprovider "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider"
 	mockvmpv "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/mock"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
 )
 
 const (
@@ -98,6 +101,10 @@ func nodeLifecycleManagerTests() {
 
 				provider := mockvmpv.NewMockVmProvider()
 				nlcmReq := getNodeLcmRequest()
+				nlcmReq.NodeType = "worker_1"
+				nlcmReq.NodeConfig.NodeTypes = map[string]vmrayv1alpha1.NodeType{
+					"worker_1": {VMClass: "best-effort-small"},
+				}
 
 				// Set mock provider deploy function.
 				provider.DeployVmServiceSetResponse(1, "192.10.10.1", nil)
@@ -111,6 +118,9 @@ func nodeLifecycleManagerTests() {
 				err := nlcm.ProcessNodeVmState(ctx, nlcmReq)
 				Expect(err).ToNot(HaveOccurred())
 
+				Expect(nlcmReq.NodeStatus.CreationTime).ToNot(BeNil())
+				Expect(nlcmReq.NodeStatus.ReadyTime).To(BeNil())
+
 				err = nlcm.ProcessNodeVmState(ctx, nlcmReq)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(nlcmReq.NodeStatus.VmStatus).To(Equal(vmrayv1alpha1.INITIALIZED))
@@ -118,12 +128,119 @@ func nodeLifecycleManagerTests() {
 				err = nlcm.ProcessNodeVmState(ctx, nlcmReq)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(nlcmReq.NodeStatus.VmStatus).To(Equal(vmrayv1alpha1.RUNNING))
+				Expect(nlcmReq.NodeStatus.ReadyTime).ToNot(BeNil())
+				Expect(nlcmReq.NodeStatus.NodeType).To(Equal("worker_1"))
+				Expect(nlcmReq.NodeStatus.VMClass).To(Equal("best-effort-small"))
 
 				err = nlcm.ProcessNodeVmState(ctx, nlcmReq)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(nlcmReq.NodeStatus.VmStatus).To(Equal(vmrayv1alpha1.RUNNING))
 			})
 
+			It("Test node status is taken from VMs listed for the cluster", func() {
+
+				provider := mockvmpv.NewMockVmProvider()
+				nlcmReq := getNodeLcmRequest()
+				nlcmReq.NodeStatus.VmStatus = vmrayv1alpha1.INITIALIZED
+				nlcmReq.ClusterVms = map[string]vmprovider.ClusterVm{
+					vmname: {Role: vmprovider.NodeRoleWorker, Status: &vmrayv1alpha1.VMRayNodeStatus{Ip: "10.10.10.10"}},
+				}
+
+				// VM listed for the cluster isn't fetched on its own.
+				nlcm := lcm.NewNodeLifecycleManager(provider)
+				err := nlcm.ProcessNodeVmState(ctx, nlcmReq)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(nlcmReq.NodeStatus.VmStatus).To(Equal(vmrayv1alpha1.RUNNING))
+				Expect(nlcmReq.NodeStatus.Ip).To(Equal("10.10.10.10"))
+
+				// VM missing from the list, e.g. not labeled with its cluster, is fetched.
+				nlcmReq.ClusterVms = map[string]vmprovider.ClusterVm{}
+				provider.FetchVmStatusSetResponse(1, &vmrayv1alpha1.VMRayNodeStatus{Ip: "10.10.10.11"}, nil)
+				err = nlcm.ProcessNodeVmState(ctx, nlcmReq)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(nlcmReq.NodeStatus.Ip).To(Equal("10.10.10.11"))
+				Expect(provider.FetchVmStatusGetRequest(1).Name).To(Equal(vmname))
+			})
+
+			It("Test node deployment records config hash", func() {
+
+				provider := mockvmpv.NewMockVmProvider()
+				nlcmReq := getNodeLcmRequest()
+				nlcmReq.NodeType = "worker_1"
+				nlcmReq.NodeConfig.NodeTypes = map[string]vmrayv1alpha1.NodeType{
+					"worker_1": {VMClass: "best-effort-small"},
+				}
+				hash := lcm.GetNodeConfigHash(nlcmReq)
+
+				provider.DeployVmServiceSetResponse(1, "192.10.10.1", nil)
+				provider.DeploySetResponse(1, nil)
+				provider.FetchVmStatusSetResponse(1, &vmrayv1alpha1.VMRayNodeStatus{Ip: "10.10.10.10"}, nil)
+				provider.FetchVmStatusSetResponse(2, &vmrayv1alpha1.VMRayNodeStatus{Ip: "10.10.10.10", ConfigHash: "old-hash"}, nil)
+
+				nlcm := lcm.NewNodeLifecycleManager(provider)
+				err := nlcm.ProcessNodeVmState(ctx, nlcmReq)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(nlcmReq.NodeStatus.ConfigHash).To(Equal(hash))
+
+				// VM without config hash annotation retains the recorded hash.
+				err = nlcm.ProcessNodeVmState(ctx, nlcmReq)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(nlcmReq.NodeStatus.ConfigHash).To(Equal(hash))
+
+				// Hash found on the VM takes precedence.
+				err = nlcm.ProcessNodeVmState(ctx, nlcmReq)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(nlcmReq.NodeStatus.ConfigHash).To(Equal("old-hash"))
+
+				// Hash changes along with the configuration of the node.
+				nlcmReq.DockerImage = "new-docker-img"
+				Expect(lcm.GetNodeConfigHash(nlcmReq)).ToNot(Equal(hash))
+				nlcmReq.DockerImage = dockerimg
+				nlcmReq.NodeConfig.NodeTypes["worker_1"] = vmrayv1alpha1.NodeType{VMClass: "best-effort-large"}
+				Expect(lcm.GetNodeConfigHash(nlcmReq)).ToNot(Equal(hash))
+			})
+
+			It("Test node deployment records zone & host of its VM", func() {
+
+				provider := mockvmpv.NewMockVmProvider()
+				nlcmReq := getNodeLcmRequest()
+				nlcmReq.Zone = "zone-a"
+
+				provider.DeployVmServiceSetResponse(1, "192.10.10.1", nil)
+				provider.DeploySetResponse(1, nil)
+				provider.FetchVmStatusSetResponse(1, &vmrayv1alpha1.VMRayNodeStatus{Ip: "10.10.10.10", Zone: "zone-a", Host: "esx-01"}, nil)
+
+				nlcm := lcm.NewNodeLifecycleManager(provider)
+				err := nlcm.ProcessNodeVmState(ctx, nlcmReq)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(provider.DeployGetRequest(1).Zone).To(Equal("zone-a"))
+				Expect(nlcmReq.NodeStatus.Zone).To(Equal("zone-a"))
+
+				err = nlcm.ProcessNodeVmState(ctx, nlcmReq)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(nlcmReq.NodeStatus.Zone).To(Equal("zone-a"))
+				Expect(nlcmReq.NodeStatus.Host).To(Equal("esx-01"))
+			})
+
+			It("Test config hash of resources doesn't change with their notation", func() {
+				req := vmprovider.VmDeploymentRequest{DockerImage: "img", NodeType: "worker_1"}
+				req.NodeConfig.VMImage = "vmi"
+				req.NodeConfig.NodeTypes = map[string]vmrayv1alpha1.NodeType{
+					"worker_1": {VMClass: "best-effort-small", Resources: vmrayv1alpha1.NodeResource{
+						CPU:    resource.NewQuantity(2, resource.DecimalSI),
+						Memory: ptr.To(resource.MustParse("16Gi")),
+						GPU:    ptr.To(resource.MustParse("1")),
+					}},
+				}
+				// Hash of the node with the integer resources it had before they became quantities.
+				Expect(vmprovider.GetNodeConfigHash(req)).To(Equal("b8e90559bcbc4ccd"))
+
+				nt := req.NodeConfig.NodeTypes["worker_1"]
+				nt.Resources.CustomResources = map[string]resource.Quantity{"TPU": resource.MustParse("1")}
+				req.NodeConfig.NodeTypes["worker_1"] = nt
+				Expect(vmprovider.GetNodeConfigHash(req)).ToNot(Equal("b8e90559bcbc4ccd"))
+			})
+
 			It("Test node deployment, failure recovery", func() {
 
 				provider := mockvmpv.NewMockVmProvider()
@@ -0,0 +1,43 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package lcm
+
+import (
+	"slices"
+
+	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+)
+
+// NodeZones returns zones nodes of the node type are spread across, zones of the
+// node type take precedence over zones common to all node types.
+func NodeZones(config vmrayv1alpha1.CommonNodeConfig, nodeType string) []string {
+	if zones := config.NodeTypes[nodeType].Zones; len(zones) > 0 {
+		return zones
+	}
+	return config.Zones
+}
+
+// PickZone returns the zone the node is deployed to, i.e. the zone running the fewest
+// of the other nodes, ties are broken by order of zones. A node with a zone in its
+// status is redeployed as its VM failed there, so its zone is only picked again if
+// it's the only zone left.
+func PickZone(zones []string, status vmrayv1alpha1.VMRayNodeStatus, others []vmrayv1alpha1.VMRayNodeStatus) string {
+	if len(zones) == 0 {
+		return ""
+	}
+	if len(zones) > 1 && slices.Contains(zones, status.Zone) {
+		zones = slices.DeleteFunc(slices.Clone(zones), func(z string) bool { return z == status.Zone })
+	}
+	counts := make(map[string]int, len(zones))
+	for _, other := range others {
+		counts[other.Zone]++
+	}
+	zone := zones[0]
+	for _, z := range zones[1:] {
+		if counts[z] < counts[zone] {
+			zone = z
+		}
+	}
+	return zone
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package lcm_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/internal/controller/lcm"
+)
+
+func placementTests() {
+
+	Describe("Zone placement", func() {
+
+		zones := []string{"zone-a", "zone-b", "zone-c"}
+
+		It("Prefers zones of the node type over common zones", func() {
+			config := vmrayv1alpha1.CommonNodeConfig{
+				Zones: zones,
+				NodeTypes: map[string]vmrayv1alpha1.NodeType{
+					"cpu": {VMClass: "best-effort-small"},
+					"gpu": {VMClass: "vgpu-small", Zones: []string{"zone-b"}},
+				},
+			}
+			Expect(lcm.NodeZones(config, "cpu")).To(Equal(zones))
+			Expect(lcm.NodeZones(config, "gpu")).To(Equal([]string{"zone-b"}))
+			Expect(lcm.NodeZones(vmrayv1alpha1.CommonNodeConfig{}, "cpu")).To(BeEmpty())
+		})
+
+		It("Picks the zone running the fewest nodes", func() {
+			others := []vmrayv1alpha1.VMRayNodeStatus{{Zone: "zone-a"}, {Zone: "zone-b"}, {Zone: "zone-a"}, {}}
+			Expect(lcm.PickZone(zones, vmrayv1alpha1.VMRayNodeStatus{}, others)).To(Equal("zone-c"))
+
+			others = append(others, vmrayv1alpha1.VMRayNodeStatus{Zone: "zone-c"})
+			Expect(lcm.PickZone(zones, vmrayv1alpha1.VMRayNodeStatus{}, others)).To(Equal("zone-b"))
+			Expect(lcm.PickZone(zones, vmrayv1alpha1.VMRayNodeStatus{}, nil)).To(Equal("zone-a"))
+			Expect(lcm.PickZone(nil, vmrayv1alpha1.VMRayNodeStatus{}, others)).To(BeEmpty())
+		})
+
+		It("Avoids the zone the node failed in", func() {
+			others := []vmrayv1alpha1.VMRayNodeStatus{{Zone: "zone-b"}}
+			Expect(lcm.PickZone(zones, vmrayv1alpha1.VMRayNodeStatus{Zone: "zone-a"}, others)).To(Equal("zone-c"))
+			Expect(lcm.PickZone(zones, vmrayv1alpha1.VMRayNodeStatus{Zone: "zone-c"}, others)).To(Equal("zone-a"))
+			Expect(lcm.PickZone(zones, vmrayv1alpha1.VMRayNodeStatus{Zone: "zone-x"}, others)).To(Equal("zone-a"))
+			Expect(zones).To(Equal([]string{"zone-a", "zone-b", "zone-c"}))
+			// The failed zone is picked again if it's the only one.
+			Expect(lcm.PickZone([]string{"zone-b"}, vmrayv1alpha1.VMRayNodeStatus{Zone: "zone-b"}, others)).To(Equal("zone-b"))
+		})
+	})
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package lcm
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/raydashboard"
+)
+
+// IndexRayNodesByIP indexes ray nodes reported by ray dashboard by their IP. A VM
+// whose ray process restarted has both a dead & an alive ray node with the same IP,
+// in which case the alive one, otherwise the most recently started one, is kept.
+func IndexRayNodesByIP(nodes []raydashboard.NodeState) map[string]raydashboard.NodeState {
+	index := make(map[string]raydashboard.NodeState, len(nodes))
+	for _, node := range nodes {
+		if existing, ok := index[node.NodeIP]; ok && !isPreferredRayNode(node, existing) {
+			continue
+		}
+		index[node.NodeIP] = node
+	}
+	return index
+}
+
+func isPreferredRayNode(node, existing raydashboard.NodeState) bool {
+	nodeAlive := node.State == raydashboard.NodeStatusAlive
+	existingAlive := existing.State == raydashboard.NodeStatusAlive
+	if nodeAlive != existingAlive {
+		return nodeAlive
+	}
+	return node.StartTimeMs > existing.StartTimeMs
+}
+
+// UpdateRayNodeStatus sets ray node ID, state & resources of the node status from
+// the ray node running on node's IP. Ray fields are cleared if there is no such node.
+func UpdateRayNodeStatus(status *vmrayv1alpha1.VMRayNodeStatus, nodes map[string]raydashboard.NodeState) {
+	node, ok := nodes[status.Ip]
+	if status.Ip == "" || !ok {
+		status.RayNodeID = ""
+		status.RayNodeState = ""
+		status.RayNodeStateMessage = ""
+		status.RayResources = nil
+		return
+	}
+
+	status.RayNodeID = node.NodeID
+	status.RayNodeState = vmrayv1alpha1.RayNodeState(node.State)
+	status.RayNodeStateMessage = node.StateMessage
+	status.RayResources = make(map[string]string, len(node.ResourcesTotal))
+	for name, value := range node.ResourcesTotal {
+		status.RayResources[name] = strconv.FormatFloat(value, 'f', -1, 64)
+	}
+}
+
+// GPUSetupTimeout bounds the time from a GPU node's VM getting its IP until ray runs
+// with access to its GPUs, it covers installing the NVIDIA driver & container toolkit.
+const GPUSetupTimeout = 30 * time.Minute
+
+// UpdateGPUStatus sets readiness of the node's GPUs from its ray node. Ray's container
+// only starts once docker's NVIDIA runtime gives it access to the GPUs, so they're
+// ready once ray node is alive. Nodes without GPUs have their GPU status cleared.
+func UpdateGPUStatus(status *vmrayv1alpha1.VMRayNodeStatus, resources vmrayv1alpha1.NodeResource, now time.Time) {
+	if !resources.HasGPU() {
+		status.GPU = nil
+		return
+	}
+	if status.GPU == nil {
+		status.GPU = &vmrayv1alpha1.VMRayNodeGPUStatus{}
+	}
+
+	switch {
+	case status.RayNodeState == vmrayv1alpha1.RayNodeAlive:
+		devices, _ := strconv.ParseFloat(status.RayResources["GPU"], 64)
+		status.GPU.State = vmrayv1alpha1.NODE_GPU_READY
+		status.GPU.Devices = int32(devices)
+		status.GPU.Message = ""
+	case status.VmStatus == vmrayv1alpha1.RUNNING && status.ReadyTime != nil &&
+		now.Sub(status.ReadyTime.Time) > GPUSetupTimeout:
+		status.GPU.State = vmrayv1alpha1.NODE_GPU_NOT_READY
+		status.GPU.Devices = 0
+		status.GPU.Message = fmt.Sprintf("ray didn't start with access to GPUs within %s, "+
+			"see /var/log/vmray-gpu-setup.log of the VM", GPUSetupTimeout)
+	default:
+		status.GPU.State = vmrayv1alpha1.NODE_GPU_PENDING
+		status.GPU.Devices = 0
+		status.GPU.Message = "waiting for NVIDIA driver, container toolkit & ray to start"
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package lcm_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/internal/controller/lcm"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/raydashboard"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func rayNodeTests() {
+
+	Describe("Map ray nodes to VMs", func() {
+
+		nodes := []raydashboard.NodeState{
+			{NodeID: "dead-new", NodeIP: "10.0.0.1", State: raydashboard.NodeStatusDead, StartTimeMs: 3,
+				StateMessage: "health check failed"},
+			{NodeID: "alive", NodeIP: "10.0.0.1", State: raydashboard.NodeStatusAlive, StartTimeMs: 2,
+				ResourcesTotal: map[string]float64{"CPU": 8, "GPU": 1}},
+			{NodeID: "dead-old", NodeIP: "10.0.0.2", State: raydashboard.NodeStatusDead, StartTimeMs: 1},
+			{NodeID: "dead-recent", NodeIP: "10.0.0.2", State: raydashboard.NodeStatusDead, StartTimeMs: 5,
+				StateMessage: "node was drained"},
+		}
+
+		It("Prefers alive, then most recently started, ray node for an IP", func() {
+			index := lcm.IndexRayNodesByIP(nodes)
+			Expect(index).To(HaveLen(2))
+			Expect(index["10.0.0.1"].NodeID).To(Equal("alive"))
+			Expect(index["10.0.0.2"].NodeID).To(Equal("dead-recent"))
+		})
+
+		It("Sets and clears ray fields of node status", func() {
+			index := lcm.IndexRayNodesByIP(nodes)
+
+			status := &vmrayv1alpha1.VMRayNodeStatus{Ip: "10.0.0.1"}
+			lcm.UpdateRayNodeStatus(status, index)
+			Expect(status.RayNodeID).To(Equal("alive"))
+			Expect(status.RayNodeState).To(Equal(vmrayv1alpha1.RayNodeAlive))
+			Expect(status.RayResources).To(Equal(map[string]string{"CPU": "8", "GPU": "1"}))
+
+			status.Ip = "10.0.0.2"
+			lcm.UpdateRayNodeStatus(status, index)
+			Expect(status.RayNodeID).To(Equal("dead-recent"))
+			Expect(status.RayNodeState).To(Equal(vmrayv1alpha1.RayNodeDead))
+			Expect(status.RayNodeStateMessage).To(Equal("node was drained"))
+
+			status.Ip = "10.0.0.3"
+			lcm.UpdateRayNodeStatus(status, index)
+			Expect(status.RayNodeID).To(BeEmpty())
+			Expect(status.RayNodeState).To(BeEmpty())
+			Expect(status.RayResources).To(BeNil())
+		})
+	})
+
+	Describe("GPU readiness", func() {
+
+		gpus := vmrayv1alpha1.NodeResource{GPU: resource.NewQuantity(2, resource.DecimalSI)}
+		now := time.Now()
+
+		It("Is pending until ray node is alive & not ready after the timeout", func() {
+			readyTime := metav1.NewTime(now.Add(-time.Minute))
+			status := &vmrayv1alpha1.VMRayNodeStatus{VmStatus: vmrayv1alpha1.RUNNING, ReadyTime: &readyTime}
+			lcm.UpdateGPUStatus(status, gpus, now)
+			Expect(status.GPU.State).To(Equal(vmrayv1alpha1.NODE_GPU_PENDING))
+
+			lcm.UpdateGPUStatus(status, gpus, now.Add(lcm.GPUSetupTimeout))
+			Expect(status.GPU.State).To(Equal(vmrayv1alpha1.NODE_GPU_NOT_READY))
+			Expect(status.GPU.Message).To(ContainSubstring("/var/log/vmray-gpu-setup.log"))
+
+			status.RayNodeState = vmrayv1alpha1.RayNodeAlive
+			status.RayResources = map[string]string{"CPU": "8", "GPU": "2"}
+			lcm.UpdateGPUStatus(status, gpus, now.Add(lcm.GPUSetupTimeout))
+			Expect(*status.GPU).To(Equal(vmrayv1alpha1.VMRayNodeGPUStatus{State: vmrayv1alpha1.NODE_GPU_READY, Devices: 2}))
+		})
+
+		It("Is cleared for nodes without GPUs", func() {
+			status := &vmrayv1alpha1.VMRayNodeStatus{GPU: &vmrayv1alpha1.VMRayNodeGPUStatus{}}
+			lcm.UpdateGPUStatus(status, vmrayv1alpha1.NodeResource{GPU: resource.NewQuantity(0, resource.DecimalSI)}, now)
+			Expect(status.GPU).To(BeNil())
+		})
+	})
+}
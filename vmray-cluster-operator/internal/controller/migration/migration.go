@@ -0,0 +1,129 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package migration
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+/*
+StorageVersionMigrator moves objects of the operator's CRDs to the CRD's storage
+version once it changed, e.g. from v1alpha1 to v1alpha2. The API server keeps
+objects in the version they were last written in, which the CRD lists in its
+status.storedVersions, so a version can only be removed from the CRD once no
+object is stored in it anymore.
+
+When a CRD has stored versions other than its storage version, each of its objects
+is rewritten unchanged, which stores it in the storage version, and the CRD's stored
+versions are reduced to its storage version. The migration runs once the manager
+becomes leader & is a no-op while all objects are stored in the storage version.
+Failed migrations are retried when the manager is restarted.
+*/
+
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions/status,verbs=update;patch
+
+// listChunkSize limits the number of objects listed at once.
+const listChunkSize = 100
+
+type StorageVersionMigrator struct {
+	client client.Client
+	reader client.Reader
+	crds   []string
+	log    logr.Logger
+}
+
+// NewStorageVersionMigrator creates migrator of the objects of the named CRDs.
+// Objects are read with reader & written with kubeclient.
+func NewStorageVersionMigrator(kubeclient client.Client, reader client.Reader, crds ...string) *StorageVersionMigrator {
+	return &StorageVersionMigrator{
+		client: kubeclient,
+		reader: reader,
+		crds:   crds,
+		log:    ctrl.Log.WithName("StorageVersionMigrator"),
+	}
+}
+
+// NeedLeaderElection makes only the leading manager migrate objects.
+func (m *StorageVersionMigrator) NeedLeaderElection() bool {
+	return true
+}
+
+// Start migrates objects of each CRD, failures are logged so they don't stop the manager.
+func (m *StorageVersionMigrator) Start(ctx context.Context) error {
+	for _, name := range m.crds {
+		if err := m.Migrate(ctx, name); err != nil {
+			m.log.Error(err, "Failed to migrate storage version", "crd", name)
+		}
+	}
+	return nil
+}
+
+// Migrate moves objects of the named CRD to its storage version.
+func (m *StorageVersionMigrator) Migrate(ctx context.Context, name string) error {
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err := m.reader.Get(ctx, client.ObjectKey{Name: name}, crd); err != nil {
+		return err
+	}
+	storageVersion := ""
+	for _, version := range crd.Spec.Versions {
+		if version.Storage {
+			storageVersion = version.Name
+		}
+	}
+	if storageVersion == "" {
+		return fmt.Errorf("crd %s has no storage version", name)
+	}
+	if len(crd.Status.StoredVersions) == 1 && crd.Status.StoredVersions[0] == storageVersion {
+		return nil
+	}
+
+	m.log.Info("Migrating objects to storage version", "crd", name, "stored versions",
+		crd.Status.StoredVersions, "storage version", storageVersion)
+	gvk := schema.GroupVersionKind{Group: crd.Spec.Group, Version: storageVersion, Kind: crd.Spec.Names.ListKind}
+	count := 0
+	continueToken := ""
+	for {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk)
+		if err := m.reader.List(ctx, list, client.Limit(listChunkSize), client.Continue(continueToken)); err != nil {
+			return err
+		}
+		for i := range list.Items {
+			// Objects updated or deleted meanwhile don't need to be migrated anymore.
+			if err := m.client.Update(ctx, &list.Items[i]); err != nil &&
+				!apierrors.IsConflict(err) && !apierrors.IsNotFound(err) {
+				return err
+			}
+			count++
+		}
+		if continueToken = list.GetContinue(); continueToken == "" {
+			break
+		}
+	}
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := m.reader.Get(ctx, client.ObjectKey{Name: name}, crd); err != nil {
+			return err
+		}
+		crd.Status.StoredVersions = []string{storageVersion}
+		return m.client.Status().Update(ctx, crd)
+	})
+	if err != nil {
+		return err
+	}
+	m.log.Info("Migrated objects to storage version", "crd", name, "objects", count,
+		"storage version", storageVersion)
+	return nil
+}
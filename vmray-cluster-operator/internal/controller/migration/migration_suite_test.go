@@ -0,0 +1,18 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package migration
+
+import (
+	"testing"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+func TestStorageVersionMigrator(t *testing.T) {
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	ginkgo.Describe("Unit tests", storageVersionMigratorTests)
+
+	ginkgo.RunSpecs(t, "Unit testcases to validate storage version migrator")
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package migration
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+)
+
+const (
+	namespace = "namespace-migration"
+	crdName   = "vmrayclusters.vmray.broadcom.com"
+)
+
+func newCRD(storedVersions ...string) *apiextensionsv1.CustomResourceDefinition {
+	return &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: crdName},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: vmrayv1alpha1.GroupVersion.Group,
+			Names: apiextensionsv1.CustomResourceDefinitionNames{
+				Plural:   "vmrayclusters",
+				Kind:     "VMRayCluster",
+				ListKind: "VMRayClusterList",
+			},
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{Name: "v1alpha0", Served: true},
+				{Name: vmrayv1alpha1.GroupVersion.Version, Served: true, Storage: true},
+			},
+		},
+		Status: apiextensionsv1.CustomResourceDefinitionStatus{StoredVersions: storedVersions},
+	}
+}
+
+func newCluster(name string) *vmrayv1alpha1.VMRayCluster {
+	return &vmrayv1alpha1.VMRayCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+	}
+}
+
+func storageVersionMigratorTests() {
+	var (
+		ctx        context.Context
+		kubeclient client.Client
+		migrator   *StorageVersionMigrator
+		clusters   []*vmrayv1alpha1.VMRayCluster
+	)
+
+	setup := func(crd *apiextensionsv1.CustomResourceDefinition) {
+		scheme := runtime.NewScheme()
+		Expect(vmrayv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(apiextensionsv1.AddToScheme(scheme)).To(Succeed())
+
+		objs := []client.Object{crd}
+		clusters = nil
+		// More clusters than fit into a single list chunk.
+		for i := 0; i < listChunkSize+1; i++ {
+			cluster := newCluster(fmt.Sprintf("cluster-%d", i))
+			clusters = append(clusters, cluster)
+			objs = append(objs, cluster)
+		}
+		kubeclient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).
+			WithStatusSubresource(crd).Build()
+		migrator = NewStorageVersionMigrator(kubeclient, kubeclient, crdName)
+	}
+
+	getResourceVersions := func() map[string]string {
+		list := &vmrayv1alpha1.VMRayClusterList{}
+		Expect(kubeclient.List(ctx, list)).To(Succeed())
+		versions := make(map[string]string)
+		for _, cluster := range list.Items {
+			versions[cluster.Name] = cluster.ResourceVersion
+		}
+		return versions
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	Context("When objects are stored in an outdated version", func() {
+		It("Rewrites them & prunes stored versions", func() {
+			setup(newCRD("v1alpha0", "v1alpha1"))
+			before := getResourceVersions()
+			Expect(before).To(HaveLen(len(clusters)))
+
+			Expect(migrator.Migrate(ctx, crdName)).To(Succeed())
+
+			after := getResourceVersions()
+			for name, version := range before {
+				Expect(after[name]).ToNot(Equal(version), "cluster %s wasn't rewritten", name)
+			}
+			crd := &apiextensionsv1.CustomResourceDefinition{}
+			Expect(kubeclient.Get(ctx, client.ObjectKey{Name: crdName}, crd)).To(Succeed())
+			Expect(crd.Status.StoredVersions).To(Equal([]string{"v1alpha1"}))
+		})
+	})
+
+	Context("When objects are stored in the storage version", func() {
+		It("Leaves them as they are", func() {
+			setup(newCRD("v1alpha1"))
+			before := getResourceVersions()
+
+			Expect(migrator.Migrate(ctx, crdName)).To(Succeed())
+			Expect(getResourceVersions()).To(Equal(before))
+		})
+	})
+
+	Context("When CRD doesn't exist", func() {
+		It("Fails the migration without stopping the manager", func() {
+			setup(newCRD("v1alpha1"))
+			migrator = NewStorageVersionMigrator(kubeclient, kubeclient, "missing.vmray.broadcom.com")
+
+			Expect(migrator.Migrate(ctx, "missing.vmray.broadcom.com")).ToNot(Succeed())
+			Expect(migrator.Start(ctx)).To(Succeed())
+		})
+	})
+}
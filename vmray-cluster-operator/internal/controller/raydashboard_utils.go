@@ -0,0 +1,7 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+// DashboardAddressFunc returns ray dashboard address for given head node IP.
+type DashboardAddressFunc func(ip string) string
@@ -0,0 +1,81 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package reclaim
+
+import (
+	"time"
+)
+
+/*
+Reclaim decides whether a whole ray cluster should be reclaimed, either because
+it stayed idle longer than its idle TTL or because its expiry time was reached.
+
+Warn decision is returned once the cluster is within the warning lead time of
+being reclaimed, so users get a chance to either use the cluster or protect it.
+*/
+
+type Action int
+
+const (
+	None Action = iota
+	Warn
+	Reclaim
+)
+
+type Trigger string
+
+const (
+	TriggerIdle   Trigger = "idle"
+	TriggerExpiry Trigger = "expiry"
+)
+
+type Policy struct {
+	// Duration cluster may stay idle, nil disables idle reclaim.
+	TTLAfterIdle *time.Duration
+	// Absolute expiry time, nil disables expiry reclaim.
+	ExpiresAt *time.Time
+	// Duration before reclaim time during which warnings are raised.
+	WarningLeadTime time.Duration
+}
+
+type Decision struct {
+	Action  Action
+	Trigger Trigger
+	// Time at which cluster is (or was) due to be reclaimed.
+	ReclaimAt time.Time
+}
+
+// Evaluate computes decision for a cluster which was last
+// active at `lastActivity`, evaluated at `now`.
+func Evaluate(policy Policy, lastActivity, now time.Time) Decision {
+	var decision Decision
+	found := false
+
+	// Choose whichever of the triggers is due first.
+	if policy.ExpiresAt != nil {
+		decision = Decision{Trigger: TriggerExpiry, ReclaimAt: *policy.ExpiresAt}
+		found = true
+	}
+	if policy.TTLAfterIdle != nil {
+		idleReclaimAt := lastActivity.Add(*policy.TTLAfterIdle)
+		if !found || idleReclaimAt.Before(decision.ReclaimAt) {
+			decision = Decision{Trigger: TriggerIdle, ReclaimAt: idleReclaimAt}
+			found = true
+		}
+	}
+
+	if !found {
+		return Decision{Action: None}
+	}
+
+	switch {
+	case !now.Before(decision.ReclaimAt):
+		decision.Action = Reclaim
+	case !now.Before(decision.ReclaimAt.Add(-policy.WarningLeadTime)):
+		decision.Action = Warn
+	default:
+		decision.Action = None
+	}
+	return decision
+}
@@ -0,0 +1,18 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package reclaim_test
+
+import (
+	"testing"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+func TestReclaim(t *testing.T) {
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	ginkgo.Describe("Unit tests", reclaimPolicyTests)
+
+	ginkgo.RunSpecs(t, "Unit testcases to validate cluster reclaim policies")
+}
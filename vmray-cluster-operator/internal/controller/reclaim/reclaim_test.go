@@ -0,0 +1,72 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package reclaim_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/internal/controller/reclaim"
+)
+
+func reclaimPolicyTests() {
+
+	lastActivity := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	ttl := time.Hour
+	lead := 10 * time.Minute
+
+	Describe("Evaluate cluster reclaim policies", func() {
+
+		It("Returns no action when no policy is set", func() {
+			decision := reclaim.Evaluate(reclaim.Policy{WarningLeadTime: lead}, lastActivity, lastActivity.Add(100*time.Hour))
+			Expect(decision.Action).To(Equal(reclaim.None))
+		})
+
+		It("Handles idle ttl", func() {
+			policy := reclaim.Policy{TTLAfterIdle: &ttl, WarningLeadTime: lead}
+
+			decision := reclaim.Evaluate(policy, lastActivity, lastActivity.Add(30*time.Minute))
+			Expect(decision.Action).To(Equal(reclaim.None))
+			Expect(decision.Trigger).To(Equal(reclaim.TriggerIdle))
+			Expect(decision.ReclaimAt).To(Equal(lastActivity.Add(ttl)))
+
+			decision = reclaim.Evaluate(policy, lastActivity, lastActivity.Add(55*time.Minute))
+			Expect(decision.Action).To(Equal(reclaim.Warn))
+
+			decision = reclaim.Evaluate(policy, lastActivity, lastActivity.Add(ttl))
+			Expect(decision.Action).To(Equal(reclaim.Reclaim))
+		})
+
+		It("Handles expiry time", func() {
+			expiresAt := lastActivity.Add(2 * time.Hour)
+			policy := reclaim.Policy{ExpiresAt: &expiresAt, WarningLeadTime: lead}
+
+			decision := reclaim.Evaluate(policy, lastActivity, expiresAt.Add(-11*time.Minute))
+			Expect(decision.Action).To(Equal(reclaim.None))
+
+			decision = reclaim.Evaluate(policy, lastActivity, expiresAt.Add(-time.Minute))
+			Expect(decision.Action).To(Equal(reclaim.Warn))
+			Expect(decision.Trigger).To(Equal(reclaim.TriggerExpiry))
+
+			decision = reclaim.Evaluate(policy, lastActivity, expiresAt.Add(time.Minute))
+			Expect(decision.Action).To(Equal(reclaim.Reclaim))
+		})
+
+		It("Picks the trigger which is due first", func() {
+			expiresAt := lastActivity.Add(30 * time.Minute)
+			policy := reclaim.Policy{TTLAfterIdle: &ttl, ExpiresAt: &expiresAt, WarningLeadTime: lead}
+
+			decision := reclaim.Evaluate(policy, lastActivity, lastActivity.Add(40*time.Minute))
+			Expect(decision.Action).To(Equal(reclaim.Reclaim))
+			Expect(decision.Trigger).To(Equal(reclaim.TriggerExpiry))
+
+			// Recent activity doesn't postpone expiry.
+			decision = reclaim.Evaluate(policy, lastActivity.Add(39*time.Minute), lastActivity.Add(40*time.Minute))
+			Expect(decision.Action).To(Equal(reclaim.Reclaim))
+			Expect(decision.Trigger).To(Equal(reclaim.TriggerExpiry))
+		})
+	})
+}
@@ -8,14 +8,29 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 
+	vmraycontroller "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/internal/controller"
+	vmprovider "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider"
+	fakedashboard "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/raydashboard/fake"
 	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/test/builder"
 )
 
 var suite *builder.TestSuite = builder.NewTestSuiteWithoutManager()
 
+// dashboard is a fake ray dashboard reporting no ray nodes, it serves
+// cluster reconcilers of tests which don't exercise ray dashboard.
+var dashboard = fakedashboard.NewServer()
+
+func newClusterReconciler(provider vmprovider.VmProvider) *vmraycontroller.VMRayClusterReconciler {
+	reconciler := vmraycontroller.NewVMRayClusterReconciler(suite.GetK8sClient(), suite.GetK8sClient().Scheme(), provider)
+	reconciler.DashboardAddress = func(ip string) string { return dashboard.URL }
+	return reconciler
+}
+
 func tests() {
 	Describe("ray head node tests", rayHeadUnitTests)
 	Describe("ray worker worker tests", rayWorkerUnitTests)
+	Describe("ray job tests", rayJobUnitTests)
+	Describe("ray service tests", rayServiceUnitTests)
 }
 
 func TestRayControllers(t *testing.T) {
@@ -24,4 +39,7 @@ func TestRayControllers(t *testing.T) {
 
 var _ = BeforeSuite(suite.BeforeSuite)
 
-var _ = AfterSuite(suite.AfterSuite)
+var _ = AfterSuite(func() {
+	dashboard.Close()
+	suite.AfterSuite()
+})
@@ -11,6 +11,7 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -18,11 +19,13 @@ import (
 	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/internal/controller/lcm"
 	vmprovider "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider"
 	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/vmop/tls"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/raydashboard"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
 const (
 	finalizerName              = "vmraycluster.vmray.broadcom.com"
+	eventRecorderName          = "vmraycluster-controller"
 	HeadNodeNounceLabel        = "vmray.kubernetes.io/head-nounce"
 	nouceLength            int = 5
 	defaultRequeueDuration     = 60 * time.Second
@@ -32,8 +35,12 @@ const (
 // VMRayClusterReconciler reconciles a VMRayCluster object
 type VMRayClusterReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
-	Log    logr.Logger
+	Scheme   *runtime.Scheme
+	Log      logr.Logger
+	Recorder record.EventRecorder
+	// DashboardAddress returns address of head node's ray dashboard, it
+	// defaults to raydashboard.GetDashboardAddress.
+	DashboardAddress DashboardAddressFunc
 
 	provider vmprovider.VmProvider
 	nlcm     *lcm.NodeLifecycleManager
@@ -41,11 +48,12 @@ type VMRayClusterReconciler struct {
 
 func NewVMRayClusterReconciler(client client.Client, Scheme *runtime.Scheme, provider vmprovider.VmProvider) *VMRayClusterReconciler {
 	return &VMRayClusterReconciler{
-		Client:   client,
-		Scheme:   Scheme,
-		provider: provider,
-		nlcm:     lcm.NewNodeLifecycleManager(provider),
-		Log:      ctrl.Log.WithName("VMRayClusterReconciler"),
+		Client:           client,
+		Scheme:           Scheme,
+		DashboardAddress: raydashboard.GetDashboardAddress,
+		provider:         provider,
+		nlcm:             lcm.NewNodeLifecycleManager(provider),
+		Log:              ctrl.Log.WithName("VMRayClusterReconciler"),
 	}
 }
 
@@ -57,6 +65,7 @@ type reconcileEnvelope struct {
 // +kubebuilder:rbac:groups=vmray.broadcom.com,resources=vmrayclusters,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=vmray.broadcom.com,resources=vmrayclusters/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=vmray.broadcom.com,resources=vmrayclusters/finalizers,verbs=update
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -84,7 +93,9 @@ func (r *VMRayClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request
 
 	// If deletion timestamp is non-zero then execute delete reoncile loop.
 	if !instance.ObjectMeta.DeletionTimestamp.IsZero() {
-		if err := r.VMRayClusterDelete(ctx, re.CurrentClusterState); err != nil {
+		if err := r.VMRayClusterDelete(ctx, re.CurrentClusterState); err == errNodesDraining {
+			return r.updateStatus(ctx, re, drainRequeueDuration)
+		} else if err != nil {
 			return r.updateStatus(ctx, re, defaultRequeueDuration)
 		}
 		return ctrl.Result{}, r.removeFinalizer(ctx, re.CurrentClusterState)
@@ -113,9 +124,14 @@ func (r *VMRayClusterReconciler) addFinalizerAndNounce(ctx context.Context, inst
 		}
 
 		// Create a cluster nounce if it doesn't exist, it may exist
-		// if cluster CR was submitted via ray cli using up cmd.
+		// if cluster CR was submitted via ray cli using up cmd. A cluster
+		// CR recreated without its nounce takes over its existing head VM.
 		if _, ok := instance.ObjectMeta.Labels[HeadNodeNounceLabel]; !ok {
-			instance.ObjectMeta.Labels[HeadNodeNounceLabel] = createRandomNounce(nouceLength)
+			nounce, found := r.findHeadNounce(ctx, instance)
+			if !found {
+				nounce = createRandomNounce(nouceLength)
+			}
+			instance.ObjectMeta.Labels[HeadNodeNounceLabel] = nounce
 		}
 
 		return r.Update(ctx, instance)
@@ -145,6 +161,28 @@ func (r *VMRayClusterReconciler) VMRayClusterReconcile(
 	if err := r.addFinalizerAndNounce(ctx, instance); err != nil {
 		return ctrl.Result{}, err
 	}
+
+	// Restore status of the nodes from cluster's VMs if it was lost, before nodes are reconciled.
+	if isNodeStatusEmpty(instance) {
+		r.rebuildNodeStatus(ctx, instance)
+	}
+
+	// If cluster is suspended, tear down its nodes and skip rest of the reconciliation.
+	if instance.Spec.Suspend {
+		// Retain the state as cluster's nodes are already torn down.
+		if re.OriginalClusterState.Status.ClusterState == vmrayv1alpha1.SUSPENDED {
+			instance.Status.ClusterState = vmrayv1alpha1.SUSPENDED
+		}
+		if err := r.suspendCluster(ctx, instance); err == errNodesDraining {
+			return r.updateStatus(ctx, re, drainRequeueDuration)
+		} else if err != nil {
+			instance.Status.ClusterState = vmrayv1alpha1.UNHEALTHY
+			r.Log.Error(err, "VMRayCluster suspend failed", "cluster name", instance.ObjectMeta.Name)
+			addErrorCondition(err, instance, vmrayv1alpha1.VMRayClusterConditionClusterSuspend, vmrayv1alpha1.FailureToSuspendClusterReason)
+		}
+		return r.updateStatus(ctx, re, defaultRequeueDuration)
+	}
+
 	// Setup Root Ca for VMRayCluster
 	err := tls.CreateVMRayClusterRootSecret(ctx, r.Client, instance.Namespace, instance.Name)
 	if err != nil {
@@ -161,7 +199,20 @@ func (r *VMRayClusterReconciler) VMRayClusterReconcile(
 		return r.updateStatus(ctx, re, defaultRequeueDuration)
 	}
 
-	// Step 3: Reconcile head node.
+	// Adopt existing VMs requested by cluster's annotations, before head node gets deployed.
+	if err := r.adoptVms(ctx, instance); err != nil {
+		instance.Status.ClusterState = vmrayv1alpha1.UNHEALTHY
+		addErrorCondition(err, instance, vmrayv1alpha1.VMRayClusterConditionAdoptVms, vmrayv1alpha1.FailureToAdoptVmsReason)
+		return r.updateStatus(ctx, re, defaultRequeueDuration)
+	}
+
+	// Step 3: Replace head node if it's outdated & opted in, then reconcile head node.
+	if err := r.replaceHeadNode(ctx, instance); err == errHeadReplacing {
+		return r.updateStatus(ctx, re, upgradeRequeueDuration)
+	} else if err != nil {
+		r.Log.Error(err, "VMRayCluster failed to replace outdated head node", "cluster name", instance.ObjectMeta.Name)
+		return r.updateStatus(ctx, re, defaultRequeueDuration)
+	}
 	if err := r.reconcileHeadNode(ctx, instance); err != nil {
 		instance.Status.ClusterState = vmrayv1alpha1.UNHEALTHY
 		r.Log.Error(err, "VMRayCluster reconcile head failed", "cluster", instance.ObjectMeta.Name)
@@ -187,10 +238,35 @@ func (r *VMRayClusterReconciler) VMRayClusterReconcile(
 				addErrorCondition(err, instance, vmrayv1alpha1.VMRayClusterConditionWorkerNodeReady, vmrayv1alpha1.FailureToDeployNodeReason)
 			}
 		}
+
+		// Map ray nodes reported by ray dashboard to head & worker VMs.
+		if err := r.syncRayNodeStatus(ctx, instance); err != nil {
+			r.Log.Error(err, "VMRayCluster failed to fetch ray nodes", "cluster name", instance.ObjectMeta.Name)
+		}
+		updateGPUStatus(instance)
+
+		// Replace ray nodes whose configuration changed.
+		if err := r.reconcileUpgrade(ctx, instance); err != nil {
+			r.Log.Error(err, "VMRayCluster failed to upgrade outdated nodes", "cluster name", instance.ObjectMeta.Name)
+		}
+	}
+	requeueDuration := defaultRequeueDuration
+	if isAnyWorkerDraining(instance) {
+		requeueDuration = drainRequeueDuration
+	} else if instance.Status.Upgrade != nil && instance.Status.Upgrade.State != vmrayv1alpha1.UPGRADE_HEAD_PENDING {
+		requeueDuration = upgradeRequeueDuration
+	}
+
+	// Step 5: Suspend or delete the cluster if its idle or expiry policy is triggered.
+	// Deleted cluster is torn down by the reconcile of its deletion, its status isn't updated.
+	if err := r.reconcileReclaimPolicy(ctx, re); err == errClusterReclaimed {
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		r.Log.Error(err, "VMRayCluster reclaim policy evaluation failed", "cluster name", instance.ObjectMeta.Name)
 	}
 
-	// Step 5: Update the Ray cluster instance status.
-	return r.updateStatus(ctx, re, defaultRequeueDuration)
+	// Step 6: Update the Ray cluster instance status.
+	return r.updateStatus(ctx, re, requeueDuration)
 }
 
 func (r *VMRayClusterReconciler) VMRayClusterDelete(ctx context.Context, instance *vmrayv1alpha1.VMRayCluster) error {
@@ -204,22 +280,24 @@ func (r *VMRayClusterReconciler) VMRayClusterDelete(ctx context.Context, instanc
 		return err
 	}
 
-	// Step 2: Delete head node.
-	nounce := instance.ObjectMeta.Labels[HeadNodeNounceLabel]
-	headNodeName := vmprovider.GetHeadNodeName(instance.ObjectMeta.Name, nounce)
-	r.Log.Info("Deleting head node ", "vmname", headNodeName)
-	err = r.provider.Delete(ctx, instance.ObjectMeta.Namespace, headNodeName)
-	if err != nil {
-		r.Log.Error(err, "Failure when trying to delete head node.", "cluster name", instance.ObjectMeta.Name)
-		addErrorCondition(err, instance, vmrayv1alpha1.VMRayClusterConditionClusterDelete, vmrayv1alpha1.FailureToDeleteHeadNodeReason)
+	// Step 2: Delete all worker nodes, before the head node as
+	// their ray nodes are drained through the head node.
+	err = r.deleteWorkerNodes(ctx, instance, true)
+	if err == errNodesDraining {
+		r.Log.Info("Waiting for ray nodes to drain before deleting the cluster", "cluster name", instance.ObjectMeta.Name)
+		return err
+	} else if err != nil {
+		r.Log.Error(err, "Failure when trying to delete worker nodes.", "cluster name", instance.ObjectMeta.Name)
+		addErrorCondition(err, instance, vmrayv1alpha1.VMRayClusterConditionClusterDelete, vmrayv1alpha1.FailureToDeleteWorkerNodeReason)
 		return err
 	}
 
-	// Step 3: Delete all worker nodes.
-	err = r.deleteWorkerNodes(ctx, instance, true)
+	// Step 3: Delete head node.
+	r.Log.Info("Deleting head node ", "vmname", getHeadNodeName(instance))
+	err = r.deleteHeadNode(ctx, instance)
 	if err != nil {
-		r.Log.Error(err, "Failure when trying to delete worker nodes.", "cluster name", instance.ObjectMeta.Name)
-		addErrorCondition(err, instance, vmrayv1alpha1.VMRayClusterConditionClusterDelete, vmrayv1alpha1.FailureToDeleteWorkerNodeReason)
+		r.Log.Error(err, "Failure when trying to delete head node.", "cluster name", instance.ObjectMeta.Name)
+		addErrorCondition(err, instance, vmrayv1alpha1.VMRayClusterConditionClusterDelete, vmrayv1alpha1.FailureToDeleteHeadNodeReason)
 		return err
 	}
 
@@ -229,6 +307,7 @@ func (r *VMRayClusterReconciler) VMRayClusterDelete(ctx context.Context, instanc
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *VMRayClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.Recorder = mgr.GetEventRecorderFor(eventRecorderName)
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&vmrayv1alpha1.VMRayCluster{}).
 		Complete(r)
@@ -0,0 +1,235 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/internal/controller/lcm"
+	vmprovider "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider"
+)
+
+/*
+Adoption takes over VMs deployed outside of the operator, e.g. by `ray up` with the
+vSphere provider, instead of deploying new ones. It's requested with annotations on
+the cluster, see vmrayv1alpha1.AdoptHeadAnnotation, naming the existing head VM & its
+VM service and selecting existing workers either by their names or labels. Adopted
+VMs are verified to match the cluster's spec, labeled like the VMs the operator
+deploys and enter node lifecycle as INITIALIZED with their IPs, so they're managed
+from then on without being recreated. Adoption is done once, AdoptedAnnotation is set on the cluster afterwards.
+Without an adopted VM service the head node is reached on its own IP.
+
+Adopted VMs weren't deployed with the cluster's configuration, they carry
+vmprovider.AdoptedConfigHash and are replaced as outdated nodes by upgrades.
+autoscaler_desired_workers is owned by the autoscaler, adopted workers are marked
+as such in their status instead & kept till the autoscaler lists them.
+*/
+
+const (
+	// AdoptedAnnotation is set on the cluster once its VMs are adopted.
+	AdoptedAnnotation = "vmray.kubernetes.io/adopted"
+	// HeadNodeNameAnnotation records name of the adopted head VM, as it isn't named
+	// after the cluster & its nounce like head VMs deployed by the operator.
+	HeadNodeNameAnnotation = "vmray.kubernetes.io/head-node-name"
+	// HeadVmServiceNameAnnotation records name of the adopted head node's VM service.
+	HeadVmServiceNameAnnotation = "vmray.kubernetes.io/head-vm-service-name"
+)
+
+// getHeadNodeName returns name of cluster's head VM, i.e. name of the adopted head
+// VM if any, otherwise the name derived from cluster's name & nounce.
+func getHeadNodeName(instance *vmrayv1alpha1.VMRayCluster) string {
+	if name, ok := instance.ObjectMeta.Annotations[HeadNodeNameAnnotation]; ok {
+		return name
+	}
+	return vmprovider.GetHeadNodeName(instance.ObjectMeta.Name, instance.ObjectMeta.Labels[HeadNodeNounceLabel])
+}
+
+func isAdoptionRequested(instance *vmrayv1alpha1.VMRayCluster) bool {
+	if _, ok := instance.ObjectMeta.Annotations[AdoptedAnnotation]; ok {
+		return false
+	}
+	for _, annotation := range []string{vmrayv1alpha1.AdoptHeadAnnotation,
+		vmrayv1alpha1.AdoptWorkersAnnotation, vmrayv1alpha1.AdoptWorkerSelectorAnnotation} {
+		if _, ok := instance.ObjectMeta.Annotations[annotation]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// adoptVms adopts the VMs requested by cluster's annotations. An error leaves adoption
+// to be retried in the next reconcile, adopting already adopted VMs is idempotent.
+func (r *VMRayClusterReconciler) adoptVms(ctx context.Context, instance *vmrayv1alpha1.VMRayCluster) error {
+	if !isAdoptionRequested(instance) {
+		return nil
+	}
+	r.Log.Info("Adopting existing VMs", "cluster name", instance.ObjectMeta.Name)
+
+	annotations := map[string]string{AdoptedAnnotation: "true"}
+	headStatus := instance.Status.HeadNodeStatus
+	serviceStatus := instance.Status.VMServiceStatus
+
+	// Step 1: Adopt head node & its VM service.
+	if headname, ok := instance.ObjectMeta.Annotations[vmrayv1alpha1.AdoptHeadAnnotation]; ok {
+		req := newHeadLcmRequest(instance)
+		req.Name = headname
+		deploymentRequest := lcm.NewVmDeploymentRequest(req)
+
+		status, err := r.provider.AdoptVm(ctx, deploymentRequest)
+		if err != nil {
+			return r.adoptionFailed(instance, fmt.Errorf("failed to adopt head VM %s: %w", headname, err))
+		}
+		headStatus = newAdoptedNodeStatus(status, req.NodeType)
+		annotations[HeadNodeNameAnnotation] = headname
+
+		if servicename, ok := instance.ObjectMeta.Annotations[vmrayv1alpha1.AdoptHeadServiceAnnotation]; ok {
+			ip, err := r.provider.AdoptVmService(ctx, deploymentRequest, servicename)
+			if err != nil {
+				return r.adoptionFailed(instance, fmt.Errorf("failed to adopt head VM service %s: %w", servicename, err))
+			}
+			if ip == "" {
+				return fmt.Errorf("IP of head VM service %s isn't assigned yet", servicename)
+			}
+			serviceStatus.Ip = ip
+			annotations[HeadVmServiceNameAnnotation] = servicename
+		}
+	}
+
+	// Step 2: Adopt workers, whose node types are resolved from their VM class.
+	workernames, err := r.findWorkersToAdopt(ctx, instance)
+	if err != nil {
+		return r.adoptionFailed(instance, err)
+	}
+	workers := make(map[string]vmrayv1alpha1.VMRayNodeStatus)
+	for _, name := range workernames {
+		if _, ok := instance.Status.CurrentWorkers[name]; ok {
+			continue
+		}
+		req := newWorkerLcmRequest(instance, name, "")
+		req.HeadNodeStatus = &headStatus
+		status, err := r.provider.AdoptVm(ctx, lcm.NewVmDeploymentRequest(req))
+		if err != nil {
+			return r.adoptionFailed(instance, fmt.Errorf("failed to adopt worker VM %s: %w", name, err))
+		}
+		adopted := newAdoptedNodeStatus(status, status.NodeType)
+		_, desired := instance.Spec.AutoscalerDesiredWorkers[name]
+		adopted.Adopted = !desired
+		workers[name] = adopted
+	}
+
+	// Step 3: Record adoption on the cluster. A copy is patched, as patch response
+	// would override status changes made in this reconcile.
+	obj := instance.DeepCopy()
+	patch := client.MergeFrom(obj.DeepCopy())
+	if obj.ObjectMeta.Annotations == nil {
+		obj.ObjectMeta.Annotations = make(map[string]string)
+	}
+	for k, v := range annotations {
+		obj.ObjectMeta.Annotations[k] = v
+	}
+	if err := r.Patch(ctx, obj, patch); err != nil {
+		return err
+	}
+	instance.ObjectMeta.Annotations = obj.ObjectMeta.Annotations
+
+	// Step 4: Import adopted nodes into status.
+	instance.Status.HeadNodeStatus = headStatus
+	instance.Status.VMServiceStatus = serviceStatus
+	if instance.Status.CurrentWorkers == nil {
+		instance.Status.CurrentWorkers = make(map[string]vmrayv1alpha1.VMRayNodeStatus)
+	}
+	for name, status := range workers {
+		instance.Status.CurrentWorkers[name] = status
+	}
+
+	r.recordEvent(instance, corev1.EventTypeNormal, vmrayv1alpha1.VmsAdoptedReason,
+		fmt.Sprintf("Adopted head VM %s & %d worker VMs", instance.ObjectMeta.Annotations[HeadNodeNameAnnotation], len(workers)))
+	return nil
+}
+
+// findWorkersToAdopt returns names of the worker VMs listed or selected by cluster's annotations.
+func (r *VMRayClusterReconciler) findWorkersToAdopt(ctx context.Context, instance *vmrayv1alpha1.VMRayCluster) ([]string, error) {
+	names := []string{}
+	if list, ok := instance.ObjectMeta.Annotations[vmrayv1alpha1.AdoptWorkersAnnotation]; ok {
+		for _, name := range strings.Split(list, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	if s, ok := instance.ObjectMeta.Annotations[vmrayv1alpha1.AdoptWorkerSelectorAnnotation]; ok {
+		selector, err := labels.Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid worker selector %q: %w", s, err)
+		}
+		found, err := r.provider.FindVms(ctx, instance.ObjectMeta.Namespace, selector)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, found...)
+	}
+	return names, nil
+}
+
+func (r *VMRayClusterReconciler) adoptionFailed(instance *vmrayv1alpha1.VMRayCluster, err error) error {
+	r.Log.Error(err, "Failed to adopt existing VMs", "cluster name", instance.ObjectMeta.Name)
+	r.recordEvent(instance, corev1.EventTypeWarning, vmrayv1alpha1.FailureToAdoptVmsReason, err.Error())
+	return err
+}
+
+// newAdoptedNodeStatus returns status of an adopted node, which enters node
+// lifecycle as INITIALIZED to be moved to RUNNING once its IP is verified.
+func newAdoptedNodeStatus(status *vmrayv1alpha1.VMRayNodeStatus, nodeType string) vmrayv1alpha1.VMRayNodeStatus {
+	now := metav1.Now()
+	return vmrayv1alpha1.VMRayNodeStatus{
+		Ip:           status.Ip,
+		Conditions:   status.Conditions,
+		VmStatus:     vmrayv1alpha1.INITIALIZED,
+		NodeType:     nodeType,
+		VMClass:      status.VMClass,
+		ConfigHash:   status.ConfigHash,
+		Zone:         status.Zone,
+		Host:         status.Host,
+		CreationTime: &now,
+	}
+}
+
+// deleteHeadNode deletes cluster's head VM & the adopted head VM service if any,
+// the VM service of a head VM deployed by the operator is deleted along with it.
+func (r *VMRayClusterReconciler) deleteHeadNode(ctx context.Context, instance *vmrayv1alpha1.VMRayCluster) error {
+	if servicename, ok := instance.ObjectMeta.Annotations[HeadVmServiceNameAnnotation]; ok {
+		if err := r.provider.DeleteServeService(ctx, instance.ObjectMeta.Namespace, servicename); err != nil {
+			return err
+		}
+	}
+	return r.provider.Delete(ctx, instance.ObjectMeta.Namespace, getHeadNodeName(instance))
+}
+
+// forgetAdoptedHead drops name of the adopted head VM & its VM service once they're
+// deleted, so a replacement head node is deployed with the operator's naming.
+func (r *VMRayClusterReconciler) forgetAdoptedHead(ctx context.Context, instance *vmrayv1alpha1.VMRayCluster) error {
+	_, headok := instance.ObjectMeta.Annotations[HeadNodeNameAnnotation]
+	_, serviceok := instance.ObjectMeta.Annotations[HeadVmServiceNameAnnotation]
+	if !headok && !serviceok {
+		return nil
+	}
+
+	obj := instance.DeepCopy()
+	patch := client.MergeFrom(obj.DeepCopy())
+	delete(obj.ObjectMeta.Annotations, HeadNodeNameAnnotation)
+	delete(obj.ObjectMeta.Annotations, HeadVmServiceNameAnnotation)
+	if err := r.Patch(ctx, obj, patch); err != nil {
+		return err
+	}
+	instance.ObjectMeta.Annotations = obj.ObjectMeta.Annotations
+	return nil
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/raydashboard"
+)
+
+const (
+	defaultDrainGracePeriod = 300 * time.Second
+	drainRequeueDuration    = 15 * time.Second
+	drainMessage            = "VM of the ray node is being deleted by vmray cluster operator"
+)
+
+// errNodesDraining is returned when worker VMs can't be deleted yet as their ray nodes are being drained.
+var errNodesDraining = errors.New("waiting for ray nodes to drain")
+
+func getDrainGracePeriod(instance *vmrayv1alpha1.VMRayCluster) time.Duration {
+	if instance.Spec.WorkerNode.DrainGracePeriodSeconds == nil {
+		return defaultDrainGracePeriod
+	}
+	return time.Duration(*instance.Spec.WorkerNode.DrainGracePeriodSeconds) * time.Second
+}
+
+func isAnyWorkerDraining(instance *vmrayv1alpha1.VMRayCluster) bool {
+	for _, status := range instance.Status.CurrentWorkers {
+		if status.Drain != nil && status.Drain.State == vmrayv1alpha1.NODE_DRAINING {
+			return true
+		}
+	}
+	return false
+}
+
+// drainWorkerNode drains ray node of the given worker, recording its progress in
+// the worker's status. It returns true once worker's VM can be deleted, i.e. the
+// ray node has no running tasks or the grace period has elapsed.
+func (r *VMRayClusterReconciler) drainWorkerNode(ctx context.Context, instance *vmrayv1alpha1.VMRayCluster, name string) bool {
+	status := instance.Status.CurrentWorkers[name]
+	defer func() {
+		instance.Status.CurrentWorkers[name] = status
+	}()
+
+	// Nothing to drain if ray isn't running on the node or ray can't be reached.
+	gracePeriod := getDrainGracePeriod(instance)
+	if gracePeriod == 0 || status.RayNodeID == "" || status.RayNodeState != vmrayv1alpha1.RayNodeAlive ||
+		instance.Status.HeadNodeStatus.RayStatus != vmrayv1alpha1.RAY_RUNNING {
+		return true
+	}
+	address := getDashboardIp(instance)
+	if address == "" {
+		return true
+	}
+
+	if status.Drain == nil {
+		now := metav1.Now()
+		status.Drain = &vmrayv1alpha1.VMRayNodeDrainStatus{
+			State:     vmrayv1alpha1.NODE_DRAINING,
+			StartTime: &now,
+		}
+		r.Log.Info("Draining ray node of worker", "cluster name", instance.ObjectMeta.Name,
+			"vm", name, "ray node id", status.RayNodeID)
+	}
+
+	switch status.Drain.State {
+	case vmrayv1alpha1.NODE_DRAINED, vmrayv1alpha1.NODE_DRAIN_TIMEOUT:
+		return true
+	}
+
+	remaining := time.Until(status.Drain.StartTime.Add(gracePeriod))
+	if remaining <= 0 {
+		r.Log.Info("Ray node didn't drain within grace period", "cluster name", instance.ObjectMeta.Name,
+			"vm", name, "running tasks", status.Drain.RunningTasks)
+		status.Drain.State = vmrayv1alpha1.NODE_DRAIN_TIMEOUT
+		return true
+	}
+
+	drained, err := r.checkRayNodeDrained(ctx, instance, address, status.RayNodeID, remaining, status.Drain)
+	if err != nil {
+		// Keep waiting till the grace period elapses, ray may be reachable again.
+		r.Log.Error(err, "Failed to drain ray node", "cluster name", instance.ObjectMeta.Name, "vm", name)
+		status.Drain.Message = err.Error()
+		return false
+	}
+	if drained {
+		status.Drain.State = vmrayv1alpha1.NODE_DRAINED
+	}
+	return drained
+}
+
+// checkRayNodeDrained asks ray to drain the node, if not already asked, and
+// returns true once there are no tasks running on the node.
+func (r *VMRayClusterReconciler) checkRayNodeDrained(ctx context.Context, instance *vmrayv1alpha1.VMRayCluster,
+	address, nodeID string, deadline time.Duration, drain *vmrayv1alpha1.VMRayNodeDrainStatus) (bool, error) {
+
+	dashboard := raydashboard.NewClient(r.DashboardAddress(address))
+	job, err := dashboard.DrainNode(ctx, nodeID, drainMessage, deadline)
+	if err != nil {
+		return false, err
+	}
+	if job.Status == raydashboard.JobStatusFailed {
+		// Ray may reject the drain, e.g. an older ray version, tasks are
+		// still awaited as the node would be deleted without draining anyway.
+		drain.Message = "ray drain-node failed: " + job.Message
+	}
+
+	tasks, err := dashboard.CountRunningTasks(ctx, nodeID)
+	if err != nil {
+		return false, err
+	}
+	drain.RunningTasks = int32(tasks)
+	return tasks == 0, nil
+}
@@ -13,6 +13,7 @@ import (
 	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
 	vmraycontroller "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/internal/controller"
 	mockvmpv "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/mock"
+	"k8s.io/apimachinery/pkg/api/resource"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -45,7 +46,7 @@ func rayHeadUnitTests() {
 				_ = testutil.CreateRayClusterInstance(ctx, suite.GetK8sClient(), namespace, "vmrayclustertest-test", "not-created")
 
 				// Run a nodeconfig reconclie loop and make sure it is valid.
-				controllerReconciler := vmraycontroller.NewVMRayClusterReconciler(suite.GetK8sClient(), suite.GetK8sClient().Scheme(), provider)
+				controllerReconciler := newClusterReconciler(provider)
 				_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
 				Expect(err).NotTo(HaveOccurred())
 
@@ -66,12 +67,44 @@ func rayHeadUnitTests() {
 				Expect(instance.Status.Conditions[2].Message).To(Equal("virtualmachineclasses.vmoperator.vmware.com \"not-created\" not found"))
 			})
 
+			It("Flag node resources exceeding the VM class without blocking deployment", func() {
+				provider := mockvmpv.NewMockVmProvider()
+				typeNamespacedName := testutil.GetNamespacedName(namespace, "vmrayclustertest-resources")
+				instance := testutil.CreateRayClusterInstance(ctx, suite.GetK8sClient(), namespace, "vmrayclustertest-resources", testobjectname)
+
+				// VM class of the test has no devices, so it provides no GPUs.
+				nt := instance.Spec.NodeConfig.NodeTypes["worker_1"]
+				nt.Resources.GPU = resource.NewQuantity(1, resource.DecimalSI)
+				instance.Spec.NodeConfig.NodeTypes["worker_1"] = nt
+				Expect(suite.GetK8sClient().Update(ctx, instance)).To(Succeed())
+
+				controllerReconciler := newClusterReconciler(provider)
+				provider.DeployVmServiceSetResponse(1, "192.10.10.1", nil)
+				provider.DeploySetResponse(1, nil)
+				_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(suite.GetK8sClient().Get(ctx, typeNamespacedName, instance)).To(Succeed())
+				Expect(instance.Status.Conditions).To(HaveLen(1))
+				Expect(instance.Status.Conditions[0].Type).To(Equal(vmrayv1alpha1.NodeConfigInvalidResources))
+				Expect(instance.Status.Conditions[0].Reason).To(Equal(vmrayv1alpha1.ResourcesExceedVMClassReason))
+				Expect(instance.Status.Conditions[0].Message).To(Equal("resources exceed VM class hardware: " +
+					"node type worker_1 exceeds VM class " + testobjectname + ": gpu 1 > 0"))
+				Expect(instance.Status.HeadNodeStatus.VmStatus).To(Equal(vmrayv1alpha1.INITIALIZED))
+
+				provider.DeleteAuxiliaryResourcesSetResponse(1, nil)
+				provider.DeleteSetResponse(1, nil)
+				testutil.DeleteRayCluster(ctx, suite.GetK8sClient(), typeNamespacedName, instance)
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
 			It("Life cycle of the head node VM, Ray Process and Ray Cluster Status update", func() {
 				provider := mockvmpv.NewMockVmProvider()
 				typeNamespacedName := testutil.GetNamespacedName(namespace, "vmrayclustertest3")
 				instance := testutil.CreateRayClusterInstance(ctx, suite.GetK8sClient(), namespace, "vmrayclustertest3", testobjectname)
 
-				controllerReconciler := vmraycontroller.NewVMRayClusterReconciler(suite.GetK8sClient(), suite.GetK8sClient().Scheme(), provider)
+				controllerReconciler := newClusterReconciler(provider)
 
 				provider.DeployVmServiceSetResponse(1, "192.10.10.1", nil)
 				provider.DeploySetResponse(1, nil)
@@ -140,7 +173,7 @@ func rayHeadUnitTests() {
 			It("Ray Cluster Get fails with instance not found error", func() {
 				provider := mockvmpv.NewMockVmProvider()
 				typeNamespacedName := testutil.GetNamespacedName(namespace, "vmrayclustertest5")
-				controllerReconciler := vmraycontroller.NewVMRayClusterReconciler(suite.GetK8sClient(), suite.GetK8sClient().Scheme(), provider)
+				controllerReconciler := newClusterReconciler(provider)
 				_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{
 					NamespacedName: typeNamespacedName,
 				})
@@ -152,7 +185,7 @@ func rayHeadUnitTests() {
 				provider := mockvmpv.NewMockVmProvider()
 				typeNamespacedName := testutil.GetNamespacedName(namespace, "vmrayclustertest6")
 				instance := testutil.CreateRayClusterInstance(ctx, suite.GetK8sClient(), namespace, "vmrayclustertest6", testobjectname)
-				controllerReconciler := vmraycontroller.NewVMRayClusterReconciler(suite.GetK8sClient(), suite.GetK8sClient().Scheme(), provider)
+				controllerReconciler := newClusterReconciler(provider)
 
 				provider.DeployVmServiceSetResponse(1, "192.10.10.1", nil)
 				provider.DeploySetResponse(1, nil)
@@ -182,7 +215,7 @@ func rayHeadUnitTests() {
 				provider := mockvmpv.NewMockVmProvider()
 				typeNamespacedName := testutil.GetNamespacedName(namespace, "vmrayclustertest7")
 				instance := testutil.CreateRayClusterInstance(ctx, suite.GetK8sClient(), namespace, "vmrayclustertest7", testobjectname)
-				controllerReconciler := vmraycontroller.NewVMRayClusterReconciler(suite.GetK8sClient(), suite.GetK8sClient().Scheme(), provider)
+				controllerReconciler := newClusterReconciler(provider)
 
 				provider.DeployVmServiceSetResponse(1, "192.10.10.1", nil)
 				provider.DeploySetResponse(1, nil)
@@ -216,7 +249,7 @@ func rayHeadUnitTests() {
 
 				origInstance := instance.DeepCopy()
 
-				controllerReconciler := vmraycontroller.NewVMRayClusterReconciler(suite.GetK8sClient(), suite.GetK8sClient().Scheme(), provider)
+				controllerReconciler := newClusterReconciler(provider)
 				err := suite.GetK8sClient().Get(ctx, typeNamespacedName, instance)
 				Expect(err).ToNot(HaveOccurred())
 
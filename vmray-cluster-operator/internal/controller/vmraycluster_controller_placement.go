@@ -0,0 +1,36 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/internal/controller/lcm"
+)
+
+// pickZone returns the zone the node is deployed to, nodes are spread evenly across
+// zones of their node type. Other nodes of the cluster are counted regardless of their
+// node type, unless zone spread is per node type.
+func pickZone(instance *vmrayv1alpha1.VMRayCluster, name, nodeTypeName string, status vmrayv1alpha1.VMRayNodeStatus) string {
+	zones := lcm.NodeZones(instance.Spec.NodeConfig, nodeTypeName)
+	if len(zones) == 0 {
+		return ""
+	}
+
+	others := make([]vmrayv1alpha1.VMRayNodeStatus, 0, len(instance.Status.CurrentWorkers)+1)
+	add := func(other vmrayv1alpha1.VMRayNodeStatus) {
+		if instance.Spec.NodeConfig.ZoneSpread == vmrayv1alpha1.ZoneSpreadNodeType && other.NodeType != nodeTypeName {
+			return
+		}
+		others = append(others, other)
+	}
+	if name != getHeadNodeName(instance) {
+		add(instance.Status.HeadNodeStatus)
+	}
+	for worker, other := range instance.Status.CurrentWorkers {
+		if worker != name {
+			add(other)
+		}
+	}
+	return lcm.PickZone(zones, status, others)
+}
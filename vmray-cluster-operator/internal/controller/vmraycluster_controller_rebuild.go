@@ -0,0 +1,73 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+
+	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+	vmprovider "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider"
+)
+
+// findHeadNounce returns nounce of the cluster's existing head VM, letting a cluster
+// CR recreated without its nounce label take over its head node instead of leaking it.
+func (r *VMRayClusterReconciler) findHeadNounce(ctx context.Context, instance *vmrayv1alpha1.VMRayCluster) (string, bool) {
+	vms, err := r.provider.ListClusterVms(ctx, instance.ObjectMeta.Namespace, instance.ObjectMeta.Name)
+	if err != nil {
+		r.Log.Error(err, "Failed to list VMs of the cluster to find its head node", "cluster name", instance.ObjectMeta.Name)
+		return "", false
+	}
+	for name, vm := range vms {
+		if vm.Role == vmprovider.NodeRoleHead && !vm.Deleting &&
+			name == vmprovider.GetHeadNodeName(instance.ObjectMeta.Name, vm.Nounce) {
+			return vm.Nounce, true
+		}
+	}
+	return "", false
+}
+
+// isNodeStatusEmpty checks if cluster's status has neither head node nor workers,
+// i.e. the cluster is new or its status was lost, e.g. on a backup restore.
+func isNodeStatusEmpty(instance *vmrayv1alpha1.VMRayCluster) bool {
+	return instance.Status.HeadNodeStatus.VmStatus == vmrayv1alpha1.EMPTY && len(instance.Status.CurrentWorkers) == 0
+}
+
+// rebuildNodeStatus restores status of workers from the cluster's labeled VMs once
+// status was lost. Status is a cache of the VMs, rebuilt workers re-enter node
+// lifecycle as INITIALIZED and workers which aren't desired anymore are deleted as
+// usual. Workers deployed for another nounce or whose VMs are being deleted are left
+// to the garbage collector. Head node's status rebuilds on its own, as deploying an
+// existing head VM is ignored.
+func (r *VMRayClusterReconciler) rebuildNodeStatus(ctx context.Context, instance *vmrayv1alpha1.VMRayCluster) {
+	vms, err := r.provider.ListClusterVms(ctx, instance.ObjectMeta.Namespace, instance.ObjectMeta.Name)
+	if err != nil {
+		r.Log.Error(err, "Failed to list VMs of the cluster to rebuild its status", "cluster name", instance.ObjectMeta.Name)
+		return
+	}
+
+	nounce := instance.ObjectMeta.Labels[HeadNodeNounceLabel]
+	for name, vm := range vms {
+		if vm.Role != vmprovider.NodeRoleWorker || vm.Deleting || vm.Nounce != nounce {
+			continue
+		}
+		if _, ok := instance.Status.CurrentWorkers[name]; ok {
+			continue
+		}
+		if instance.Status.CurrentWorkers == nil {
+			instance.Status.CurrentWorkers = make(map[string]vmrayv1alpha1.VMRayNodeStatus)
+		}
+
+		r.Log.Info("Rebuilding status of worker node from its VM", "cluster name", instance.ObjectMeta.Name, "vm", name)
+		instance.Status.CurrentWorkers[name] = vmrayv1alpha1.VMRayNodeStatus{
+			Ip:         vm.Status.Ip,
+			Conditions: vm.Status.Conditions,
+			VmStatus:   vmrayv1alpha1.INITIALIZED,
+			NodeType:   vm.Status.NodeType,
+			VMClass:    vm.Status.VMClass,
+			ConfigHash: vm.Status.ConfigHash,
+			Zone:       vm.Status.Zone,
+			Host:       vm.Status.Host,
+		}
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/internal/controller/reclaim"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/raydashboard"
+)
+
+const (
+	// Warning events are raised for this duration before cluster is reclaimed.
+	reclaimWarningLeadTime = 15 * time.Minute
+)
+
+// errClusterReclaimed is returned once the cluster is deleted by its reclaim policy.
+var errClusterReclaimed = errors.New("cluster is deleted by its reclaim policy")
+
+// reconcileReclaimPolicy tracks cluster's activity using ray dashboard and
+// suspends or deletes the cluster once its idle TTL or expiry time is reached.
+func (r *VMRayClusterReconciler) reconcileReclaimPolicy(ctx context.Context, re reconcileEnvelope) error {
+	instance := re.CurrentClusterState
+	now := time.Now()
+
+	// Cluster creation (or resume) counts as activity.
+	if instance.Status.LastActivityTime == nil {
+		instance.Status.LastActivityTime = &metav1.Time{Time: now}
+	}
+
+	if instance.Spec.TTLSecondsAfterIdle == nil && instance.Spec.ExpiresAt == nil {
+		return nil
+	}
+
+	policy := reclaim.Policy{WarningLeadTime: reclaimWarningLeadTime}
+	if instance.Spec.ExpiresAt != nil {
+		policy.ExpiresAt = &instance.Spec.ExpiresAt.Time
+	}
+
+	// Idle TTL is only evaluated when activity of the cluster could be
+	// determined, an unreachable dashboard doesn't mean cluster is idle.
+	if instance.Spec.TTLSecondsAfterIdle != nil {
+		active, err := r.isClusterActive(ctx, instance)
+		if err != nil {
+			r.Log.Info("Unable to determine cluster activity, skipping idle evaluation",
+				"cluster name", instance.ObjectMeta.Name, "reason", err.Error())
+		} else {
+			if active {
+				instance.Status.LastActivityTime = &metav1.Time{Time: now}
+			}
+			ttl := time.Duration(*instance.Spec.TTLSecondsAfterIdle) * time.Second
+			policy.TTLAfterIdle = &ttl
+		}
+	}
+
+	decision := reclaim.Evaluate(policy, instance.Status.LastActivityTime.Time, now)
+	if decision.Action == reclaim.None {
+		return nil
+	}
+
+	if instance.ObjectMeta.Annotations[vmrayv1alpha1.ProtectedAnnotation] == "true" {
+		r.Log.Info("Cluster is protected, skipping reclaim", "cluster name", instance.ObjectMeta.Name,
+			"trigger", decision.Trigger)
+		return nil
+	}
+
+	reason := vmrayv1alpha1.ClusterIdleReason
+	if decision.Trigger == reclaim.TriggerExpiry {
+		reason = vmrayv1alpha1.ClusterExpiredReason
+	}
+	action := getReclaimAction(instance)
+
+	if decision.Action == reclaim.Warn {
+		msg := fmt.Sprintf("Cluster will be %s at %s due to %s policy, set annotation %s=true to prevent it",
+			getReclaimActionVerb(action), decision.ReclaimAt.UTC().Format(time.RFC3339), decision.Trigger,
+			vmrayv1alpha1.ProtectedAnnotation)
+		condition := metav1.Condition{
+			Type:               vmrayv1alpha1.VMRayClusterConditionClusterIdle,
+			Status:             metav1.ConditionTrue,
+			LastTransitionTime: metav1.NewTime(now),
+			Reason:             reason,
+			Message:            msg,
+		}
+		// Warn only once per reclaim time, message of the condition carries it.
+		previous := meta.FindStatusCondition(re.OriginalClusterState.Status.Conditions, condition.Type)
+		if previous != nil && previous.Status == metav1.ConditionTrue && previous.Message == msg {
+			condition.LastTransitionTime = previous.LastTransitionTime
+		} else {
+			r.recordEvent(instance, corev1.EventTypeWarning, reason, msg)
+		}
+		instance.Status.Conditions = append(instance.Status.Conditions, condition)
+		return nil
+	}
+
+	msg := fmt.Sprintf("Cluster is %s due to %s policy", getReclaimActionVerb(action), decision.Trigger)
+	r.Log.Info(msg, "cluster name", instance.ObjectMeta.Name)
+	r.recordEvent(instance, corev1.EventTypeWarning, reason, msg)
+
+	if action == vmrayv1alpha1.ReclaimActionDelete {
+		if err := r.Client.Delete(ctx, instance); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		return errClusterReclaimed
+	}
+
+	// Patch a copy so in-flight status changes of the instance are not overwritten.
+	suspended := instance.DeepCopy()
+	patch := client.MergeFrom(instance.DeepCopy())
+	suspended.Spec.Suspend = true
+	return r.Client.Patch(ctx, suspended, patch)
+}
+
+// isClusterActive returns true if ray dashboard reports any
+// running jobs or client connections i.e. drivers.
+func (r *VMRayClusterReconciler) isClusterActive(ctx context.Context, instance *vmrayv1alpha1.VMRayCluster) (bool, error) {
+	if instance.Status.HeadNodeStatus.RayStatus != vmrayv1alpha1.RAY_RUNNING {
+		return false, fmt.Errorf("ray process in head node is not running")
+	}
+
+	address := getDashboardIp(instance)
+	if address == "" {
+		return false, fmt.Errorf("head node IP is not assigned")
+	}
+
+	jobs, err := raydashboard.NewClient(r.DashboardAddress(address)).ListJobs(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	submissions, drivers := raydashboard.CountActiveJobs(jobs)
+	return submissions > 0 || drivers > 0, nil
+}
+
+// suspendCluster tears down all ray nodes of the cluster, while retaining
+// cluster's secrets so that it can be brought back by unsetting suspend.
+func (r *VMRayClusterReconciler) suspendCluster(ctx context.Context, instance *vmrayv1alpha1.VMRayCluster) error {
+	if instance.Status.ClusterState == vmrayv1alpha1.SUSPENDED {
+		return nil
+	}
+	r.Log.Info("Suspending vmraycluster", "cluster name", instance.ObjectMeta.Name)
+
+	if err := r.deleteWorkerNodes(ctx, instance, true); err != nil {
+		return err
+	}
+
+	if err := r.deleteHeadNode(ctx, instance); err != nil {
+		return err
+	}
+	// Resumed cluster deploys a new head node instead of the adopted one.
+	if err := r.forgetAdoptedHead(ctx, instance); err != nil {
+		return err
+	}
+
+	instance.Status.HeadNodeStatus = vmrayv1alpha1.VMRayNodeStatus{}
+	instance.Status.VMServiceStatus = vmrayv1alpha1.VMServiceStatus{}
+	// Reset activity so that resumed cluster gets a fresh idle window.
+	instance.Status.LastActivityTime = nil
+	instance.Status.ClusterState = vmrayv1alpha1.SUSPENDED
+	r.recordEvent(instance, corev1.EventTypeNormal, vmrayv1alpha1.ClusterSuspendedReason, "Cluster nodes are deleted as cluster is suspended")
+	return nil
+}
+
+func (r *VMRayClusterReconciler) recordEvent(instance *vmrayv1alpha1.VMRayCluster, eventtype, reason, message string) {
+	// Recorder is only available when reconciler is setup with a manager.
+	if r.Recorder == nil {
+		return
+	}
+	r.Recorder.Event(instance, eventtype, reason, message)
+}
+
+func getReclaimAction(instance *vmrayv1alpha1.VMRayCluster) vmrayv1alpha1.ReclaimAction {
+	if instance.Spec.ReclaimAction == vmrayv1alpha1.ReclaimActionDelete {
+		return vmrayv1alpha1.ReclaimActionDelete
+	}
+	return vmrayv1alpha1.ReclaimActionSuspend
+}
+
+func getReclaimActionVerb(action vmrayv1alpha1.ReclaimAction) string {
+	if action == vmrayv1alpha1.ReclaimActionDelete {
+		return "deleted"
+	}
+	return "suspended"
+}
+
+// getDashboardIp prefers VM service ingress IP and falls back to head node's IP.
+func getDashboardIp(instance *vmrayv1alpha1.VMRayCluster) string {
+	if instance.Status.VMServiceStatus.Ip != "" {
+		return instance.Status.VMServiceStatus.Ip
+	}
+	return instance.Status.HeadNodeStatus.Ip
+}
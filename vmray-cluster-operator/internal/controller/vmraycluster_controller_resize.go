@@ -0,0 +1,143 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/internal/controller/lcm"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/vmop/cloudinit"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/vmop/constants"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/raydashboard"
+)
+
+func isWorkerResizing(status vmrayv1alpha1.VMRayNodeStatus) bool {
+	return status.Resize != nil
+}
+
+// canResizeInPlace checks if in place resize is opted in and VM class is the
+// only configuration of the worker which differs from the desired one.
+func canResizeInPlace(instance *vmrayv1alpha1.VMRayCluster, status vmrayv1alpha1.VMRayNodeStatus, req lcm.NodeLcmRequest) bool {
+	if instance.Spec.UpgradeStrategy.ResizePolicy != vmrayv1alpha1.ResizePolicyInPlace || status.VMClass == "" {
+		return false
+	}
+	nodeType, ok := req.NodeConfig.NodeTypes[req.NodeType]
+	if !ok || nodeType.VMClass == status.VMClass {
+		return false
+	}
+
+	// Hash desired configuration with worker's current VM class, it matches
+	// worker's config hash if nothing but the VM class has changed.
+	nodeTypes := make(map[string]vmrayv1alpha1.NodeType, len(req.NodeConfig.NodeTypes))
+	for name, nt := range req.NodeConfig.NodeTypes {
+		nodeTypes[name] = nt
+	}
+	nodeType.VMClass = status.VMClass
+	nodeTypes[req.NodeType] = nodeType
+	req.NodeConfig.NodeTypes = nodeTypes
+	return lcm.GetNodeConfigHash(req) == status.ConfigHash
+}
+
+// resizeWorkerNode resizes VM of the given worker in place, recording its progress
+// in the worker's status. Ray node is drained first, then the VM is resized by the
+// VM provider and finally ray is restarted on the VM so it advertises its new
+// resources. It returns true once the resize is done.
+func (r *VMRayClusterReconciler) resizeWorkerNode(ctx context.Context, instance *vmrayv1alpha1.VMRayCluster,
+	name, nodeTypeName string) (bool, error) {
+	req := newWorkerLcmRequest(instance, name, nodeTypeName)
+	if status := instance.Status.CurrentWorkers[name]; status.Resize == nil {
+		now := metav1.Now()
+		status.Resize = &vmrayv1alpha1.VMRayNodeResizeStatus{
+			State:     vmrayv1alpha1.NODE_RESIZING,
+			VMClass:   req.NodeConfig.NodeTypes[nodeTypeName].VMClass,
+			StartTime: &now,
+		}
+		instance.Status.CurrentWorkers[name] = status
+		r.Log.Info("Resizing worker in place", "cluster name", instance.ObjectMeta.Name,
+			"vm", name, "from", status.VMClass, "to", status.Resize.VMClass)
+	}
+	if instance.Status.CurrentWorkers[name].Resize.State == vmrayv1alpha1.NODE_RESIZING &&
+		!r.drainWorkerNode(ctx, instance, name) {
+		return false, nil
+	}
+
+	status := instance.Status.CurrentWorkers[name]
+	defer func() {
+		instance.Status.CurrentWorkers[name] = status
+	}()
+
+	switch status.Resize.State {
+	case vmrayv1alpha1.NODE_RESIZING:
+		done, err := r.nlcm.ResizeNodeVm(ctx, req)
+		if err != nil {
+			status.Resize.Message = err.Error()
+			return false, err
+		}
+		if !done {
+			return false, nil
+		}
+		// VM got powered on with the new VM class, let node lifecycle
+		// manager wait for its IP before ray is restarted on it.
+		status.Resize.State = vmrayv1alpha1.NODE_RAY_RESTARTING
+		status.Resize.Message = ""
+		status.Ip = ""
+		status.VmStatus = vmrayv1alpha1.INITIALIZED
+		status.RayStatus = vmrayv1alpha1.RAY_INITIALIZED
+		return false, nil
+
+	case vmrayv1alpha1.NODE_RAY_RESTARTING:
+		if status.VmStatus != vmrayv1alpha1.RUNNING || status.Ip == "" {
+			return false, nil
+		}
+		restarted, err := r.restartRayNode(ctx, instance, req, &status)
+		if err != nil {
+			status.Resize.Message = err.Error()
+			return false, err
+		}
+		if !restarted {
+			return false, nil
+		}
+		r.Log.Info("Resized worker in place", "cluster name", instance.ObjectMeta.Name,
+			"vm", name, "vm class", status.Resize.VMClass)
+		status.Resize = nil
+		status.Drain = nil
+		return true, nil
+	}
+	return false, fmt.Errorf("invalid resize state %s of worker %s", status.Resize.State, name)
+}
+
+// restartRayNode re-creates ray container on worker's VM by running the restart
+// command over ssh from the head node, returning true once the command succeeded.
+// A failed restart is deleted so that it gets retried in the next reconcile.
+func (r *VMRayClusterReconciler) restartRayNode(ctx context.Context, instance *vmrayv1alpha1.VMRayCluster,
+	req lcm.NodeLcmRequest, status *vmrayv1alpha1.VMRayNodeStatus) (bool, error) {
+	address := getDashboardIp(instance)
+	if address == "" {
+		return false, nil
+	}
+	dashboard := raydashboard.NewClient(r.DashboardAddress(address))
+
+	restartID := fmt.Sprintf("%s-%d", req.Name, status.Resize.StartTime.Unix())
+	deploymentRequest := lcm.NewVmDeploymentRequest(req)
+	deploymentRequest.Zone, deploymentRequest.Host = status.Zone, status.Host
+	command := cloudinit.GetWorkerRestartCommand(deploymentRequest, instance.Status.HeadNodeStatus.Ip)
+	job, err := dashboard.RestartNode(ctx, restartID, fmt.Sprintf("%s@%s", req.NodeConfig.VMUser, status.Ip),
+		constants.SSHPvtKeyPath, command)
+	if err != nil {
+		return false, err
+	}
+	switch job.Status {
+	case raydashboard.JobStatusSucceeded:
+		return true, nil
+	case raydashboard.JobStatusFailed, raydashboard.JobStatusStopped:
+		status.Resize.Message = "failed to restart ray: " + job.Message
+		_, err := dashboard.DeleteJob(ctx, job.SubmissionID)
+		return false, err
+	}
+	return false, nil
+}
@@ -0,0 +1,358 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/internal/controller/lcm"
+)
+
+const (
+	defaultMaxUnavailable  = vmrayv1alpha1.DefaultMaxUnavailable
+	defaultMaxSurge        = vmrayv1alpha1.DefaultMaxSurge
+	upgradeRequeueDuration = 15 * time.Second
+	surgeWorkerPattern     = "%s-w-%s"
+)
+
+// errHeadReplacing is returned while outdated head node's VM is being deleted.
+var errHeadReplacing = errors.New("waiting for outdated head node to be deleted")
+
+func getMaxUnavailable(instance *vmrayv1alpha1.VMRayCluster) int {
+	if instance.Spec.UpgradeStrategy.MaxUnavailable == nil {
+		return int(defaultMaxUnavailable)
+	}
+	return int(*instance.Spec.UpgradeStrategy.MaxUnavailable)
+}
+
+func getMaxSurge(instance *vmrayv1alpha1.VMRayCluster) int {
+	if instance.Spec.UpgradeStrategy.MaxSurge == nil {
+		return int(defaultMaxSurge)
+	}
+	return int(*instance.Spec.UpgradeStrategy.MaxSurge)
+}
+
+// isOutdated checks if node was deployed with a configuration other than the
+// desired one, nodes without a recorded config hash are never considered outdated.
+func isOutdated(status vmrayv1alpha1.VMRayNodeStatus, desiredHash string) bool {
+	return status.ConfigHash != "" && status.ConfigHash != desiredHash
+}
+
+func isWorkerReady(instance *vmrayv1alpha1.VMRayCluster, name string) bool {
+	status, ok := instance.Status.CurrentWorkers[name]
+	return ok && status.VmStatus == vmrayv1alpha1.RUNNING && status.RayStatus == vmrayv1alpha1.RAY_RUNNING
+}
+
+// reconcileUpgrade detects ray nodes whose configuration drifted from the spec and
+// replaces outdated workers in a rolling fashion, honoring max_unavailable & max_surge.
+// Once all workers are upgraded, the head node is marked for replacement if opted in.
+//
+// With InPlace resize policy, workers whose VM class is the only change are resized
+// in place instead, counting towards max_unavailable while being resized.
+//
+// autoscaler_desired_workers is owned by the autoscaler, so outdated workers are
+// re-deployed under their own name, replacements are only tracked in status. With
+// surge a temporary surge worker is deployed first, once it's ready the outdated
+// worker's VM is deleted & re-deployed and the surge worker is deleted after the
+// worker is ready again. Otherwise the outdated worker is re-deployed right away.
+func (r *VMRayClusterReconciler) reconcileUpgrade(ctx context.Context, instance *vmrayv1alpha1.VMRayCluster) error {
+	upgrade := instance.Status.Upgrade
+	if upgrade == nil {
+		upgrade = &vmrayv1alpha1.VMRayClusterUpgradeStatus{}
+	}
+	if upgrade.Replacements == nil {
+		upgrade.Replacements = make(map[string]vmrayv1alpha1.VMRayWorkerReplacement)
+	}
+	desired := instance.Spec.AutoscalerDesiredWorkers
+
+	surge, unavailable := 0, 0
+	replacing := make(map[string]bool)
+
+	// Step 1: Progress replacements which are already in flight.
+	names := make([]string, 0, len(upgrade.Replacements))
+	for name := range upgrade.Replacements {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		replacement := upgrade.Replacements[name]
+		surged := name != replacement.Worker
+		if surged {
+			surge++
+		}
+
+		nodeTypeName, ok := desired[replacement.Worker]
+		if !ok {
+			// Outdated worker was scaled down by autoscaler, its VM is deleted by the
+			// worker reconciliation and the surge worker isn't needed anymore.
+			if !surged || r.retireSurgeWorker(ctx, instance, name) {
+				delete(upgrade.Replacements, name)
+			}
+			continue
+		}
+		replacing[replacement.Worker] = true
+
+		// Surge worker has to be ready before the outdated worker goes away.
+		if surged && !replacement.Recreating && !isWorkerReady(instance, name) {
+			continue
+		}
+		hash := lcm.GetNodeConfigHash(newWorkerLcmRequest(instance, replacement.Worker, nodeTypeName))
+		if status, ok := instance.Status.CurrentWorkers[replacement.Worker]; replacement.Recreating ||
+			(ok && isOutdated(status, hash)) {
+			if !surged {
+				unavailable++
+			}
+			if err := r.recreateWorkerNode(ctx, instance, &replacement); err != nil {
+				r.Log.Error(err, "Failed to re-deploy outdated worker", "cluster name", instance.ObjectMeta.Name,
+					"vm", replacement.Worker)
+			}
+			upgrade.Replacements[name] = replacement
+			continue
+		}
+
+		// Outdated worker is re-deployed, wait till it's ready again.
+		if !isWorkerReady(instance, replacement.Worker) {
+			if !surged {
+				unavailable++
+			}
+			continue
+		}
+		if surged && !r.retireSurgeWorker(ctx, instance, name) {
+			continue
+		}
+		r.Log.Info("Replaced outdated worker", "cluster name", instance.ObjectMeta.Name,
+			"worker", replacement.Worker, "surge worker", name)
+		delete(upgrade.Replacements, name)
+	}
+
+	// Step 2: Progress in place resizes, resizing workers are unavailable till ray is restarted on them.
+	resizing := int32(0)
+	for name, nodeTypeName := range desired {
+		if status, ok := instance.Status.CurrentWorkers[name]; !ok || !isWorkerResizing(status) {
+			continue
+		}
+		unavailable++
+		if done, err := r.resizeWorkerNode(ctx, instance, name, nodeTypeName); err != nil {
+			r.Log.Error(err, "Failed to resize worker in place", "cluster name", instance.ObjectMeta.Name, "vm", name)
+		} else if done {
+			unavailable--
+			continue
+		}
+		resizing++
+	}
+
+	// Step 3: Find outdated workers and start resizing or replacing them within the allowed budget.
+	outdated := []string{}
+	updated := int32(0)
+	for name, nodeTypeName := range desired {
+		status, ok := instance.Status.CurrentWorkers[name]
+		if !ok {
+			continue
+		}
+		if !isOutdated(status, lcm.GetNodeConfigHash(newWorkerLcmRequest(instance, name, nodeTypeName))) {
+			updated++
+			continue
+		}
+		outdated = append(outdated, name)
+	}
+	sort.Strings(outdated)
+
+	maxSurge, maxUnavailable := getMaxSurge(instance), getMaxUnavailable(instance)
+	for _, name := range outdated {
+		status := instance.Status.CurrentWorkers[name]
+		if replacing[name] || isWorkerResizing(status) {
+			continue
+		}
+		if canResizeInPlace(instance, status, newWorkerLcmRequest(instance, name, desired[name])) {
+			if unavailable >= maxUnavailable {
+				continue
+			}
+			unavailable++
+			resizing++
+			if _, err := r.resizeWorkerNode(ctx, instance, name, desired[name]); err != nil {
+				r.Log.Error(err, "Failed to resize worker in place", "cluster name", instance.ObjectMeta.Name, "vm", name)
+			}
+			continue
+		}
+		replacement := vmrayv1alpha1.VMRayWorkerReplacement{
+			Worker:   name,
+			NodeType: desired[name],
+		}
+		key := name
+		if surge < maxSurge {
+			// Surge worker is deployed by the worker reconciliation.
+			surge++
+			key = newSurgeWorkerName(instance, upgrade)
+		} else if unavailable < maxUnavailable {
+			unavailable++
+			if err := r.recreateWorkerNode(ctx, instance, &replacement); err != nil {
+				r.Log.Error(err, "Failed to re-deploy outdated worker", "cluster name", instance.ObjectMeta.Name, "vm", name)
+			}
+		} else {
+			break
+		}
+		r.Log.Info("Replacing outdated worker", "cluster name", instance.ObjectMeta.Name,
+			"worker", name, "surge", key != name)
+		upgrade.Replacements[key] = replacement
+	}
+
+	// Step 4: Compute upgrade state & decide whether head node has to be replaced.
+	upgrade.UpdatedWorkers = updated
+	upgrade.OutdatedWorkers = int32(len(outdated))
+	upgrade.ResizingWorkers = resizing
+	upgrade.HeadOutdated = isOutdated(instance.Status.HeadNodeStatus, lcm.GetNodeConfigHash(newHeadLcmRequest(instance)))
+	switch {
+	case len(outdated) > 0 || len(upgrade.Replacements) > 0 || resizing > 0:
+		upgrade.State = vmrayv1alpha1.UPGRADE_WORKERS
+	case upgrade.HeadOutdated && instance.Spec.UpgradeStrategy.UpgradeHead:
+		upgrade.State = vmrayv1alpha1.UPGRADE_HEAD
+	case upgrade.HeadOutdated:
+		upgrade.State = vmrayv1alpha1.UPGRADE_HEAD_PENDING
+	default:
+		if instance.Status.Upgrade != nil {
+			r.Log.Info("Upgrade of ray nodes completed", "cluster name", instance.ObjectMeta.Name)
+		}
+		instance.Status.Upgrade = nil
+		return nil
+	}
+	if upgrade.StartTime == nil {
+		now := metav1.Now()
+		upgrade.StartTime = &now
+	}
+	instance.Status.Upgrade = upgrade
+	return nil
+}
+
+// recreateWorkerNode drains the outdated worker & deletes its VM. Once the VM is gone,
+// worker's status is reset so it gets re-deployed with the current configuration.
+func (r *VMRayClusterReconciler) recreateWorkerNode(ctx context.Context, instance *vmrayv1alpha1.VMRayCluster,
+	replacement *vmrayv1alpha1.VMRayWorkerReplacement) error {
+	name, namespace := replacement.Worker, instance.ObjectMeta.Namespace
+	if !replacement.Recreating {
+		if !r.drainWorkerNode(ctx, instance, name) {
+			return nil
+		}
+		r.Log.Info("Deleting outdated worker", "cluster name", instance.ObjectMeta.Name, "vm", name)
+		if err := r.provider.Delete(ctx, namespace, name); err != nil {
+			return err
+		}
+		replacement.Recreating = true
+	}
+
+	_, err := r.provider.FetchVmStatus(ctx, namespace, name)
+	if client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	if err != nil {
+		r.Log.Info("Outdated worker deleted, re-deploying it", "cluster name", instance.ObjectMeta.Name, "vm", name)
+		instance.Status.CurrentWorkers[name] = vmrayv1alpha1.VMRayNodeStatus{}
+		replacement.Recreating = false
+	}
+	return nil
+}
+
+// retireSurgeWorker drains & deletes the surge worker, returning true once its VM is deleted.
+func (r *VMRayClusterReconciler) retireSurgeWorker(ctx context.Context, instance *vmrayv1alpha1.VMRayCluster, name string) bool {
+	if _, ok := instance.Status.CurrentWorkers[name]; !ok {
+		return true
+	}
+	if err := r.deleteNodes(ctx, []string{name}, instance); err != nil {
+		if err != errNodesDraining {
+			r.Log.Error(err, "Failed to delete surge worker", "cluster name", instance.ObjectMeta.Name, "vm", name)
+		}
+		return false
+	}
+	return true
+}
+
+// isWorkerRecreating checks if VM of the outdated worker is being deleted to re-deploy it.
+func isWorkerRecreating(instance *vmrayv1alpha1.VMRayCluster, name string) bool {
+	if instance.Status.Upgrade == nil {
+		return false
+	}
+	for _, replacement := range instance.Status.Upgrade.Replacements {
+		if replacement.Worker == name && replacement.Recreating {
+			return true
+		}
+	}
+	return false
+}
+
+// getClusterWorkers returns workers desired by the autoscaler along with surge workers
+// deployed by the operator while outdated workers are replaced and adopted workers
+// the autoscaler doesn't list yet.
+func getClusterWorkers(instance *vmrayv1alpha1.VMRayCluster) map[string]string {
+	workers := make(map[string]string, len(instance.Spec.AutoscalerDesiredWorkers))
+	for name, nodeTypeName := range instance.Spec.AutoscalerDesiredWorkers {
+		workers[name] = nodeTypeName
+	}
+	if instance.Status.Upgrade != nil {
+		for name, replacement := range instance.Status.Upgrade.Replacements {
+			if name != replacement.Worker {
+				workers[name] = replacement.NodeType
+			}
+		}
+	}
+	for name, status := range instance.Status.CurrentWorkers {
+		if _, ok := workers[name]; !ok && status.Adopted {
+			workers[name] = status.NodeType
+		}
+	}
+	return workers
+}
+
+// replaceHeadNode deletes outdated head node's VM when head upgrade is in progress,
+// errHeadReplacing is returned till the VM is gone. Afterwards head node's status is
+// reset so the head node gets re-deployed with the current configuration.
+func (r *VMRayClusterReconciler) replaceHeadNode(ctx context.Context, instance *vmrayv1alpha1.VMRayCluster) error {
+	upgrade := instance.Status.Upgrade
+	if upgrade == nil || upgrade.State != vmrayv1alpha1.UPGRADE_HEAD || !instance.Spec.UpgradeStrategy.UpgradeHead {
+		return nil
+	}
+	req := newHeadLcmRequest(instance)
+	if !isOutdated(instance.Status.HeadNodeStatus, lcm.GetNodeConfigHash(req)) {
+		return nil
+	}
+
+	_, err := r.provider.FetchVmStatus(ctx, req.Namespace, req.Name)
+	if client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	if err != nil {
+		r.Log.Info("Outdated head node deleted, re-deploying it", "cluster name", instance.ObjectMeta.Name, "vm", req.Name)
+		instance.Status.HeadNodeStatus = vmrayv1alpha1.VMRayNodeStatus{}
+		// An adopted head node is replaced by a head node deployed by the operator.
+		return r.forgetAdoptedHead(ctx, instance)
+	}
+
+	r.Log.Info("Deleting outdated head node", "cluster name", instance.ObjectMeta.Name, "vm", req.Name)
+	if err := r.deleteHeadNode(ctx, instance); err != nil {
+		return err
+	}
+	return errHeadReplacing
+}
+
+func newSurgeWorkerName(instance *vmrayv1alpha1.VMRayCluster, upgrade *vmrayv1alpha1.VMRayClusterUpgradeStatus) string {
+	for {
+		name := fmt.Sprintf(surgeWorkerPattern, instance.ObjectMeta.Name, createRandomNounce(nouceLength))
+		if _, ok := instance.Spec.AutoscalerDesiredWorkers[name]; ok {
+			continue
+		}
+		if _, ok := instance.Status.CurrentWorkers[name]; ok {
+			continue
+		}
+		if _, ok := upgrade.Replacements[name]; ok {
+			continue
+		}
+		return name
+	}
+}
@@ -5,7 +5,10 @@ package controller
 
 import (
 	"context"
+	"fmt"
 	"math/rand"
+	"sort"
+	"strings"
 	"time"
 
 	vmopv1 "github.com/vmware-tanzu/vm-operator/api/v1alpha2"
@@ -15,6 +18,7 @@ import (
 	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
 	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/internal/controller/lcm"
 	vmprovider "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/raydashboard"
 	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
@@ -31,12 +35,26 @@ const (
 func (r *VMRayClusterReconciler) reconcileHeadNode(ctx context.Context, instance *vmrayv1alpha1.VMRayCluster) error {
 	r.Log.Info("Reconciling head node.")
 
+	req := newHeadLcmRequest(instance)
+	if instance.Status.HeadNodeStatus.VmStatus == vmrayv1alpha1.EMPTY {
+		req.Zone = pickZone(instance, req.Name, req.NodeType, instance.Status.HeadNodeStatus)
+	}
+
+	// Step 2: leverage node lifecycle manager to process headnode state.
+	if err := r.nlcm.ProcessNodeVmState(ctx, req); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func newHeadLcmRequest(instance *vmrayv1alpha1.VMRayCluster) lcm.NodeLcmRequest {
 	nounce := instance.ObjectMeta.Labels[HeadNodeNounceLabel]
-	req := lcm.NodeLcmRequest{
+	return lcm.NodeLcmRequest{
 		Namespace:           instance.ObjectMeta.Namespace,
 		Clustername:         instance.ObjectMeta.Name,
 		Nounce:              nounce,
-		Name:                vmprovider.GetHeadNodeName(instance.ObjectMeta.Name, nounce),
+		Name:                getHeadNodeName(instance),
 		NodeType:            instance.Spec.HeadNode.NodeType,
 		DockerImage:         instance.Spec.Image,
 		ApiServer:           instance.Spec.ApiServer,
@@ -50,13 +68,26 @@ func (r *VMRayClusterReconciler) reconcileHeadNode(ctx context.Context, instance
 		RayClusterRequestor: fetchRayClusterRequestor(instance),
 		DockerConfig:        instance.Spec.DockerConfig,
 	}
+}
 
-	// Step 2: leverage node lifecycle manager to process headnode state.
-	if err := r.nlcm.ProcessNodeVmState(ctx, req); err != nil {
-		return err
+func newWorkerLcmRequest(instance *vmrayv1alpha1.VMRayCluster, name, nodeTypeName string) lcm.NodeLcmRequest {
+	nounce := instance.ObjectMeta.Labels[HeadNodeNounceLabel]
+	return lcm.NodeLcmRequest{
+		Namespace:           instance.ObjectMeta.Namespace,
+		Clustername:         instance.ObjectMeta.Name,
+		Nounce:              nounce,
+		Name:                name,
+		NodeType:            nodeTypeName,
+		DockerImage:         instance.Spec.Image,
+		HeadNodeConfig:      instance.Spec.HeadNode,
+		WorkerNodeConfig:    instance.Spec.WorkerNode,
+		NodeConfig:          instance.Spec.NodeConfig,
+		ApiServer:           instance.Spec.ApiServer,
+		HeadNodeStatus:      &instance.Status.HeadNodeStatus,
+		EnableTLS:           instance.Spec.EnableTLS,
+		RayClusterRequestor: fetchRayClusterRequestor(instance),
+		DockerConfig:        instance.Spec.DockerConfig,
 	}
-
-	return nil
 }
 
 func (r *VMRayClusterReconciler) reconcileWorkerNodes(ctx context.Context, instance *vmrayv1alpha1.VMRayCluster) error {
@@ -73,8 +104,17 @@ func (r *VMRayClusterReconciler) reconcileWorkerNodes(ctx context.Context, insta
 		instance.Spec.AutoscalerDesiredWorkers = make(map[string]string)
 	}
 
+	// Adopted workers listed by the autoscaler are managed like any other worker from now on.
+	for name, status := range instance.Status.CurrentWorkers {
+		if _, ok := instance.Spec.AutoscalerDesiredWorkers[name]; ok && status.Adopted {
+			status.Adopted = false
+			instance.Status.CurrentWorkers[name] = status
+		}
+	}
+
 	// Step 1: Delete current worker nodes which are not mentioned in desired spec anymore.
-	if err := r.deleteWorkerNodes(ctx, instance, false); err != nil {
+	// Workers whose ray nodes are still draining are deleted in a later reconcile.
+	if err := r.deleteWorkerNodes(ctx, instance, false); err != nil && err != errNodesDraining {
 		r.Log.Error(err, "Failed to delete nonessential worker nodes", "VMRayCluster", instance.ObjectMeta.Name)
 		return err
 	}
@@ -86,16 +126,24 @@ func (r *VMRayClusterReconciler) reconcileWorkerNodes(ctx context.Context, insta
 
 func (r *VMRayClusterReconciler) deleteWorkerNodes(ctx context.Context, instance *vmrayv1alpha1.VMRayCluster, all bool) error {
 	nodesToDelete := []string{}
+	workers := getClusterWorkers(instance)
 	for name := range instance.Status.CurrentWorkers {
-		if _, ok := instance.Spec.AutoscalerDesiredWorkers[name]; all || !ok {
+		if _, ok := workers[name]; all || !ok {
 			nodesToDelete = append(nodesToDelete, name)
 		}
 	}
 	return r.deleteNodes(ctx, nodesToDelete, instance)
 }
 
+// deleteNodes deletes VMs of the given workers once their ray nodes are drained,
+// errNodesDraining is returned if any of the workers is still being drained.
 func (r *VMRayClusterReconciler) deleteNodes(ctx context.Context, nodesToDelete []string, instance *vmrayv1alpha1.VMRayCluster) error {
+	draining := false
 	for _, name := range nodesToDelete {
+		if !r.drainWorkerNode(ctx, instance, name) {
+			draining = true
+			continue
+		}
 		err := r.provider.Delete(ctx, instance.ObjectMeta.Namespace, name)
 		if err != nil {
 			return err
@@ -103,12 +151,22 @@ func (r *VMRayClusterReconciler) deleteNodes(ctx context.Context, nodesToDelete
 		delete(instance.Status.CurrentWorkers, name)
 		r.Log.Info("[DeleteWorkerNodes] Successfully deleted ray worker VM", "vm", name)
 	}
+	if draining {
+		return errNodesDraining
+	}
 	return nil
 }
 
 func (r *VMRayClusterReconciler) reconcileDesiredWorkers(ctx context.Context, instance *vmrayv1alpha1.VMRayCluster) error {
 
-	for name, nodeTypeName := range instance.Spec.AutoscalerDesiredWorkers {
+	// List statuses of all the cluster's VMs at once instead of fetching
+	// each worker's VM, on failure workers fall back to fetching their own.
+	clusterVms, err := r.nlcm.ListClusterVms(ctx, instance.ObjectMeta.Namespace, instance.ObjectMeta.Name)
+	if err != nil {
+		r.Log.Error(err, "Failed to list VMs of the cluster", "cluster name", instance.ObjectMeta.Name)
+	}
+
+	for name, nodeTypeName := range getClusterWorkers(instance) {
 		// Check if worker is already present in current workers status map,
 		// if so use those status objects during reconciliation, otherwise create
 		// new status objects and assign them back.
@@ -116,24 +174,22 @@ func (r *VMRayClusterReconciler) reconcileDesiredWorkers(ctx context.Context, in
 		if s, ok := instance.Status.CurrentWorkers[name]; ok {
 			status = s
 		}
+		// VM of a worker being resized is powered off on purpose, the
+		// resize hands it back to node lifecycle manager once powered on.
+		if status.Resize != nil && status.Resize.State == vmrayv1alpha1.NODE_RESIZING {
+			continue
+		}
+		// Likewise VM of an outdated worker is deleted on purpose, the
+		// upgrade resets its status once the VM is gone to re-deploy it.
+		if isWorkerRecreating(instance, name) {
+			continue
+		}
 
-		nounce := instance.ObjectMeta.Labels[HeadNodeNounceLabel]
-		req := lcm.NodeLcmRequest{
-			Namespace:           instance.ObjectMeta.Namespace,
-			Clustername:         instance.ObjectMeta.Name,
-			Nounce:              nounce,
-			Name:                name,
-			NodeType:            nodeTypeName,
-			DockerImage:         instance.Spec.Image,
-			HeadNodeConfig:      instance.Spec.HeadNode,
-			WorkerNodeConfig:    instance.Spec.WorkerNode,
-			NodeConfig:          instance.Spec.NodeConfig,
-			ApiServer:           instance.Spec.ApiServer,
-			NodeStatus:          &status,
-			HeadNodeStatus:      &instance.Status.HeadNodeStatus,
-			EnableTLS:           instance.Spec.EnableTLS,
-			RayClusterRequestor: fetchRayClusterRequestor(instance),
-			DockerConfig:        instance.Spec.DockerConfig,
+		req := newWorkerLcmRequest(instance, name, nodeTypeName)
+		req.NodeStatus = &status
+		req.ClusterVms = clusterVms
+		if status.VmStatus == vmrayv1alpha1.EMPTY {
+			req.Zone = pickZone(instance, name, nodeTypeName, status)
 		}
 
 		err := r.nlcm.ProcessNodeVmState(ctx, req)
@@ -147,6 +203,41 @@ func (r *VMRayClusterReconciler) reconcileDesiredWorkers(ctx context.Context, in
 	return nil
 }
 
+// syncRayNodeStatus maps ray nodes reported by ray dashboard to head & worker
+// VMs by their IP, recording ray node ID, state & resources in their status.
+func (r *VMRayClusterReconciler) syncRayNodeStatus(ctx context.Context, instance *vmrayv1alpha1.VMRayCluster) error {
+	address := getDashboardIp(instance)
+	if address == "" {
+		return nil
+	}
+
+	dashboard := raydashboard.NewClient(r.DashboardAddress(address))
+	nodes, err := dashboard.ListNodes(ctx)
+	if err != nil {
+		return err
+	}
+
+	index := lcm.IndexRayNodesByIP(nodes)
+	lcm.UpdateRayNodeStatus(&instance.Status.HeadNodeStatus, index)
+	for name, status := range instance.Status.CurrentWorkers {
+		lcm.UpdateRayNodeStatus(&status, index)
+		instance.Status.CurrentWorkers[name] = status
+	}
+	return nil
+}
+
+// updateGPUStatus sets readiness of GPUs of head & worker nodes from their ray nodes.
+func updateGPUStatus(instance *vmrayv1alpha1.VMRayCluster) {
+	now := time.Now()
+	nodeTypes := instance.Spec.NodeConfig.NodeTypes
+	head := &instance.Status.HeadNodeStatus
+	lcm.UpdateGPUStatus(head, nodeTypes[instance.Spec.HeadNode.NodeType].Resources, now)
+	for name, status := range instance.Status.CurrentWorkers {
+		lcm.UpdateGPUStatus(&status, nodeTypes[status.NodeType].Resources, now)
+		instance.Status.CurrentWorkers[name] = status
+	}
+}
+
 func addErrorCondition(err error, instance *vmrayv1alpha1.VMRayCluster, Type, Reason string) {
 	instance.Status.Conditions = append(instance.Status.Conditions, metav1.Condition{
 		Type:               Type,
@@ -191,13 +282,18 @@ func (r *VMRayClusterReconciler) ValidateAuxiliaryDependencies(ctx context.Conte
 
 	invalidState := false
 
-	// 1. Validate VM image
+	// 1. Validate VM image, it's either a VirtualMachineImage of the namespace or a ClusterVirtualMachineImage.
 	vmi := vmopv1.VirtualMachineImage{}
 	vmiNamespaceName := types.NamespacedName{
 		Name:      instance.Spec.NodeConfig.VMImage,
 		Namespace: instance.ObjectMeta.Namespace,
 	}
-	if err := r.Client.Get(ctx, vmiNamespaceName, &vmi); err != nil {
+	err := r.Client.Get(ctx, vmiNamespaceName, &vmi)
+	if errors.IsNotFound(err) {
+		cvmi := vmopv1.ClusterVirtualMachineImage{}
+		err = r.Client.Get(ctx, types.NamespacedName{Name: vmiNamespaceName.Name}, &cvmi)
+	}
+	if err != nil {
 		if errors.IsNotFound(err) {
 			addErrorCondition(err, instance, vmrayv1alpha1.NodeConfigInvalidVMI, vmrayv1alpha1.ResourceNotFoundReason)
 		} else {
@@ -207,17 +303,16 @@ func (r *VMRayClusterReconciler) ValidateAuxiliaryDependencies(ctx context.Conte
 		invalidState = true
 	}
 
-	// 2. Validate Storage class.
+	// 2. Validate Storage class, which is cluster scoped.
 	sc := storagev1.StorageClass{}
-	scNamespaceName := types.NamespacedName{
-		Name:      instance.Spec.NodeConfig.StorageClass,
-		Namespace: instance.ObjectMeta.Namespace,
+	scName := types.NamespacedName{
+		Name: instance.Spec.NodeConfig.StorageClass,
 	}
-	if err := r.Client.Get(ctx, scNamespaceName, &sc); err != nil {
+	if err := r.Client.Get(ctx, scName, &sc); err != nil {
 		if errors.IsNotFound(err) {
 			addErrorCondition(err, instance, vmrayv1alpha1.NodeConfigInvalidStorageClass, vmrayv1alpha1.ResourceNotFoundReason)
 		} else {
-			r.Log.Error(err, "Failure when trying to fetch storage class.", "Namespace", scNamespaceName.Namespace, "Name", scNamespaceName.Name)
+			r.Log.Error(err, "Failure when trying to fetch storage class.", "Name", scName.Name)
 			return true, err
 		}
 		invalidState = true
@@ -230,7 +325,9 @@ func (r *VMRayClusterReconciler) ValidateAuxiliaryDependencies(ctx context.Conte
 	}
 
 	// remove duplicate elements from the vmclasses.
+	slices.Sort(vmclasses)
 	vmclasses = slices.Compact(vmclasses)
+	provided := map[string]vmrayv1alpha1.NodeResource{}
 	for i := range vmclasses {
 		vmclass := vmopv1.VirtualMachineClass{}
 		vmclassNamespaceName := types.NamespacedName{
@@ -245,11 +342,47 @@ func (r *VMRayClusterReconciler) ValidateAuxiliaryDependencies(ctx context.Conte
 				return true, err
 			}
 			invalidState = true
+			continue
 		}
+		provided[vmclasses[i]] = vmrayv1alpha1.HardwareResources(vmclass.Spec.Hardware)
+	}
+
+	// 4. Flag node types whose resources exceed their VM class, ray would plan with capacity
+	// their nodes don't have. Nodes are deployed nevertheless, as they run with less capacity.
+	if err := exceededResourcesError(instance, provided); err != nil {
+		addErrorCondition(err, instance, vmrayv1alpha1.NodeConfigInvalidResources, vmrayv1alpha1.ResourcesExceedVMClassReason)
 	}
 	return invalidState, nil
 }
 
+// exceededResourcesError describes the node types whose resources exceed the resources
+// provided by their VM class, or returns nil if none does. VM classes missing in provided
+// are skipped.
+func exceededResourcesError(instance *vmrayv1alpha1.VMRayCluster, provided map[string]vmrayv1alpha1.NodeResource) error {
+	names := make([]string, 0, len(instance.Spec.NodeConfig.NodeTypes))
+	for name := range instance.Spec.NodeConfig.NodeTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	messages := []string{}
+	for _, name := range names {
+		nt := instance.Spec.NodeConfig.NodeTypes[name]
+		classResources, ok := provided[nt.VMClass]
+		if !ok {
+			continue
+		}
+		if exceeded := nt.Resources.ExceededBy(classResources); len(exceeded) > 0 {
+			messages = append(messages, fmt.Sprintf("node type %s exceeds VM class %s: %s",
+				name, nt.VMClass, strings.Join(exceeded, ", ")))
+		}
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+	return fmt.Errorf("resources exceed VM class hardware: %s", strings.Join(messages, "; "))
+}
+
 // createRandomNounce generates a random alpha-numeric string of given size.
 func createRandomNounce(n int) string {
 	buf := make([]byte, n)
@@ -6,13 +6,19 @@ package controller_test
 import (
 	"context"
 	"fmt"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
 	vmraycontroller "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/internal/controller"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/internal/controller/lcm"
+	vmprovider "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider"
 	mockvmpv "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/mock"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/raydashboard"
+	fakedashboard "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/raydashboard/fake"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -54,8 +60,8 @@ func rayWorkerUnitTests() {
 								MinWorkers: 3,
 								MaxWorkers: 5,
 								Resources: vmrayv1alpha1.NodeResource{
-									CPU:    2,
-									Memory: 1024,
+									CPU:    resource.NewQuantity(2, resource.DecimalSI),
+									Memory: resource.NewQuantity(1024, resource.BinarySI),
 								},
 							},
 						},
@@ -86,7 +92,7 @@ func rayWorkerUnitTests() {
 				err := suite.GetK8sClient().Get(ctx, rayClusterNamespacedName, instance)
 				Expect(err).NotTo(HaveOccurred())
 				provider := mockvmpv.NewMockVmProvider()
-				controllerReconciler := vmraycontroller.NewVMRayClusterReconciler(suite.GetK8sClient(), suite.GetK8sClient().Scheme(), provider)
+				controllerReconciler := newClusterReconciler(provider)
 
 				// 1st reconcile to deploy HEAD node and set vm_status to initialized
 				provider.DeployVmServiceSetResponse(1, "192.10.10.1", nil)
@@ -190,13 +196,55 @@ func rayWorkerUnitTests() {
 				Expect(instance.Status.CurrentWorkers["worker1"].VmStatus).Should(Equal(vmrayv1alpha1.RUNNING))
 				Expect(instance.Status.CurrentWorkers["worker1"].RayStatus).Should(Equal(vmrayv1alpha1.RAY_RUNNING))
 			})
+			It("Maps ray nodes to head & worker VMs in node status", func() {
+				instance := &vmrayv1alpha1.VMRayCluster{}
+				err := suite.GetK8sClient().Get(ctx, rayClusterNamespacedName, instance)
+				Expect(err).NotTo(HaveOccurred())
+				provider := mockvmpv.NewMockVmProvider()
+				controllerReconciler := newClusterReconciler(provider)
+
+				server := fakedashboard.NewServer()
+				defer server.Close()
+				controllerReconciler.DashboardAddress = func(ip string) string { return server.URL }
+				server.SetNodes([]raydashboard.NodeState{
+					{NodeID: "head-node-id", NodeIP: "12.12.12.12", IsHeadNode: true, State: raydashboard.NodeStatusAlive},
+					{NodeID: "old-worker-node-id", NodeIP: "12.12.12.13", State: raydashboard.NodeStatusDead, StartTimeMs: 2},
+					{NodeID: "worker-node-id", NodeIP: "12.12.12.13", State: raydashboard.NodeStatusAlive, StartTimeMs: 1,
+						ResourcesTotal: map[string]float64{"CPU": 4, "memory": 1.5}},
+				})
+
+				status := vmrayv1alpha1.VMRayNodeStatus{
+					Ip:        "12.12.12.13",
+					VmStatus:  vmrayv1alpha1.RUNNING,
+					RayStatus: vmrayv1alpha1.RAY_RUNNING,
+				}
+				provider.FetchVmStatusSetResponse(1, &instance.Status.HeadNodeStatus, nil)
+				provider.FetchVmStatusSetResponse(2, &status, nil)
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{
+					NamespacedName: rayClusterNamespacedName,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				err = suite.GetK8sClient().Get(ctx, rayClusterNamespacedName, instance)
+				Expect(err).ToNot(HaveOccurred())
+
+				// Ray nodes are mapped to VMs by IP, alive ray node is preferred over the dead one.
+				worker := instance.Status.CurrentWorkers["worker1"]
+				Expect(worker.NodeType).Should(Equal("worker-node-type"))
+				Expect(worker.CreationTime).ShouldNot(BeNil())
+				Expect(worker.ReadyTime).ShouldNot(BeNil())
+				Expect(worker.RayNodeID).Should(Equal("worker-node-id"))
+				Expect(worker.RayNodeState).Should(Equal(vmrayv1alpha1.RayNodeAlive))
+				Expect(worker.RayResources).Should(Equal(map[string]string{"CPU": "4", "memory": "1.5"}))
+				Expect(instance.Status.HeadNodeStatus.RayNodeID).Should(Equal("head-node-id"))
+			})
 			// negative test: Losing worker node IP
 			It("Worker node losing IP marks the VM and Ray status as failed", func() {
 				instance := &vmrayv1alpha1.VMRayCluster{}
 				err := suite.GetK8sClient().Get(ctx, rayClusterNamespacedName, instance)
 				Expect(err).NotTo(HaveOccurred())
 				provider := mockvmpv.NewMockVmProvider()
-				controllerReconciler := vmraycontroller.NewVMRayClusterReconciler(suite.GetK8sClient(), suite.GetK8sClient().Scheme(), provider)
+				controllerReconciler := newClusterReconciler(provider)
 
 				status := vmrayv1alpha1.VMRayNodeStatus{
 					Ip:        "",
@@ -227,7 +275,7 @@ func rayWorkerUnitTests() {
 				err := suite.GetK8sClient().Get(ctx, rayClusterNamespacedName, instance)
 				Expect(err).NotTo(HaveOccurred())
 				provider := mockvmpv.NewMockVmProvider()
-				controllerReconciler := vmraycontroller.NewVMRayClusterReconciler(suite.GetK8sClient(), suite.GetK8sClient().Scheme(), provider)
+				controllerReconciler := newClusterReconciler(provider)
 
 				headNodeStatus := vmrayv1alpha1.VMRayNodeStatus{
 					Ip:        "12.12.12.12",
@@ -243,9 +291,9 @@ func rayWorkerUnitTests() {
 				provider.FetchVmStatusSetResponse(2, &workerNodeStatus, nil)
 
 				err = fmt.Errorf("Failure when trying to delete worker nodes. %s ", instance.Name)
+				// Worker nodes are deleted before the head node.
 				provider.DeleteAuxiliaryResourcesSetResponse(1, nil)
-				provider.DeleteSetResponse(1, nil)
-				provider.DeleteSetResponse(2, err)
+				provider.DeleteSetResponse(1, err)
 				testutil.DeleteRayCluster(ctx, suite.GetK8sClient(), rayClusterNamespacedName, instance)
 				// call reconciler to delete the cluster
 				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{
@@ -265,7 +313,7 @@ func rayWorkerUnitTests() {
 				err := suite.GetK8sClient().Get(ctx, rayClusterNamespacedName, instance)
 				Expect(err).NotTo(HaveOccurred())
 				provider := mockvmpv.NewMockVmProvider()
-				controllerReconciler := vmraycontroller.NewVMRayClusterReconciler(suite.GetK8sClient(), suite.GetK8sClient().Scheme(), provider)
+				controllerReconciler := newClusterReconciler(provider)
 
 				headNodeStatus := vmrayv1alpha1.VMRayNodeStatus{
 					Ip:        "12.12.12.12",
@@ -291,5 +339,568 @@ func rayWorkerUnitTests() {
 			})
 
 		})
+
+		Context("When scaling down worker nodes", func() {
+			ctx := context.Background()
+
+			BeforeEach(func() {
+				testutil.CreateAuxiliaryDependencies(ctx, suite.GetK8sClient(), namespace, testobjectname)
+			})
+			AfterEach(func() {
+				testutil.DeleteAuxiliaryDependencies(ctx, suite.GetK8sClient(), namespace, testobjectname)
+			})
+
+			It("Drains ray node of the worker before deleting its VM", func() {
+				instance := testutil.CreateRayClusterInstance(ctx, suite.GetK8sClient(), namespace, "test-drain-ray-cluster", testobjectname)
+				namespacedName := testutil.GetNamespacedName(namespace, instance.ObjectMeta.Name)
+
+				server := fakedashboard.NewServer()
+				defer server.Close()
+				server.SetNodes([]raydashboard.NodeState{
+					{NodeID: "head", NodeIP: "12.12.12.12", IsHeadNode: true, State: raydashboard.NodeStatusAlive},
+					{NodeID: "worker2-node-id", NodeIP: "12.12.12.14", State: raydashboard.NodeStatusAlive},
+				})
+				server.SetRunningTasks("worker2-node-id", 2)
+
+				provider := mockvmpv.NewMockVmProvider()
+				controllerReconciler := vmraycontroller.NewVMRayClusterReconciler(suite.GetK8sClient(), suite.GetK8sClient().Scheme(), provider)
+				controllerReconciler.DashboardAddress = func(ip string) string { return server.URL }
+
+				// Head node is running along with a worker which isn't desired anymore.
+				headNodeStatus := vmrayv1alpha1.VMRayNodeStatus{
+					Ip:        "12.12.12.12",
+					VmStatus:  vmrayv1alpha1.RUNNING,
+					RayStatus: vmrayv1alpha1.RAY_RUNNING,
+				}
+				instance.Status.HeadNodeStatus = headNodeStatus
+				instance.Status.VMServiceStatus.Ip = "192.10.10.1"
+				instance.Status.CurrentWorkers = map[string]vmrayv1alpha1.VMRayNodeStatus{
+					"worker2": {
+						Ip:           "12.12.12.14",
+						VmStatus:     vmrayv1alpha1.RUNNING,
+						RayStatus:    vmrayv1alpha1.RAY_RUNNING,
+						RayNodeID:    "worker2-node-id",
+						RayNodeState: vmrayv1alpha1.RayNodeAlive,
+					},
+				}
+				Expect(suite.GetK8sClient().Status().Update(ctx, instance)).To(Succeed())
+
+				// Worker is being drained as it has running tasks.
+				provider.FetchVmStatusSetResponse(1, &headNodeStatus, nil)
+				result, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RequeueAfter).To(BeNumerically("<", time.Minute))
+				Expect(suite.GetK8sClient().Get(ctx, namespacedName, instance)).To(Succeed())
+				drain := instance.Status.CurrentWorkers["worker2"].Drain
+				Expect(drain).ToNot(BeNil())
+				Expect(drain.State).To(Equal(vmrayv1alpha1.NODE_DRAINING))
+				Expect(drain.RunningTasks).To(Equal(int32(2)))
+				drainJob, ok := server.GetJob("vmray-drain-worker2-node-id")
+				Expect(ok).To(BeTrue())
+				Expect(drainJob.Entrypoint).To(ContainSubstring("ray drain-node --node-id worker2-node-id"))
+
+				// Tasks finished, worker VM is deleted.
+				server.SetRunningTasks("worker2-node-id", 0)
+				provider.FetchVmStatusSetResponse(2, &headNodeStatus, nil)
+				provider.DeleteSetResponse(1, nil)
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(provider.DeleteGetRequest(1).Name).To(Equal("worker2"))
+				Expect(suite.GetK8sClient().Get(ctx, namespacedName, instance)).To(Succeed())
+				Expect(instance.Status.CurrentWorkers).ToNot(HaveKey("worker2"))
+
+				provider.DeleteAuxiliaryResourcesSetResponse(1, nil)
+				provider.DeleteSetResponse(2, nil)
+				testutil.DeleteRayCluster(ctx, suite.GetK8sClient(), namespacedName, instance)
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("When configuration of workers changes", func() {
+			ctx := context.Background()
+
+			BeforeEach(func() {
+				testutil.CreateAuxiliaryDependencies(ctx, suite.GetK8sClient(), namespace, testobjectname)
+			})
+			AfterEach(func() {
+				testutil.DeleteAuxiliaryDependencies(ctx, suite.GetK8sClient(), namespace, testobjectname)
+			})
+
+			It("Replaces outdated worker by surging a new worker", func() {
+				instance := testutil.CreateRayClusterInstance(ctx, suite.GetK8sClient(), namespace, "test-upgrade-ray-cluster", testobjectname)
+				namespacedName := testutil.GetNamespacedName(namespace, instance.ObjectMeta.Name)
+				instance.Spec.AutoscalerDesiredWorkers = map[string]string{"worker1": "worker_1"}
+				Expect(suite.GetK8sClient().Update(ctx, instance)).To(Succeed())
+
+				provider := mockvmpv.NewMockVmProvider()
+				controllerReconciler := newClusterReconciler(provider)
+
+				// Worker was deployed with a configuration different from the current one.
+				headNodeStatus := vmrayv1alpha1.VMRayNodeStatus{
+					Ip:        "12.12.12.12",
+					VmStatus:  vmrayv1alpha1.RUNNING,
+					RayStatus: vmrayv1alpha1.RAY_RUNNING,
+				}
+				workerNodeStatus := vmrayv1alpha1.VMRayNodeStatus{
+					Ip:         "12.12.12.13",
+					VmStatus:   vmrayv1alpha1.RUNNING,
+					RayStatus:  vmrayv1alpha1.RAY_RUNNING,
+					ConfigHash: "outdated-hash",
+				}
+				instance.Status.HeadNodeStatus = headNodeStatus
+				instance.Status.VMServiceStatus.Ip = "192.10.10.1"
+				instance.Status.CurrentWorkers = map[string]vmrayv1alpha1.VMRayNodeStatus{
+					"worker1": workerNodeStatus,
+				}
+				Expect(suite.GetK8sClient().Status().Update(ctx, instance)).To(Succeed())
+
+				// Surge worker is tracked in status, desired workers are left to the autoscaler.
+				provider.FetchVmStatusSetResponse(1, &headNodeStatus, nil)
+				provider.FetchVmStatusSetResponse(2, &workerNodeStatus, nil)
+				result, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RequeueAfter).To(BeNumerically("<", time.Minute))
+				Expect(suite.GetK8sClient().Get(ctx, namespacedName, instance)).To(Succeed())
+
+				upgrade := instance.Status.Upgrade
+				Expect(upgrade).ToNot(BeNil())
+				Expect(upgrade.State).To(Equal(vmrayv1alpha1.UPGRADE_WORKERS))
+				Expect(upgrade.OutdatedWorkers).To(Equal(int32(1)))
+				Expect(upgrade.Replacements).To(HaveLen(1))
+				surgeWorker := ""
+				for name, r := range upgrade.Replacements {
+					surgeWorker = name
+					Expect(r.Worker).To(Equal("worker1"))
+					Expect(r.NodeType).To(Equal("worker_1"))
+					Expect(r.Recreating).To(BeFalse())
+				}
+				Expect(surgeWorker).ToNot(Equal("worker1"))
+				Expect(instance.Spec.AutoscalerDesiredWorkers).To(Equal(map[string]string{"worker1": "worker_1"}))
+
+				// Surge worker is deployed while outdated worker keeps running.
+				provider.FetchVmStatusSetResponse(3, &headNodeStatus, nil)
+				provider.FetchVmStatusSetResponse(4, &workerNodeStatus, nil)
+				provider.DeploySetResponse(1, nil)
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(provider.DeployGetRequest(1).VmName).To(Equal(surgeWorker))
+				Expect(suite.GetK8sClient().Get(ctx, namespacedName, instance)).To(Succeed())
+				Expect(instance.Status.CurrentWorkers[surgeWorker].VmStatus).To(Equal(vmrayv1alpha1.INITIALIZED))
+				Expect(instance.Status.CurrentWorkers[surgeWorker].ConfigHash).ToNot(Equal("outdated-hash"))
+				Expect(instance.Status.CurrentWorkers).To(HaveKey("worker1"))
+				Expect(instance.Spec.AutoscalerDesiredWorkers).To(Equal(map[string]string{"worker1": "worker_1"}))
+
+				// Surge worker becomes ready over the next two reconciles.
+				vmStatus := vmrayv1alpha1.VMRayNodeStatus{Ip: "12.12.12.13"}
+				provider.FetchVmStatusSetResponse(5, &headNodeStatus, nil)
+				provider.FetchVmStatusSetResponse(6, &vmStatus, nil)
+				provider.FetchVmStatusSetResponse(7, &vmStatus, nil)
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+
+				// Once surge worker is ready, outdated worker's VM is deleted & its status reset.
+				provider.FetchVmStatusSetResponse(8, &headNodeStatus, nil)
+				provider.FetchVmStatusSetResponse(9, &vmStatus, nil)
+				provider.FetchVmStatusSetResponse(10, &vmStatus, nil)
+				provider.DeleteSetResponse(1, nil)
+				provider.FetchVmStatusSetResponse(11, nil, mockvmpv.NewNotFoundError("worker1"))
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(provider.DeleteGetRequest(1).Name).To(Equal("worker1"))
+				Expect(suite.GetK8sClient().Get(ctx, namespacedName, instance)).To(Succeed())
+				Expect(instance.Status.CurrentWorkers["worker1"].VmStatus).To(Equal(vmrayv1alpha1.EMPTY))
+				Expect(instance.Status.Upgrade.Replacements).To(HaveKeyWithValue(surgeWorker,
+					vmrayv1alpha1.VMRayWorkerReplacement{Worker: "worker1", NodeType: "worker_1"}))
+
+				// Outdated worker is re-deployed under its name with the current configuration.
+				provider.FetchVmStatusSetResponse(12, &headNodeStatus, nil)
+				provider.FetchVmStatusSetResponse(13, &vmStatus, nil)
+				provider.DeploySetResponse(2, nil)
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(provider.DeployGetRequest(2).VmName).To(Equal("worker1"))
+				Expect(suite.GetK8sClient().Get(ctx, namespacedName, instance)).To(Succeed())
+				Expect(instance.Status.CurrentWorkers["worker1"].VmStatus).To(Equal(vmrayv1alpha1.INITIALIZED))
+				Expect(instance.Status.CurrentWorkers["worker1"].ConfigHash).ToNot(Equal("outdated-hash"))
+				Expect(instance.Status.Upgrade.Replacements).To(HaveKey(surgeWorker))
+				Expect(instance.Spec.AutoscalerDesiredWorkers).To(Equal(map[string]string{"worker1": "worker_1"}))
+
+				provider.DeleteAuxiliaryResourcesSetResponse(1, nil)
+				provider.DeleteSetResponse(2, nil)
+				provider.DeleteSetResponse(3, nil)
+				provider.DeleteSetResponse(4, nil)
+				testutil.DeleteRayCluster(ctx, suite.GetK8sClient(), namespacedName, instance)
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("Resizes worker in place when only its VM class changed", func() {
+				instance := testutil.CreateRayClusterInstance(ctx, suite.GetK8sClient(), namespace, "test-resize-ray-cluster", testobjectname)
+				namespacedName := testutil.GetNamespacedName(namespace, instance.ObjectMeta.Name)
+				instance.Spec.AutoscalerDesiredWorkers = map[string]string{"worker1": "worker_1"}
+				instance.Spec.UpgradeStrategy.ResizePolicy = vmrayv1alpha1.ResizePolicyInPlace
+				Expect(suite.GetK8sClient().Update(ctx, instance)).To(Succeed())
+
+				server := fakedashboard.NewServer()
+				defer server.Close()
+				provider := mockvmpv.NewMockVmProvider()
+				controllerReconciler := newClusterReconciler(provider)
+				controllerReconciler.DashboardAddress = func(ip string) string { return server.URL }
+
+				// Worker was deployed with a different VM class only.
+				req := lcm.NodeLcmRequest{
+					NodeType:         "worker_1",
+					DockerImage:      instance.Spec.Image,
+					HeadNodeConfig:   instance.Spec.HeadNode,
+					WorkerNodeConfig: instance.Spec.WorkerNode,
+					NodeConfig:       instance.Spec.NodeConfig,
+					EnableTLS:        instance.Spec.EnableTLS,
+					DockerConfig:     instance.Spec.DockerConfig,
+				}
+				desiredHash := lcm.GetNodeConfigHash(req)
+				oldNodeType := instance.Spec.NodeConfig.NodeTypes["worker_1"]
+				oldNodeType.VMClass = "old-class"
+				req.NodeConfig.NodeTypes = map[string]vmrayv1alpha1.NodeType{"worker_1": oldNodeType}
+
+				headNodeStatus := vmrayv1alpha1.VMRayNodeStatus{
+					Ip:        "12.12.12.12",
+					VmStatus:  vmrayv1alpha1.RUNNING,
+					RayStatus: vmrayv1alpha1.RAY_RUNNING,
+				}
+				workerNodeStatus := vmrayv1alpha1.VMRayNodeStatus{
+					Ip:         "12.12.12.13",
+					VmStatus:   vmrayv1alpha1.RUNNING,
+					RayStatus:  vmrayv1alpha1.RAY_RUNNING,
+					VMClass:    "old-class",
+					ConfigHash: lcm.GetNodeConfigHash(req),
+				}
+				instance.Status.HeadNodeStatus = headNodeStatus
+				instance.Status.VMServiceStatus.Ip = "192.10.10.1"
+				instance.Status.CurrentWorkers = map[string]vmrayv1alpha1.VMRayNodeStatus{
+					"worker1": workerNodeStatus,
+				}
+				Expect(suite.GetK8sClient().Status().Update(ctx, instance)).To(Succeed())
+
+				// Resize starts instead of adding a replacement worker.
+				provider.FetchVmStatusSetResponse(1, &headNodeStatus, nil)
+				provider.FetchVmStatusSetResponse(2, &workerNodeStatus, nil)
+				provider.ResizeSetResponse(1, false, nil)
+				_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(provider.ResizeGetRequest(1).VmName).To(Equal("worker1"))
+				Expect(suite.GetK8sClient().Get(ctx, namespacedName, instance)).To(Succeed())
+				Expect(instance.Spec.AutoscalerDesiredWorkers).To(HaveLen(1))
+				Expect(instance.Status.Upgrade).ToNot(BeNil())
+				Expect(instance.Status.Upgrade.ResizingWorkers).To(Equal(int32(1)))
+				resize := instance.Status.CurrentWorkers["worker1"].Resize
+				Expect(resize).ToNot(BeNil())
+				Expect(resize.State).To(Equal(vmrayv1alpha1.NODE_RESIZING))
+				Expect(resize.VMClass).To(Equal(testobjectname))
+
+				// VM got resized, ray is restarted once VM has its IP back.
+				provider.FetchVmStatusSetResponse(3, &headNodeStatus, nil)
+				provider.ResizeSetResponse(2, true, nil)
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(suite.GetK8sClient().Get(ctx, namespacedName, instance)).To(Succeed())
+				Expect(instance.Status.CurrentWorkers["worker1"].Resize.State).To(Equal(vmrayv1alpha1.NODE_RAY_RESTARTING))
+				Expect(instance.Status.CurrentWorkers["worker1"].VmStatus).To(Equal(vmrayv1alpha1.INITIALIZED))
+
+				resizedNodeStatus := vmrayv1alpha1.VMRayNodeStatus{
+					Ip:         "12.12.12.13",
+					VMClass:    testobjectname,
+					ConfigHash: desiredHash,
+				}
+				restartID := fmt.Sprintf("vmray-restart-worker1-%d", resize.StartTime.Unix())
+				provider.FetchVmStatusSetResponse(4, &headNodeStatus, nil)
+				provider.FetchVmStatusSetResponse(5, &resizedNodeStatus, nil)
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				restartJob, ok := server.GetJob(restartID)
+				Expect(ok).To(BeTrue())
+				Expect(restartJob.Entrypoint).To(ContainSubstring(instance.Spec.NodeConfig.VMUser + "@12.12.12.13"))
+				Expect(restartJob.Entrypoint).To(ContainSubstring("ray start --block --address=12.12.12.12:6379"))
+
+				// Ray got restarted, resize & upgrade are done.
+				server.SetJobStatus(restartID, raydashboard.JobStatusSucceeded, "")
+				provider.FetchVmStatusSetResponse(6, &headNodeStatus, nil)
+				provider.FetchVmStatusSetResponse(7, &resizedNodeStatus, nil)
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(suite.GetK8sClient().Get(ctx, namespacedName, instance)).To(Succeed())
+				Expect(instance.Status.CurrentWorkers["worker1"].Resize).To(BeNil())
+				Expect(instance.Status.CurrentWorkers["worker1"].VMClass).To(Equal(testobjectname))
+				Expect(instance.Status.Upgrade).To(BeNil())
+
+				provider.DeleteAuxiliaryResourcesSetResponse(1, nil)
+				provider.DeleteSetResponse(1, nil)
+				provider.DeleteSetResponse(2, nil)
+				testutil.DeleteRayCluster(ctx, suite.GetK8sClient(), namespacedName, instance)
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("When zones are configured", func() {
+			ctx := context.Background()
+
+			BeforeEach(func() {
+				testutil.CreateAuxiliaryDependencies(ctx, suite.GetK8sClient(), namespace, testobjectname)
+			})
+			AfterEach(func() {
+				testutil.DeleteAuxiliaryDependencies(ctx, suite.GetK8sClient(), namespace, testobjectname)
+			})
+
+			It("Deploys new worker to the zone running the fewest nodes", func() {
+				instance := testutil.CreateRayClusterInstance(ctx, suite.GetK8sClient(), namespace, "test-zone-ray-cluster", testobjectname)
+				namespacedName := testutil.GetNamespacedName(namespace, instance.ObjectMeta.Name)
+				instance.Spec.NodeConfig.Zones = []string{"zone-a", "zone-b"}
+				instance.Spec.AutoscalerDesiredWorkers = map[string]string{"worker1": "worker_1", "worker2": "worker_1"}
+				Expect(suite.GetK8sClient().Update(ctx, instance)).To(Succeed())
+
+				provider := mockvmpv.NewMockVmProvider()
+				controllerReconciler := newClusterReconciler(provider)
+
+				// Head & the first worker run in the first zone.
+				headNodeStatus := vmrayv1alpha1.VMRayNodeStatus{
+					Ip:        "12.12.12.12",
+					VmStatus:  vmrayv1alpha1.RUNNING,
+					RayStatus: vmrayv1alpha1.RAY_RUNNING,
+					Zone:      "zone-a",
+				}
+				workerNodeStatus := vmrayv1alpha1.VMRayNodeStatus{
+					Ip:        "12.12.12.13",
+					VmStatus:  vmrayv1alpha1.RUNNING,
+					RayStatus: vmrayv1alpha1.RAY_RUNNING,
+					NodeType:  "worker_1",
+					Zone:      "zone-a",
+				}
+				instance.Status.HeadNodeStatus = headNodeStatus
+				instance.Status.VMServiceStatus.Ip = "192.10.10.1"
+				instance.Status.CurrentWorkers = map[string]vmrayv1alpha1.VMRayNodeStatus{
+					"worker1": workerNodeStatus,
+				}
+				Expect(suite.GetK8sClient().Status().Update(ctx, instance)).To(Succeed())
+
+				provider.FetchVmStatusSetResponse(1, &headNodeStatus, nil)
+				provider.FetchVmStatusSetResponse(2, &workerNodeStatus, nil)
+				provider.DeploySetResponse(1, nil)
+				_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(provider.DeployGetRequest(1).VmName).To(Equal("worker2"))
+				Expect(provider.DeployGetRequest(1).Zone).To(Equal("zone-b"))
+				Expect(suite.GetK8sClient().Get(ctx, namespacedName, instance)).To(Succeed())
+				Expect(instance.Status.CurrentWorkers["worker2"].Zone).To(Equal("zone-b"))
+				Expect(instance.Status.CurrentWorkers["worker1"].Zone).To(Equal("zone-a"))
+
+				provider.DeleteAuxiliaryResourcesSetResponse(1, nil)
+				provider.DeleteSetResponse(1, nil)
+				provider.DeleteSetResponse(2, nil)
+				provider.DeleteSetResponse(3, nil)
+				testutil.DeleteRayCluster(ctx, suite.GetK8sClient(), namespacedName, instance)
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("Redeploys failed worker to another zone", func() {
+				instance := testutil.CreateRayClusterInstance(ctx, suite.GetK8sClient(), namespace, "test-zone-failed-ray-cluster", testobjectname)
+				namespacedName := testutil.GetNamespacedName(namespace, instance.ObjectMeta.Name)
+				instance.Spec.NodeConfig.Zones = []string{"zone-a", "zone-b", "zone-c"}
+				instance.Spec.AutoscalerDesiredWorkers = map[string]string{"worker1": "worker_1"}
+				Expect(suite.GetK8sClient().Update(ctx, instance)).To(Succeed())
+
+				provider := mockvmpv.NewMockVmProvider()
+				controllerReconciler := newClusterReconciler(provider)
+
+				// Head runs in the first zone, VM of the worker failed in the second one.
+				headNodeStatus := vmrayv1alpha1.VMRayNodeStatus{
+					Ip:        "12.12.12.12",
+					VmStatus:  vmrayv1alpha1.RUNNING,
+					RayStatus: vmrayv1alpha1.RAY_RUNNING,
+					Zone:      "zone-a",
+				}
+				instance.Status.HeadNodeStatus = headNodeStatus
+				instance.Status.VMServiceStatus.Ip = "192.10.10.1"
+				instance.Status.CurrentWorkers = map[string]vmrayv1alpha1.VMRayNodeStatus{
+					"worker1": {VmStatus: vmrayv1alpha1.EMPTY, NodeType: "worker_1", Zone: "zone-b"},
+				}
+				Expect(suite.GetK8sClient().Status().Update(ctx, instance)).To(Succeed())
+
+				provider.FetchVmStatusSetResponse(1, &headNodeStatus, nil)
+				provider.DeploySetResponse(1, nil)
+				_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(provider.DeployGetRequest(1).VmName).To(Equal("worker1"))
+				Expect(provider.DeployGetRequest(1).Zone).To(Equal("zone-c"))
+				Expect(suite.GetK8sClient().Get(ctx, namespacedName, instance)).To(Succeed())
+				Expect(instance.Status.CurrentWorkers["worker1"].Zone).To(Equal("zone-c"))
+
+				provider.DeleteAuxiliaryResourcesSetResponse(1, nil)
+				provider.DeleteSetResponse(1, nil)
+				provider.DeleteSetResponse(2, nil)
+				testutil.DeleteRayCluster(ctx, suite.GetK8sClient(), namespacedName, instance)
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("When status of the cluster is lost", func() {
+			ctx := context.Background()
+
+			BeforeEach(func() {
+				testutil.CreateAuxiliaryDependencies(ctx, suite.GetK8sClient(), namespace, testobjectname)
+			})
+			AfterEach(func() {
+				testutil.DeleteAuxiliaryDependencies(ctx, suite.GetK8sClient(), namespace, testobjectname)
+			})
+
+			It("Rebuilds head nounce & worker status from cluster's labeled VMs", func() {
+				instance := testutil.CreateRayClusterInstance(ctx, suite.GetK8sClient(), namespace, "test-rebuild-ray-cluster", testobjectname)
+				namespacedName := testutil.GetNamespacedName(namespace, instance.ObjectMeta.Name)
+				instance.Spec.AutoscalerDesiredWorkers = map[string]string{"worker1": "worker_1"}
+				Expect(suite.GetK8sClient().Update(ctx, instance)).To(Succeed())
+
+				provider := mockvmpv.NewMockVmProvider()
+				controllerReconciler := newClusterReconciler(provider)
+
+				// Cluster CR was recreated, its nounce & nodes are only known to its VMs.
+				headName := vmprovider.GetHeadNodeName(instance.ObjectMeta.Name, "abcde")
+				clusterVms := map[string]vmprovider.ClusterVm{
+					headName: {
+						Role:   vmprovider.NodeRoleHead,
+						Nounce: "abcde",
+						Status: &vmrayv1alpha1.VMRayNodeStatus{Ip: "12.12.12.12", NodeType: "node.1"},
+					},
+					"worker1": {
+						Role:   vmprovider.NodeRoleWorker,
+						Nounce: "abcde",
+						Status: &vmrayv1alpha1.VMRayNodeStatus{Ip: "12.12.12.13", NodeType: "worker_1", VMClass: testobjectname},
+					},
+				}
+				provider.ListClusterVmsSetResponse(1, clusterVms, nil)
+				provider.ListClusterVmsSetResponse(2, clusterVms, nil)
+				provider.DeployVmServiceSetResponse(1, "192.10.10.1", nil)
+				provider.DeploySetResponse(1, mockvmpv.NewAlreadyExistsError(headName))
+				_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+
+				// Existing head VM is taken over & worker's status is restored without deploying it.
+				Expect(suite.GetK8sClient().Get(ctx, namespacedName, instance)).To(Succeed())
+				Expect(instance.ObjectMeta.Labels[vmraycontroller.HeadNodeNounceLabel]).To(Equal("abcde"))
+				Expect(provider.DeployGetRequest(1).VmName).To(Equal(headName))
+				Expect(provider.DeployGetRequest(2).VmName).To(BeEmpty())
+				Expect(instance.Status.HeadNodeStatus.VmStatus).To(Equal(vmrayv1alpha1.INITIALIZED))
+				worker := instance.Status.CurrentWorkers["worker1"]
+				Expect(worker.VmStatus).To(Equal(vmrayv1alpha1.INITIALIZED))
+				Expect(worker.NodeType).To(Equal("worker_1"))
+				Expect(worker.Ip).To(Equal("12.12.12.13"))
+
+				// Status isn't rebuilt again once it's there.
+				provider.FetchVmStatusSetResponse(1, clusterVms[headName].Status, nil)
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(provider.ListClusterVmsGetRequest(3).Name).To(BeEmpty())
+
+				provider.DeleteAuxiliaryResourcesSetResponse(1, nil)
+				provider.DeleteSetResponse(1, nil)
+				provider.DeleteSetResponse(2, nil)
+				testutil.DeleteRayCluster(ctx, suite.GetK8sClient(), namespacedName, instance)
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("When adopting existing VMs", func() {
+			ctx := context.Background()
+
+			BeforeEach(func() {
+				testutil.CreateAuxiliaryDependencies(ctx, suite.GetK8sClient(), namespace, testobjectname)
+			})
+			AfterEach(func() {
+				testutil.DeleteAuxiliaryDependencies(ctx, suite.GetK8sClient(), namespace, testobjectname)
+			})
+
+			It("Takes over head & worker VMs deployed by ray up without redeploying them", func() {
+				provider := mockvmpv.NewSimulatedMockVmProvider()
+				controllerReconciler := newClusterReconciler(provider)
+
+				rayupLabels := map[string]string{"ray-cluster-name": "rayup"}
+				provider.AddExistingVm(namespace, "rayup-head", testobjectname, testobjectname, "12.12.12.12", rayupLabels)
+				provider.AddExistingVm(namespace, "rayup-worker", testobjectname, testobjectname, "12.12.12.13", rayupLabels)
+				provider.AddExistingVmService(namespace, "rayup-head-svc", "192.10.10.1")
+
+				instance := testutil.CreateRayClusterInstance(ctx, suite.GetK8sClient(), namespace, "test-adopt-ray-cluster", testobjectname)
+				namespacedName := testutil.GetNamespacedName(namespace, instance.ObjectMeta.Name)
+				instance.ObjectMeta.Annotations = map[string]string{
+					vmrayv1alpha1.AdoptHeadAnnotation:           "rayup-head",
+					vmrayv1alpha1.AdoptHeadServiceAnnotation:    "rayup-head-svc",
+					vmrayv1alpha1.AdoptWorkerSelectorAnnotation: "ray-cluster-name=rayup",
+				}
+				Expect(suite.GetK8sClient().Update(ctx, instance)).To(Succeed())
+
+				// 1st reconcile adopts VMs, 2nd reconciles the adopted workers.
+				for i := 0; i < 2; i++ {
+					_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+					Expect(err).NotTo(HaveOccurred())
+				}
+
+				Expect(suite.GetK8sClient().Get(ctx, namespacedName, instance)).To(Succeed())
+				Expect(instance.ObjectMeta.Annotations).To(HaveKeyWithValue(vmraycontroller.AdoptedAnnotation, "true"))
+				Expect(instance.ObjectMeta.Annotations).To(HaveKeyWithValue(vmraycontroller.HeadNodeNameAnnotation, "rayup-head"))
+				Expect(instance.Status.HeadNodeStatus.Ip).To(Equal("12.12.12.12"))
+				Expect(instance.Status.HeadNodeStatus.VmStatus).To(Equal(vmrayv1alpha1.RUNNING))
+				Expect(instance.Status.VMServiceStatus.Ip).To(Equal("192.10.10.1"))
+				Expect(instance.Status.HeadNodeStatus.ConfigHash).To(Equal(vmprovider.AdoptedConfigHash))
+
+				// Adopted worker is kept in status without touching autoscaler's desired workers.
+				Expect(instance.Spec.AutoscalerDesiredWorkers).To(BeEmpty())
+				worker := instance.Status.CurrentWorkers["rayup-worker"]
+				Expect(worker.VmStatus).To(Equal(vmrayv1alpha1.RUNNING))
+				Expect(worker.Ip).To(Equal("12.12.12.13"))
+				Expect(worker.NodeType).To(Equal("worker_1"))
+				Expect(worker.Adopted).To(BeTrue())
+				Expect(worker.ConfigHash).To(Equal(vmprovider.AdoptedConfigHash))
+
+				// No VM was deployed & adopted VMs are labeled with the cluster.
+				Expect(provider.DeployGetRequest(1).VmName).To(BeEmpty())
+				vms, err := provider.ListClusterVms(ctx, namespace, instance.ObjectMeta.Name)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vms).To(HaveKey("rayup-head"))
+				Expect(vms).To(HaveKey("rayup-worker"))
+
+				testutil.DeleteRayCluster(ctx, suite.GetK8sClient(), namespacedName, instance)
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				_, err = provider.FetchVmStatus(ctx, namespace, "rayup-head")
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("Doesn't deploy a head node when existing head VM doesn't match the spec", func() {
+				provider := mockvmpv.NewSimulatedMockVmProvider()
+				controllerReconciler := newClusterReconciler(provider)
+				provider.AddExistingVm(namespace, "rayup-head", testobjectname, "another-image", "12.12.12.12", nil)
+
+				instance := testutil.CreateRayClusterInstance(ctx, suite.GetK8sClient(), namespace, "test-adopt-mismatch-ray-cluster", testobjectname)
+				namespacedName := testutil.GetNamespacedName(namespace, instance.ObjectMeta.Name)
+				instance.ObjectMeta.Annotations = map[string]string{vmrayv1alpha1.AdoptHeadAnnotation: "rayup-head"}
+				Expect(suite.GetK8sClient().Update(ctx, instance)).To(Succeed())
+
+				_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(suite.GetK8sClient().Get(ctx, namespacedName, instance)).To(Succeed())
+				Expect(instance.Status.ClusterState).To(Equal(vmrayv1alpha1.UNHEALTHY))
+				Expect(instance.Status.Conditions).To(ContainElement(And(
+					HaveField("Type", vmrayv1alpha1.VMRayClusterConditionAdoptVms),
+					HaveField("Reason", vmrayv1alpha1.FailureToAdoptVmsReason))))
+				Expect(instance.ObjectMeta.Annotations).ToNot(HaveKey(vmraycontroller.AdoptedAnnotation))
+				Expect(provider.DeployGetRequest(1).VmName).To(BeEmpty())
+
+				testutil.DeleteRayCluster(ctx, suite.GetK8sClient(), namespacedName, instance)
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
 	})
 }
@@ -0,0 +1,171 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/raydashboard"
+)
+
+const (
+	jobFinalizerName = "vmrayjob.vmray.broadcom.com"
+	// Only tail of the job logs is kept in status to stay within object size limits.
+	maxJobLogsLength   = 4096
+	jobRequeueDuration = 15 * time.Second
+)
+
+// VMRayJobReconciler reconciles a VMRayJob object
+type VMRayJobReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	Log    logr.Logger
+
+	dashboardAddress DashboardAddressFunc
+}
+
+func NewVMRayJobReconciler(client client.Client, Scheme *runtime.Scheme, dashboardAddress DashboardAddressFunc) *VMRayJobReconciler {
+	return &VMRayJobReconciler{
+		Client:           client,
+		Scheme:           Scheme,
+		dashboardAddress: dashboardAddress,
+		Log:              ctrl.Log.WithName("VMRayJobReconciler"),
+	}
+}
+
+// +kubebuilder:rbac:groups=vmray.broadcom.com,resources=vmrayjobs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=vmray.broadcom.com,resources=vmrayjobs/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=vmray.broadcom.com,resources=vmrayjobs/finalizers,verbs=update
+
+// Reconcile brings up the cluster for a VMRayJob, submits the job to it via
+// ray jobs API, tracks its status & logs and cleans up the ephemeral cluster
+// once the job is finished.
+func (r *VMRayJobReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.Log.Info("Reconciling VMRayJob", "job name", req.NamespacedName)
+
+	job := &vmrayv1alpha1.VMRayJob{}
+	if err := r.Client.Get(ctx, req.NamespacedName, job); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	original := job.DeepCopy()
+
+	// If deletion timestamp is non-zero, stop the job if it's still running.
+	if !job.ObjectMeta.DeletionTimestamp.IsZero() {
+		r.stopJob(ctx, job)
+		if controllerutil.ContainsFinalizer(job, jobFinalizerName) {
+			_ = controllerutil.RemoveFinalizer(job, jobFinalizerName)
+			return ctrl.Result{}, r.Update(ctx, job)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(job, jobFinalizerName) {
+		_ = controllerutil.AddFinalizer(job, jobFinalizerName)
+		r.Log.Info("VMRayJob adding finalizer", "finalizer", jobFinalizerName, "job name", job.ObjectMeta.Name)
+		if err := r.Update(ctx, job); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return r.VMRayJobReconcile(ctx, original, job)
+}
+
+func (r *VMRayJobReconciler) VMRayJobReconcile(ctx context.Context,
+	original, job *vmrayv1alpha1.VMRayJob) (ctrl.Result, error) {
+
+	job.Status.Conditions = []metav1.Condition{}
+	job.Status.ClusterName = job.GetClusterName()
+	if job.Status.JobDeploymentState == "" {
+		job.Status.JobDeploymentState = vmrayv1alpha1.JOB_INITIALIZING
+	}
+
+	// Step 1: Once the job is finished only the ephemeral cluster needs to be cleaned up.
+	if job.Status.JobStatus.IsTerminal() {
+		requeueAfter, err := r.reconcileFinishedJob(ctx, job)
+		if err != nil {
+			r.Log.Error(err, "VMRayJob failed to delete ephemeral cluster", "job name", job.ObjectMeta.Name)
+			addJobCondition(job, vmrayv1alpha1.VMRayJobConditionClusterDelete, metav1.ConditionFalse,
+				vmrayv1alpha1.FailureToDeleteClusterReason, err.Error())
+			return r.updateJobStatus(ctx, original, job, defaultRequeueDuration)
+		}
+		return r.updateJobStatus(ctx, original, job, requeueAfter)
+	}
+
+	// Step 2: Fetch the referenced cluster or create the ephemeral one.
+	cluster, err := r.reconcileJobCluster(ctx, job)
+	if err != nil {
+		if err == errInvalidJobSpec {
+			job.Status.JobDeploymentState = vmrayv1alpha1.JOB_FAILED
+			addJobCondition(job, vmrayv1alpha1.VMRayJobConditionClusterReady, metav1.ConditionFalse,
+				vmrayv1alpha1.InvalidJobSpecReason, err.Error())
+			return r.updateJobStatus(ctx, original, job, 0)
+		}
+		if errors.Is(err, errClusterNotOwned) {
+			job.Status.JobDeploymentState = vmrayv1alpha1.JOB_FAILED
+			addJobCondition(job, vmrayv1alpha1.VMRayJobConditionClusterReady, metav1.ConditionFalse,
+				vmrayv1alpha1.ClusterNotOwnedReason, err.Error())
+			return r.updateJobStatus(ctx, original, job, 0)
+		}
+		r.Log.Error(err, "VMRayJob failed to reconcile cluster", "job name", job.ObjectMeta.Name)
+		job.Status.JobDeploymentState = vmrayv1alpha1.JOB_WAITING_FOR_CLUSTER
+		addJobCondition(job, vmrayv1alpha1.VMRayJobConditionClusterReady, metav1.ConditionFalse,
+			vmrayv1alpha1.FailureToCreateClusterReason, err.Error())
+		return r.updateJobStatus(ctx, original, job, jobRequeueDuration)
+	}
+
+	// Step 3: Wait until ray process of the head node is running.
+	address := getDashboardIp(cluster)
+	if cluster.Status.HeadNodeStatus.RayStatus != vmrayv1alpha1.RAY_RUNNING || address == "" {
+		job.Status.JobDeploymentState = vmrayv1alpha1.JOB_WAITING_FOR_CLUSTER
+		addJobCondition(job, vmrayv1alpha1.VMRayJobConditionClusterReady, metav1.ConditionFalse,
+			vmrayv1alpha1.ClusterNotReadyReason, "ray head node of cluster "+cluster.ObjectMeta.Name+" is not running yet")
+		return r.updateJobStatus(ctx, original, job, jobRequeueDuration)
+	}
+	job.Status.DashboardURL = r.dashboardAddress(address)
+	dashboard := raydashboard.NewClient(job.Status.DashboardURL)
+
+	// Step 4: Submit the job if it's not submitted yet.
+	if job.Status.SubmissionID == "" {
+		if err := r.submitJob(ctx, job, dashboard); err != nil {
+			r.Log.Error(err, "VMRayJob submission failed", "job name", job.ObjectMeta.Name)
+			addJobCondition(job, vmrayv1alpha1.VMRayJobConditionJobSubmitted, metav1.ConditionFalse,
+				vmrayv1alpha1.FailureToSubmitJobReason, err.Error())
+			return r.updateJobStatus(ctx, original, job, jobRequeueDuration)
+		}
+	}
+	job.Status.JobDeploymentState = vmrayv1alpha1.JOB_RUNNING
+
+	// Step 5: Track status & logs of the submitted job.
+	if err := r.syncJobStatus(ctx, job, dashboard); err != nil {
+		r.Log.Error(err, "VMRayJob failed to fetch job status", "job name", job.ObjectMeta.Name)
+		addJobCondition(job, vmrayv1alpha1.VMRayJobConditionJobSubmitted, metav1.ConditionFalse,
+			vmrayv1alpha1.FailureToFetchJobStatusReason, err.Error())
+		return r.updateJobStatus(ctx, original, job, jobRequeueDuration)
+	}
+
+	if job.Status.JobStatus.IsTerminal() {
+		// Requeue right away so that cleanup is scheduled.
+		return r.updateJobStatus(ctx, original, job, time.Second)
+	}
+	return r.updateJobStatus(ctx, original, job, jobRequeueDuration)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *VMRayJobReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&vmrayv1alpha1.VMRayJob{}).
+		Owns(&vmrayv1alpha1.VMRayCluster{}).
+		Complete(r)
+}
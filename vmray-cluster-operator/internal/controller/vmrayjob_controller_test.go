@@ -0,0 +1,203 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controller_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+	vmraycontroller "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/internal/controller"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/raydashboard"
+	fakedashboard "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/raydashboard/fake"
+
+	testutil "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/test/builder/utils"
+)
+
+func rayJobUnitTests() {
+	Describe("VMRayJob controller tests", func() {
+
+		var (
+			testobjectname string = "test-object"
+			namespace      string = "default"
+		)
+
+		Context("When reconciling a job against an existing cluster", func() {
+			ctx := context.Background()
+
+			It("Submits the job once cluster is ready and tracks it till completion", func() {
+				server := fakedashboard.NewServer()
+				defer server.Close()
+
+				cluster := testutil.CreateRayClusterInstance(ctx, suite.GetK8sClient(), namespace, "vmrayjob-cluster", testobjectname)
+
+				job := &vmrayv1alpha1.VMRayJob{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "vmrayjob-test",
+						Namespace: namespace,
+					},
+					Spec: vmrayv1alpha1.VMRayJobSpec{
+						ClusterRef: cluster.ObjectMeta.Name,
+						Entrypoint: "python main.py",
+					},
+				}
+				Expect(suite.GetK8sClient().Create(ctx, job)).To(Succeed())
+				namespacedName := testutil.GetNamespacedName(namespace, job.ObjectMeta.Name)
+
+				controllerReconciler := vmraycontroller.NewVMRayJobReconciler(suite.GetK8sClient(), suite.GetK8sClient().Scheme(),
+					func(ip string) string { return server.URL })
+
+				// Cluster's head node isn't running yet.
+				_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(suite.GetK8sClient().Get(ctx, namespacedName, job)).To(Succeed())
+				Expect(job.Status.JobDeploymentState).To(Equal(vmrayv1alpha1.JOB_WAITING_FOR_CLUSTER))
+				Expect(job.Status.ClusterName).To(Equal(cluster.ObjectMeta.Name))
+				Expect(job.Status.Conditions[0].Reason).To(Equal(vmrayv1alpha1.ClusterNotReadyReason))
+
+				// Mark cluster's head node as running.
+				cluster.Status.HeadNodeStatus.Ip = "127.0.0.1"
+				cluster.Status.HeadNodeStatus.RayStatus = vmrayv1alpha1.RAY_RUNNING
+				Expect(suite.GetK8sClient().Status().Update(ctx, cluster)).To(Succeed())
+
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(suite.GetK8sClient().Get(ctx, namespacedName, job)).To(Succeed())
+				Expect(job.Status.JobDeploymentState).To(Equal(vmrayv1alpha1.JOB_RUNNING))
+				Expect(job.Status.SubmissionID).To(HavePrefix("vmrayjob-"))
+				Expect(job.Status.JobStatus).To(Equal(vmrayv1alpha1.RayJobRunning))
+				Expect(job.Status.DashboardURL).To(Equal(server.URL))
+				Expect(job.Status.StartTime).ToNot(BeNil())
+				submitted, ok := server.GetJob(job.Status.SubmissionID)
+				Expect(ok).To(BeTrue())
+				Expect(submitted.Entrypoint).To(Equal("python main.py"))
+
+				// Job finishes, referenced cluster must be retained.
+				server.SetJobLogs(job.Status.SubmissionID, "hello from ray")
+				server.SetJobStatus(job.Status.SubmissionID, raydashboard.JobStatusSucceeded, "")
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(suite.GetK8sClient().Get(ctx, namespacedName, job)).To(Succeed())
+				Expect(job.Status.JobDeploymentState).To(Equal(vmrayv1alpha1.JOB_COMPLETE))
+				Expect(job.Status.JobStatus).To(Equal(vmrayv1alpha1.RayJobSucceeded))
+				Expect(job.Status.Logs).To(Equal("hello from ray"))
+				Expect(job.Status.EndTime).ToNot(BeNil())
+
+				result, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RequeueAfter).To(BeZero())
+				Expect(suite.GetK8sClient().Get(ctx, testutil.GetNamespacedName(namespace, cluster.ObjectMeta.Name), cluster)).To(Succeed())
+
+				Expect(suite.GetK8sClient().Delete(ctx, job)).To(Succeed())
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				testutil.DeleteRayCluster(ctx, suite.GetK8sClient(), testutil.GetNamespacedName(namespace, cluster.ObjectMeta.Name), cluster)
+			})
+		})
+
+		Context("When reconciling a job with an embedded cluster spec", func() {
+			ctx := context.Background()
+
+			It("Creates an ephemeral cluster owned by the job and deletes it after job finishes", func() {
+				template := testutil.CreateRayClusterInstance(ctx, suite.GetK8sClient(), namespace, "vmrayjob-template", testobjectname)
+				clusterSpec := template.Spec
+				testutil.DeleteRayCluster(ctx, suite.GetK8sClient(), testutil.GetNamespacedName(namespace, template.ObjectMeta.Name), template)
+
+				job := &vmrayv1alpha1.VMRayJob{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "vmrayjob-ephemeral",
+						Namespace: namespace,
+					},
+					Spec: vmrayv1alpha1.VMRayJobSpec{
+						ClusterSpec: &clusterSpec,
+						Entrypoint:  "python main.py",
+					},
+				}
+				Expect(suite.GetK8sClient().Create(ctx, job)).To(Succeed())
+				namespacedName := testutil.GetNamespacedName(namespace, job.ObjectMeta.Name)
+
+				controllerReconciler := vmraycontroller.NewVMRayJobReconciler(suite.GetK8sClient(), suite.GetK8sClient().Scheme(),
+					func(ip string) string { return "" })
+
+				_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+
+				cluster := &vmrayv1alpha1.VMRayCluster{}
+				clusterName := testutil.GetNamespacedName(namespace, job.GetClusterName())
+				Expect(suite.GetK8sClient().Get(ctx, clusterName, cluster)).To(Succeed())
+				Expect(cluster.ObjectMeta.Labels[vmrayv1alpha1.VMRayJobCreatedByLabel]).To(Equal(job.ObjectMeta.Name))
+				Expect(cluster.ObjectMeta.OwnerReferences).To(HaveLen(1))
+				Expect(cluster.ObjectMeta.OwnerReferences[0].Name).To(Equal(job.ObjectMeta.Name))
+
+				// Simulate job completion, cluster is deleted as ttl is zero.
+				Expect(suite.GetK8sClient().Get(ctx, namespacedName, job)).To(Succeed())
+				now := metav1.Now()
+				job.Status.SubmissionID = "submitted"
+				job.Status.JobStatus = vmrayv1alpha1.RayJobFailed
+				job.Status.EndTime = &now
+				Expect(suite.GetK8sClient().Status().Update(ctx, job)).To(Succeed())
+
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(suite.GetK8sClient().Get(ctx, namespacedName, job)).To(Succeed())
+				Expect(job.Status.Conditions[0].Reason).To(Equal(vmrayv1alpha1.EphemeralClusterDeletedReason))
+
+				Expect(suite.GetK8sClient().Delete(ctx, job)).To(Succeed())
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("Fails the job and retains an existing cluster not owned by the job", func() {
+				cluster := testutil.CreateRayClusterInstance(ctx, suite.GetK8sClient(), namespace, "vmrayjob-unowned-cluster", testobjectname)
+				clusterSpec := cluster.Spec
+
+				job := &vmrayv1alpha1.VMRayJob{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "vmrayjob-unowned",
+						Namespace: namespace,
+					},
+					Spec: vmrayv1alpha1.VMRayJobSpec{
+						ClusterSpec: &clusterSpec,
+						Entrypoint:  "python main.py",
+					},
+				}
+				Expect(suite.GetK8sClient().Create(ctx, job)).To(Succeed())
+				namespacedName := testutil.GetNamespacedName(namespace, job.ObjectMeta.Name)
+				Expect(job.GetClusterName()).To(Equal(cluster.ObjectMeta.Name))
+
+				controllerReconciler := vmraycontroller.NewVMRayJobReconciler(suite.GetK8sClient(), suite.GetK8sClient().Scheme(),
+					func(ip string) string { return "" })
+
+				_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(suite.GetK8sClient().Get(ctx, namespacedName, job)).To(Succeed())
+				Expect(job.Status.JobDeploymentState).To(Equal(vmrayv1alpha1.JOB_FAILED))
+				Expect(job.Status.Conditions[0].Reason).To(Equal(vmrayv1alpha1.ClusterNotOwnedReason))
+
+				// Even after job finishes & its ttl elapses, the cluster is retained.
+				now := metav1.Now()
+				job.Status.SubmissionID = "submitted"
+				job.Status.JobStatus = vmrayv1alpha1.RayJobFailed
+				job.Status.EndTime = &now
+				Expect(suite.GetK8sClient().Status().Update(ctx, job)).To(Succeed())
+
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				clusterName := testutil.GetNamespacedName(namespace, cluster.ObjectMeta.Name)
+				Expect(suite.GetK8sClient().Get(ctx, clusterName, cluster)).To(Succeed())
+				Expect(cluster.ObjectMeta.DeletionTimestamp).To(BeNil())
+
+				Expect(suite.GetK8sClient().Delete(ctx, job)).To(Succeed())
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				testutil.DeleteRayCluster(ctx, suite.GetK8sClient(), clusterName, cluster)
+			})
+		})
+	})
+}
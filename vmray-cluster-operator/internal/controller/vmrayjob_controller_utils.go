@@ -0,0 +1,210 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/raydashboard"
+)
+
+var (
+	errInvalidJobSpec  = errors.New("exactly one of cluster_ref or cluster_spec must be set")
+	errClusterNotOwned = errors.New("cluster already exists and is not owned by the job")
+)
+
+// reconcileJobCluster returns the cluster job should be submitted to. For jobs
+// with embedded cluster spec, an ephemeral cluster owned by the job is created.
+func (r *VMRayJobReconciler) reconcileJobCluster(ctx context.Context, job *vmrayv1alpha1.VMRayJob) (*vmrayv1alpha1.VMRayCluster, error) {
+	if (job.Spec.ClusterRef == "") == (job.Spec.ClusterSpec == nil) {
+		return nil, errInvalidJobSpec
+	}
+
+	cluster := &vmrayv1alpha1.VMRayCluster{}
+	namespacedName := types.NamespacedName{
+		Namespace: job.ObjectMeta.Namespace,
+		Name:      job.GetClusterName(),
+	}
+	err := r.Client.Get(ctx, namespacedName, cluster)
+	if err == nil && job.Spec.ClusterRef == "" && !metav1.IsControlledBy(cluster, job) {
+		// Never take over a user's cluster which happens to have the ephemeral cluster's name.
+		return nil, fmt.Errorf("%w: %s", errClusterNotOwned, cluster.ObjectMeta.Name)
+	}
+	if err == nil || !k8serrors.IsNotFound(err) || job.Spec.ClusterRef != "" {
+		return cluster, err
+	}
+
+	// Ephemeral cluster doesn't exist yet, create it.
+	cluster = &vmrayv1alpha1.VMRayCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      namespacedName.Name,
+			Namespace: namespacedName.Namespace,
+			Labels: map[string]string{
+				vmrayv1alpha1.VMRayJobCreatedByLabel: job.ObjectMeta.Name,
+			},
+		},
+		Spec: *job.Spec.ClusterSpec.DeepCopy(),
+	}
+	if err := controllerutil.SetControllerReference(job, cluster, r.Scheme); err != nil {
+		return nil, err
+	}
+
+	r.Log.Info("Creating ephemeral cluster for VMRayJob", "job name", job.ObjectMeta.Name, "cluster name", cluster.ObjectMeta.Name)
+	if err := r.Client.Create(ctx, cluster); err != nil {
+		return nil, err
+	}
+	return cluster, nil
+}
+
+// submitJob submits the job's entrypoint to the ray cluster. Submission ID is
+// derived from job's UID so a submission whose status update was lost is
+// picked up again instead of being submitted twice.
+func (r *VMRayJobReconciler) submitJob(ctx context.Context, job *vmrayv1alpha1.VMRayJob, dashboard *raydashboard.Client) error {
+	submissionID := "vmrayjob-" + string(job.ObjectMeta.UID)
+
+	if _, err := dashboard.GetJob(ctx, submissionID); err == nil {
+		r.Log.Info("VMRayJob already submitted", "job name", job.ObjectMeta.Name, "submission id", submissionID)
+	} else if !raydashboard.IsNotFound(err) {
+		return err
+	} else {
+		request := &raydashboard.JobSubmitRequest{
+			Entrypoint:   job.Spec.Entrypoint,
+			SubmissionID: submissionID,
+			Metadata:     job.Spec.Metadata,
+		}
+		if job.Spec.RuntimeEnv != nil && len(job.Spec.RuntimeEnv.Raw) > 0 {
+			request.RuntimeEnv = json.RawMessage(job.Spec.RuntimeEnv.Raw)
+		}
+
+		r.Log.Info("Submitting VMRayJob", "job name", job.ObjectMeta.Name, "submission id", submissionID)
+		if _, err := dashboard.SubmitJob(ctx, request); err != nil {
+			return err
+		}
+	}
+
+	now := metav1.Now()
+	job.Status.SubmissionID = submissionID
+	job.Status.JobStatus = vmrayv1alpha1.RayJobPending
+	job.Status.StartTime = &now
+	addJobCondition(job, vmrayv1alpha1.VMRayJobConditionJobSubmitted, metav1.ConditionTrue,
+		vmrayv1alpha1.JobSubmittedReason, "job is submitted with submission id "+submissionID)
+	return nil
+}
+
+// syncJobStatus fetches status & logs of the submitted job from ray.
+func (r *VMRayJobReconciler) syncJobStatus(ctx context.Context, job *vmrayv1alpha1.VMRayJob, dashboard *raydashboard.Client) error {
+	details, err := dashboard.GetJob(ctx, job.Status.SubmissionID)
+	if err != nil {
+		return err
+	}
+	job.Status.JobStatus = vmrayv1alpha1.RayJobStatus(details.Status)
+	job.Status.Message = details.Message
+
+	logs, err := dashboard.GetJobLogs(ctx, job.Status.SubmissionID)
+	if err != nil {
+		return err
+	}
+	if len(logs) > maxJobLogsLength {
+		logs = logs[len(logs)-maxJobLogsLength:]
+	}
+	job.Status.Logs = logs
+
+	if !job.Status.JobStatus.IsTerminal() {
+		return nil
+	}
+
+	now := metav1.Now()
+	job.Status.EndTime = &now
+	if job.Status.JobStatus == vmrayv1alpha1.RayJobSucceeded {
+		job.Status.JobDeploymentState = vmrayv1alpha1.JOB_COMPLETE
+	} else {
+		job.Status.JobDeploymentState = vmrayv1alpha1.JOB_FAILED
+	}
+	r.Log.Info("VMRayJob finished", "job name", job.ObjectMeta.Name, "status", job.Status.JobStatus)
+	return nil
+}
+
+// reconcileFinishedJob deletes the job's ephemeral cluster once its TTL after the job
+// finished has elapsed. It returns the duration after which job should be requeued.
+func (r *VMRayJobReconciler) reconcileFinishedJob(ctx context.Context, job *vmrayv1alpha1.VMRayJob) (time.Duration, error) {
+	// Referenced clusters are owned by the user and never deleted.
+	if job.Spec.ClusterRef != "" || job.Status.EndTime == nil {
+		return 0, nil
+	}
+
+	deleteAt := job.Status.EndTime.Add(time.Duration(job.Spec.TTLSecondsAfterFinished) * time.Second)
+	if remaining := time.Until(deleteAt); remaining > 0 {
+		return remaining, nil
+	}
+
+	cluster := &vmrayv1alpha1.VMRayCluster{}
+	namespacedName := types.NamespacedName{
+		Namespace: job.ObjectMeta.Namespace,
+		Name:      job.GetClusterName(),
+	}
+	if err := r.Client.Get(ctx, namespacedName, cluster); err != nil {
+		return 0, client.IgnoreNotFound(err)
+	}
+	if !metav1.IsControlledBy(cluster, job) {
+		r.Log.Info("Skipping deletion of cluster not owned by VMRayJob", "job name", job.ObjectMeta.Name, "cluster name", cluster.ObjectMeta.Name)
+		return 0, nil
+	}
+	if !cluster.ObjectMeta.DeletionTimestamp.IsZero() {
+		return defaultRequeueDuration, nil
+	}
+
+	r.Log.Info("Deleting ephemeral cluster of finished VMRayJob", "job name", job.ObjectMeta.Name, "cluster name", cluster.ObjectMeta.Name)
+	if err := r.Client.Delete(ctx, cluster); err != nil {
+		return 0, client.IgnoreNotFound(err)
+	}
+	addJobCondition(job, vmrayv1alpha1.VMRayJobConditionClusterDelete, metav1.ConditionTrue,
+		vmrayv1alpha1.EphemeralClusterDeletedReason, "ephemeral cluster "+cluster.ObjectMeta.Name+" is deleted")
+	return 0, nil
+}
+
+// stopJob makes best effort to stop a running job, e.g. when VMRayJob is deleted.
+func (r *VMRayJobReconciler) stopJob(ctx context.Context, job *vmrayv1alpha1.VMRayJob) {
+	if job.Status.SubmissionID == "" || job.Status.DashboardURL == "" || job.Status.JobStatus.IsTerminal() {
+		return
+	}
+
+	if _, err := raydashboard.NewClient(job.Status.DashboardURL).StopJob(ctx, job.Status.SubmissionID); err != nil {
+		r.Log.Error(err, "VMRayJob failed to stop job", "job name", job.ObjectMeta.Name,
+			"submission id", job.Status.SubmissionID)
+	}
+}
+
+func addJobCondition(job *vmrayv1alpha1.VMRayJob, Type string, Status metav1.ConditionStatus, Reason, Message string) {
+	job.Status.Conditions = append(job.Status.Conditions, metav1.Condition{
+		Type:               Type,
+		Status:             Status,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+		Reason:             Reason,
+		Message:            Message,
+	})
+}
+
+func (r *VMRayJobReconciler) updateJobStatus(ctx context.Context, original, job *vmrayv1alpha1.VMRayJob, duration time.Duration) (ctrl.Result, error) {
+	r.Log.Info("Update Ray job CR status", "name", job.ObjectMeta.Name, "state", job.Status.JobDeploymentState,
+		"job status", job.Status.JobStatus)
+
+	patch := client.MergeFrom(original)
+	if err := r.Client.Status().Patch(ctx, job, patch); err != nil {
+		r.Log.Error(err, "Error when updating status", "job name", job.ObjectMeta.Name)
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: duration}, nil
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+	vmprovider "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider"
+)
+
+const (
+	serviceFinalizerName = "vmrayservice.vmray.broadcom.com"
+)
+
+// VMRayServiceReconciler reconciles a VMRayService object
+type VMRayServiceReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	Log    logr.Logger
+
+	provider         vmprovider.VmProvider
+	dashboardAddress DashboardAddressFunc
+}
+
+func NewVMRayServiceReconciler(client client.Client, Scheme *runtime.Scheme,
+	provider vmprovider.VmProvider, dashboardAddress DashboardAddressFunc) *VMRayServiceReconciler {
+	return &VMRayServiceReconciler{
+		Client:           client,
+		Scheme:           Scheme,
+		provider:         provider,
+		dashboardAddress: dashboardAddress,
+		Log:              ctrl.Log.WithName("VMRayServiceReconciler"),
+	}
+}
+
+// +kubebuilder:rbac:groups=vmray.broadcom.com,resources=vmrayservices,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=vmray.broadcom.com,resources=vmrayservices/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=vmray.broadcom.com,resources=vmrayservices/finalizers,verbs=update
+
+// Reconcile runs ray serve applications of a VMRayService on a cluster it owns.
+// Whenever cluster or serve config changes, a new cluster is brought up next to
+// the active one and serve traffic is switched to it once its applications are
+// healthy, after which the old cluster is deleted.
+func (r *VMRayServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.Log.Info("Reconciling VMRayService", "service name", req.NamespacedName)
+
+	svc := &vmrayv1alpha1.VMRayService{}
+	if err := r.Client.Get(ctx, req.NamespacedName, svc); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	original := svc.DeepCopy()
+
+	// If deletion timestamp is non-zero, delete the serve VM service. Clusters
+	// are owned by the VMRayService and get garbage collected.
+	if !svc.ObjectMeta.DeletionTimestamp.IsZero() {
+		if err := r.provider.DeleteServeService(ctx, svc.ObjectMeta.Namespace, svc.GetServeServiceName()); err != nil {
+			r.Log.Error(err, "VMRayService failed to delete serve VM service", "service name", svc.ObjectMeta.Name)
+			return ctrl.Result{RequeueAfter: defaultRequeueDuration}, nil
+		}
+		if controllerutil.ContainsFinalizer(svc, serviceFinalizerName) {
+			_ = controllerutil.RemoveFinalizer(svc, serviceFinalizerName)
+			return ctrl.Result{}, r.Update(ctx, svc)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(svc, serviceFinalizerName) {
+		_ = controllerutil.AddFinalizer(svc, serviceFinalizerName)
+		r.Log.Info("VMRayService adding finalizer", "finalizer", serviceFinalizerName, "service name", svc.ObjectMeta.Name)
+		if err := r.Update(ctx, svc); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return r.VMRayServiceReconcile(ctx, original, svc)
+}
+
+func (r *VMRayServiceReconciler) VMRayServiceReconcile(ctx context.Context,
+	original, svc *vmrayv1alpha1.VMRayService) (ctrl.Result, error) {
+
+	svc.Status.Conditions = []metav1.Condition{}
+	hash, err := getServeConfigHash(svc)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Step 1: Decide whether a new cluster needs to be brought up.
+	pending := &svc.Status.PendingCluster
+	if svc.Status.ActiveCluster.ConfigHash != hash {
+		if pending.ConfigHash != hash {
+			// Spec changed again while an upgrade was in progress, drop the stale pending cluster.
+			if err := r.deleteServeCluster(ctx, svc, pending.ClusterName); err != nil {
+				return ctrl.Result{}, err
+			}
+			*pending = vmrayv1alpha1.VMRayServiceClusterStatus{
+				ClusterName: getServeClusterName(svc, hash),
+				ConfigHash:  hash,
+			}
+		}
+	} else if pending.ClusterName != "" {
+		// Spec was reverted to the active config, pending cluster is no longer needed.
+		if err := r.deleteServeCluster(ctx, svc, pending.ClusterName); err != nil {
+			return ctrl.Result{}, err
+		}
+		*pending = vmrayv1alpha1.VMRayServiceClusterStatus{}
+	}
+
+	// Step 2: Reconcile the pending cluster and switch traffic to it once it's healthy.
+	if pending.ClusterName != "" {
+		if err := r.reconcilePendingCluster(ctx, svc); err != nil {
+			r.Log.Error(err, "VMRayService failed to reconcile pending cluster", "service name", svc.ObjectMeta.Name,
+				"cluster name", pending.ClusterName)
+			addServiceCondition(svc, vmrayv1alpha1.VMRayServiceConditionClusterUpgrade,
+				vmrayv1alpha1.FailureToApplyServeConfigReason, err.Error())
+		}
+	}
+
+	// Step 3: Reconcile the active cluster & the serve VM service in front of it.
+	activeHealthy := false
+	if svc.Status.ActiveCluster.ClusterName != "" {
+		cluster, healthy, err := r.reconcileServeCluster(ctx, svc, &svc.Status.ActiveCluster)
+		if err != nil {
+			r.Log.Error(err, "VMRayService failed to reconcile active cluster", "service name", svc.ObjectMeta.Name)
+			addServiceCondition(svc, vmrayv1alpha1.VMRayServiceConditionServeReady,
+				vmrayv1alpha1.FailureToFetchServeStatusReason, err.Error())
+		} else if !healthy {
+			addServiceCondition(svc, vmrayv1alpha1.VMRayServiceConditionServeReady,
+				vmrayv1alpha1.ServeApplicationsUnhealthyReason, "serve applications of cluster "+
+					svc.Status.ActiveCluster.ClusterName+" are not healthy")
+		}
+		activeHealthy = healthy
+
+		if cluster != nil {
+			if err := r.deployServeService(ctx, svc, cluster); err != nil {
+				r.Log.Error(err, "VMRayService failed to deploy serve VM service", "service name", svc.ObjectMeta.Name)
+				addServiceCondition(svc, vmrayv1alpha1.VMRayServiceConditionServiceDeploy,
+					vmrayv1alpha1.FailureToDeployServeServiceReason, err.Error())
+			}
+		}
+	}
+
+	// Step 4: Update overall state of the service.
+	switch {
+	case svc.Status.ActiveCluster.ClusterName == "":
+		svc.Status.ServiceState = vmrayv1alpha1.SERVICE_PENDING
+	case pending.ClusterName != "":
+		svc.Status.ServiceState = vmrayv1alpha1.SERVICE_UPGRADING
+	case !activeHealthy:
+		svc.Status.ServiceState = vmrayv1alpha1.SERVICE_UNHEALTHY
+	default:
+		svc.Status.ServiceState = vmrayv1alpha1.SERVICE_RUNNING
+	}
+
+	return r.updateServiceStatus(ctx, original, svc)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *VMRayServiceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&vmrayv1alpha1.VMRayService{}).
+		Owns(&vmrayv1alpha1.VMRayCluster{}).
+		Complete(r)
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controller_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+	vmraycontroller "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/internal/controller"
+	mockvmpv "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/mock"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/raydashboard"
+	fakedashboard "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/raydashboard/fake"
+
+	testutil "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/test/builder/utils"
+)
+
+func serveDetails(status raydashboard.ServeApplicationStatus) raydashboard.ServeDetails {
+	return raydashboard.ServeDetails{
+		Applications: map[string]raydashboard.ServeApplicationDetails{
+			"app1": {
+				Name:   "app1",
+				Status: status,
+				Deployments: map[string]raydashboard.ServeDeploymentDetails{
+					"Model": {Name: "Model", Status: raydashboard.ServeDeploymentHealthy},
+				},
+			},
+		},
+	}
+}
+
+func markClusterRunning(ctx context.Context, name, namespace string) *vmrayv1alpha1.VMRayCluster {
+	cluster := &vmrayv1alpha1.VMRayCluster{}
+	Expect(suite.GetK8sClient().Get(ctx, testutil.GetNamespacedName(namespace, name), cluster)).To(Succeed())
+	cluster.Status.HeadNodeStatus.Ip = "127.0.0.1"
+	cluster.Status.HeadNodeStatus.RayStatus = vmrayv1alpha1.RAY_RUNNING
+	Expect(suite.GetK8sClient().Status().Update(ctx, cluster)).To(Succeed())
+	return cluster
+}
+
+func rayServiceUnitTests() {
+	Describe("VMRayService controller tests", func() {
+
+		var (
+			testobjectname string = "test-object"
+			namespace      string = "default"
+		)
+
+		Context("When reconciling a service", func() {
+			ctx := context.Background()
+
+			It("Brings up a cluster, switches serve traffic to it and replaces it on config change", func() {
+				server := fakedashboard.NewServer()
+				defer server.Close()
+				server.SetServeApplications(serveDetails(raydashboard.ServeApplicationDeploying))
+
+				template := testutil.CreateRayClusterInstance(ctx, suite.GetK8sClient(), namespace, "vmrayservice-template", testobjectname)
+				clusterSpec := template.Spec
+				testutil.DeleteRayCluster(ctx, suite.GetK8sClient(), testutil.GetNamespacedName(namespace, template.ObjectMeta.Name), template)
+
+				svc := &vmrayv1alpha1.VMRayService{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "vmrayservice-test",
+						Namespace: namespace,
+					},
+					Spec: vmrayv1alpha1.VMRayServiceSpec{
+						ClusterSpec: clusterSpec,
+						ServeConfig: runtime.RawExtension{Raw: []byte(`{"applications": [{"name": "app1", "import_path": "main:app"}]}`)},
+					},
+				}
+				Expect(suite.GetK8sClient().Create(ctx, svc)).To(Succeed())
+				namespacedName := testutil.GetNamespacedName(namespace, svc.ObjectMeta.Name)
+
+				provider := mockvmpv.NewMockVmProvider()
+				controllerReconciler := vmraycontroller.NewVMRayServiceReconciler(suite.GetK8sClient(), suite.GetK8sClient().Scheme(),
+					provider, func(ip string) string { return server.URL })
+
+				// 1. First reconcile creates the cluster.
+				_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(suite.GetK8sClient().Get(ctx, namespacedName, svc)).To(Succeed())
+				Expect(svc.Status.ServiceState).To(Equal(vmrayv1alpha1.SERVICE_PENDING))
+				firstCluster := svc.Status.PendingCluster.ClusterName
+				Expect(firstCluster).To(HavePrefix(svc.ObjectMeta.Name + "-"))
+
+				// 2. Once cluster is running serve config is applied, but applications are still deploying.
+				markClusterRunning(ctx, firstCluster, namespace)
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(suite.GetK8sClient().Get(ctx, namespacedName, svc)).To(Succeed())
+				Expect(svc.Status.ServiceState).To(Equal(vmrayv1alpha1.SERVICE_PENDING))
+				Expect(svc.Status.PendingCluster.Applications["app1"].Status).To(Equal("DEPLOYING"))
+				Expect(server.ServeConfig()).To(MatchJSON(`{"applications": [{"name": "app1", "import_path": "main:app"}],
+					"http_options": {"host": "0.0.0.0", "port": 8000}}`))
+
+				// 3. Applications are healthy, serve service is pointed at the cluster.
+				server.SetServeApplications(serveDetails(raydashboard.ServeApplicationRunning))
+				provider.DeployServeServiceSetResponse(1, "", nil)
+				provider.DeployServeServiceSetResponse(2, "192.10.10.2", nil)
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(suite.GetK8sClient().Get(ctx, namespacedName, svc)).To(Succeed())
+				Expect(svc.Status.ServiceState).To(Equal(vmrayv1alpha1.SERVICE_RUNNING))
+				Expect(svc.Status.ActiveCluster.ClusterName).To(Equal(firstCluster))
+				Expect(svc.Status.PendingCluster.ClusterName).To(BeEmpty())
+				Expect(svc.Status.ActiveCluster.Applications["app1"].Deployments["Model"].Status).To(Equal("HEALTHY"))
+				Expect(svc.Status.ServeEndpoint).To(Equal("http://192.10.10.2:8000"))
+				Expect(provider.DeployServeServiceGetRequest(1).Name).To(Equal(svc.GetServeServiceName()))
+				Expect(provider.DeployServeServiceGetRequest(1).HeadVmName).To(HavePrefix(firstCluster + "-h"))
+
+				// 4. Change serve config, a new cluster is brought up next to the active one.
+				svc.Spec.ServeConfig = runtime.RawExtension{Raw: []byte(`{"applications": [{"name": "app1", "import_path": "main:app2"}]}`)}
+				Expect(suite.GetK8sClient().Update(ctx, svc)).To(Succeed())
+				server.ResetServe()
+				provider.DeployServeServiceSetResponse(3, "192.10.10.2", nil)
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(suite.GetK8sClient().Get(ctx, namespacedName, svc)).To(Succeed())
+				Expect(svc.Status.ServiceState).To(Equal(vmrayv1alpha1.SERVICE_UPGRADING))
+				secondCluster := svc.Status.PendingCluster.ClusterName
+				Expect(secondCluster).ToNot(Equal(firstCluster))
+				Expect(svc.Status.ActiveCluster.ClusterName).To(Equal(firstCluster))
+
+				// 5. New cluster is healthy, traffic is switched & old cluster is deleted.
+				markClusterRunning(ctx, secondCluster, namespace)
+				provider.DeployServeServiceSetResponse(4, "192.10.10.2", nil)
+				provider.DeployServeServiceSetResponse(5, "192.10.10.2", nil)
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(suite.GetK8sClient().Get(ctx, namespacedName, svc)).To(Succeed())
+				Expect(svc.Status.ServiceState).To(Equal(vmrayv1alpha1.SERVICE_RUNNING))
+				Expect(svc.Status.ActiveCluster.ClusterName).To(Equal(secondCluster))
+				Expect(provider.DeployServeServiceGetRequest(4).HeadVmName).To(HavePrefix(secondCluster + "-h"))
+
+				cluster := &vmrayv1alpha1.VMRayCluster{}
+				err = suite.GetK8sClient().Get(ctx, testutil.GetNamespacedName(namespace, firstCluster), cluster)
+				Expect(err).To(HaveOccurred())
+
+				// 6. Delete the service, serve VM service is deleted.
+				provider.DeleteServeServiceSetResponse(1, nil)
+				Expect(suite.GetK8sClient().Delete(ctx, svc)).To(Succeed())
+				_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(provider.DeleteServeServiceGetRequest(1).Name).To(Equal(svc.GetServeServiceName()))
+			})
+		})
+	})
+}
@@ -0,0 +1,233 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/raydashboard"
+)
+
+const (
+	serveConfigHashLength  = 10
+	serveClusterHashLength = 5
+)
+
+// getServeConfigHash returns hash of cluster spec & serve config, a change
+// in either of them requires serve applications to move to a new cluster.
+func getServeConfigHash(svc *vmrayv1alpha1.VMRayService) (string, error) {
+	spec, err := json.Marshal(svc.Spec.ClusterSpec)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write(spec)
+	h.Write(svc.Spec.ServeConfig.Raw)
+	return hex.EncodeToString(h.Sum(nil))[:serveConfigHashLength], nil
+}
+
+func getServeClusterName(svc *vmrayv1alpha1.VMRayService, hash string) string {
+	return svc.ObjectMeta.Name + "-" + hash[:serveClusterHashLength]
+}
+
+// getServeConfig returns serve config to be applied, making sure serve's HTTP proxy
+// listens on all interfaces at the port exposed by the serve VM service.
+func getServeConfig(svc *vmrayv1alpha1.VMRayService) (json.RawMessage, error) {
+	config := map[string]interface{}{}
+	if len(svc.Spec.ServeConfig.Raw) > 0 {
+		if err := json.Unmarshal(svc.Spec.ServeConfig.Raw, &config); err != nil {
+			return nil, err
+		}
+	}
+
+	httpOptions, ok := config["http_options"].(map[string]interface{})
+	if !ok {
+		httpOptions = map[string]interface{}{}
+	}
+	if _, ok := httpOptions["host"]; !ok {
+		httpOptions["host"] = "0.0.0.0"
+	}
+	httpOptions["port"] = raydashboard.DefaultServePort
+	config["http_options"] = httpOptions
+
+	return json.Marshal(config)
+}
+
+// reconcilePendingCluster brings up the pending cluster and once its serve
+// applications are healthy, switches serve traffic to it & deletes the old cluster.
+func (r *VMRayServiceReconciler) reconcilePendingCluster(ctx context.Context, svc *vmrayv1alpha1.VMRayService) error {
+	cluster, healthy, err := r.reconcileServeCluster(ctx, svc, &svc.Status.PendingCluster)
+	if err != nil || !healthy {
+		return err
+	}
+
+	r.Log.Info("Switching serve traffic to new cluster", "service name", svc.ObjectMeta.Name,
+		"old cluster", svc.Status.ActiveCluster.ClusterName, "new cluster", cluster.ObjectMeta.Name)
+	if err := r.deployServeService(ctx, svc, cluster); err != nil {
+		return err
+	}
+
+	old := svc.Status.ActiveCluster.ClusterName
+	svc.Status.ActiveCluster = svc.Status.PendingCluster
+	svc.Status.PendingCluster = vmrayv1alpha1.VMRayServiceClusterStatus{}
+	return r.deleteServeCluster(ctx, svc, old)
+}
+
+// reconcileServeCluster creates the cluster if it doesn't exist, applies serve config once
+// its head node is running and refreshes applications' status. It returns the cluster
+// once it's running along with whether all of its serve applications are healthy.
+func (r *VMRayServiceReconciler) reconcileServeCluster(ctx context.Context, svc *vmrayv1alpha1.VMRayService,
+	status *vmrayv1alpha1.VMRayServiceClusterStatus) (*vmrayv1alpha1.VMRayCluster, bool, error) {
+
+	cluster := &vmrayv1alpha1.VMRayCluster{}
+	namespacedName := types.NamespacedName{
+		Namespace: svc.ObjectMeta.Namespace,
+		Name:      status.ClusterName,
+	}
+	if err := r.Client.Get(ctx, namespacedName, cluster); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return nil, false, err
+		}
+		return nil, false, r.createServeCluster(ctx, svc, status)
+	}
+
+	address := getDashboardIp(cluster)
+	if cluster.Status.HeadNodeStatus.RayStatus != vmrayv1alpha1.RAY_RUNNING || address == "" {
+		return nil, false, nil
+	}
+	dashboard := raydashboard.NewClient(r.dashboardAddress(address))
+
+	// Serve config is applied only once per cluster, as any change to it results in a new cluster.
+	if cluster.ObjectMeta.Annotations[vmrayv1alpha1.ServeConfigAppliedAnnotation] != status.ConfigHash {
+		config, err := getServeConfig(svc)
+		if err != nil {
+			return nil, false, err
+		}
+		r.Log.Info("Applying serve config", "service name", svc.ObjectMeta.Name, "cluster name", cluster.ObjectMeta.Name)
+		if err := dashboard.DeployServeApplications(ctx, config); err != nil {
+			return nil, false, err
+		}
+
+		patch := client.MergeFrom(cluster.DeepCopy())
+		if cluster.ObjectMeta.Annotations == nil {
+			cluster.ObjectMeta.Annotations = make(map[string]string)
+		}
+		cluster.ObjectMeta.Annotations[vmrayv1alpha1.ServeConfigAppliedAnnotation] = status.ConfigHash
+		if err := r.Client.Patch(ctx, cluster, patch); err != nil {
+			return nil, false, err
+		}
+	}
+
+	details, err := dashboard.GetServeApplications(ctx)
+	if err != nil {
+		return cluster, false, err
+	}
+
+	status.Applications = make(map[string]vmrayv1alpha1.ServeApplicationStatus)
+	for name, app := range details.Applications {
+		appStatus := vmrayv1alpha1.ServeApplicationStatus{
+			Status:      string(app.Status),
+			Message:     app.Message,
+			Deployments: make(map[string]vmrayv1alpha1.ServeDeploymentStatus),
+		}
+		for deploymentName, deployment := range app.Deployments {
+			appStatus.Deployments[deploymentName] = vmrayv1alpha1.ServeDeploymentStatus{
+				Status:  string(deployment.Status),
+				Message: deployment.Message,
+			}
+		}
+		status.Applications[name] = appStatus
+	}
+	return cluster, details.IsHealthy(), nil
+}
+
+func (r *VMRayServiceReconciler) createServeCluster(ctx context.Context, svc *vmrayv1alpha1.VMRayService,
+	status *vmrayv1alpha1.VMRayServiceClusterStatus) error {
+
+	cluster := &vmrayv1alpha1.VMRayCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      status.ClusterName,
+			Namespace: svc.ObjectMeta.Namespace,
+			Labels: map[string]string{
+				vmrayv1alpha1.VMRayServiceCreatedByLabel: svc.ObjectMeta.Name,
+			},
+			Annotations: map[string]string{
+				vmrayv1alpha1.ServeConfigHashAnnotation: status.ConfigHash,
+			},
+		},
+		Spec: *svc.Spec.ClusterSpec.DeepCopy(),
+	}
+	if err := controllerutil.SetControllerReference(svc, cluster, r.Scheme); err != nil {
+		return err
+	}
+
+	r.Log.Info("Creating cluster for VMRayService", "service name", svc.ObjectMeta.Name, "cluster name", cluster.ObjectMeta.Name)
+	return r.Client.Create(ctx, cluster)
+}
+
+func (r *VMRayServiceReconciler) deleteServeCluster(ctx context.Context, svc *vmrayv1alpha1.VMRayService, name string) error {
+	if name == "" {
+		return nil
+	}
+
+	cluster := &vmrayv1alpha1.VMRayCluster{}
+	namespacedName := types.NamespacedName{
+		Namespace: svc.ObjectMeta.Namespace,
+		Name:      name,
+	}
+	if err := r.Client.Get(ctx, namespacedName, cluster); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	r.Log.Info("Deleting cluster of VMRayService", "service name", svc.ObjectMeta.Name, "cluster name", name)
+	return client.IgnoreNotFound(r.Client.Delete(ctx, cluster))
+}
+
+// deployServeService points the serve VM service at head node of the given cluster.
+func (r *VMRayServiceReconciler) deployServeService(ctx context.Context, svc *vmrayv1alpha1.VMRayService,
+	cluster *vmrayv1alpha1.VMRayCluster) error {
+
+	headVmName := getHeadNodeName(cluster)
+	ip, err := r.provider.DeployServeService(ctx, svc.ObjectMeta.Namespace, svc.GetServeServiceName(), headVmName)
+	if err != nil {
+		return err
+	}
+	if ip != "" {
+		svc.Status.ServeEndpoint = raydashboard.GetServeAddress(ip)
+	}
+	return nil
+}
+
+func addServiceCondition(svc *vmrayv1alpha1.VMRayService, Type, Reason, Message string) {
+	svc.Status.Conditions = append(svc.Status.Conditions, metav1.Condition{
+		Type:               Type,
+		Status:             metav1.ConditionFalse,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+		Reason:             Reason,
+		Message:            Message,
+	})
+}
+
+func (r *VMRayServiceReconciler) updateServiceStatus(ctx context.Context, original, svc *vmrayv1alpha1.VMRayService) (ctrl.Result, error) {
+	r.Log.Info("Update Ray service CR status", "name", svc.ObjectMeta.Name, "state", svc.Status.ServiceState)
+
+	patch := client.MergeFrom(original)
+	if err := r.Client.Status().Patch(ctx, svc, patch); err != nil {
+		r.Log.Error(err, "Error when updating status", "service name", svc.ObjectMeta.Name)
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: headRequeueDuration}, nil
+}
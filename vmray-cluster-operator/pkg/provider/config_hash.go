@@ -0,0 +1,130 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+)
+
+const (
+	// ConfigHashAnnotation holds hash of the configuration a ray node's
+	// VM or cloud init secret was created with.
+	ConfigHashAnnotation = "vmray.kubernetes.io/config-hash"
+	// AdoptedConfigHash is recorded on adopted VMs instead of a hash, as they weren't
+	// deployed with node's configuration. It never matches, so they're outdated.
+	AdoptedConfigHash = "adopted"
+	configHashLength  = 16
+)
+
+// clusterConfig is the configuration shared by all ray nodes of a cluster,
+// it is rendered into head & worker cloud init secrets.
+type clusterConfig struct {
+	DockerImage            string   `json:"docker_image"`
+	VMImage                string   `json:"vm_image"`
+	SetupCommands          []string `json:"setup_commands,omitempty"`
+	HeadSetupCommands      []string `json:"head_setup_commands,omitempty"`
+	WorkerSetupCommands    []string `json:"worker_setup_commands,omitempty"`
+	InitializationCommands []string `json:"initialization_commands,omitempty"`
+	DockerAuthSecretName   string   `json:"docker_auth_secret_name,omitempty"`
+	EnableTLS              bool     `json:"enable_tls"`
+	VMUser                 string   `json:"vm_user"`
+	VMPasswordSaltHash     string   `json:"vm_password_salt_hash"`
+}
+
+// cloudInitConfig is the configuration rendered into a cloud init secret.
+type cloudInitConfig struct {
+	ClusterConfigHash string `json:"cluster_config_hash"`
+	GPUSetup          bool   `json:"gpu_setup,omitempty"`
+	Zone              string `json:"zone,omitempty"`
+	// Placement is set since nodes write their placement env file.
+	Placement bool `json:"placement"`
+}
+
+// nodeConfig is the effective configuration of a single ray node.
+type nodeConfig struct {
+	ClusterConfigHash string         `json:"cluster_config_hash"`
+	VMClass           string         `json:"vm_class"`
+	Resources         resourceConfig `json:"resources"`
+}
+
+// resourceConfig is a node's resources in the shape they had as integers, so
+// hashes of nodes created before resources became quantities don't change.
+type resourceConfig struct {
+	CPU             int64             `json:"cpu,omitempty"`
+	Memory          int64             `json:"memory,omitempty"`
+	GPU             int64             `json:"gpu,omitempty"`
+	CustomResources map[string]string `json:"custom_resources,omitempty"`
+}
+
+// GetClusterConfigHash returns hash of the configuration shared by all ray nodes
+// of the cluster, it changes whenever cloud init secrets have to be regenerated.
+func GetClusterConfigHash(req VmDeploymentRequest) string {
+	return computeHash(clusterConfig{
+		DockerImage:            req.DockerImage,
+		VMImage:                req.NodeConfig.VMImage,
+		SetupCommands:          req.NodeConfig.SetupCommands,
+		HeadSetupCommands:      req.HeadNodeConfig.SetupCommands,
+		WorkerSetupCommands:    req.WorkerNodeConfig.SetupCommands,
+		InitializationCommands: req.NodeConfig.InitializationCommands,
+		DockerAuthSecretName:   req.DockerConfig.AuthSecretName,
+		EnableTLS:              req.EnableTLS,
+		VMUser:                 req.NodeConfig.VMUser,
+		VMPasswordSaltHash:     req.NodeConfig.VMPasswordSaltHash,
+	})
+}
+
+// GetCloudInitConfigHash returns hash of the configuration rendered into the requested
+// node's cloud init secret, i.e. cluster's configuration, whether it sets up GPUs and
+// the zone of the node, which it advertises to ray. Secrets rendered before nodes got
+// their placement env file get a different hash, so they're regenerated.
+func GetCloudInitConfigHash(req VmDeploymentRequest, gpuSetup bool) string {
+	return computeHash(cloudInitConfig{
+		ClusterConfigHash: GetClusterConfigHash(req),
+		GPUSetup:          gpuSetup,
+		Zone:              req.Zone,
+		Placement:         true,
+	})
+}
+
+// GetNodeConfigHash returns hash of the effective configuration of the requested
+// ray node, a node whose VM carries a different hash has to be replaced.
+func GetNodeConfigHash(req VmDeploymentRequest) string {
+	nt := req.NodeConfig.NodeTypes[req.NodeType]
+	return computeHash(nodeConfig{
+		ClusterConfigHash: GetClusterConfigHash(req),
+		VMClass:           nt.VMClass,
+		Resources:         getResourceConfig(nt.Resources),
+	})
+}
+
+func getResourceConfig(resources vmrayv1alpha1.NodeResource) resourceConfig {
+	config := resourceConfig{}
+	if resources.CPU != nil {
+		config.CPU = resources.CPU.Value()
+	}
+	if resources.Memory != nil {
+		config.Memory = resources.Memory.Value()
+	}
+	if resources.GPU != nil {
+		config.GPU = resources.GPU.Value()
+	}
+	if len(resources.CustomResources) > 0 {
+		config.CustomResources = make(map[string]string, len(resources.CustomResources))
+		for name, quantity := range resources.CustomResources {
+			config.CustomResources[name] = quantity.String()
+		}
+	}
+	return config
+}
+
+func computeHash(config interface{}) string {
+	// Marshalling plain structs of strings & numbers can't fail.
+	b, _ := json.Marshal(config)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])[:configHashLength]
+}
@@ -0,0 +1,205 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+Package conformance holds Ginkgo specs defining the semantics every VmProvider
+implementation has to follow, controllers rely on them to be idempotent:
+
+  - DeployVmService returns an empty IP without error while the service is being
+    created and returns service's IP once assigned.
+  - Deploy of an already deployed node either succeeds or returns AlreadyExists.
+  - FetchVmStatus returns NotFound when node's VM doesn't exist.
+  - ListClusterVms returns cluster's VMs only, identified by their labels.
+  - Delete, DeleteServeService & DeleteAuxiliaryResources ignore NotFound.
+
+Providers register the specs from within their test suite with ProviderConformanceTests.
+*/
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider"
+)
+
+const (
+	headNodeType   = "ray_head"
+	workerNodeType = "worker_1"
+	defaultTimeout = 10 * time.Second
+)
+
+// Harness gives the conformance specs a provider and control over the
+// infrastructure backing it.
+type Harness struct {
+	// Provider under test.
+	Provider provider.VmProvider
+	// Namespace in which nodes are deployed, it has to exist along with any
+	// dependency of the provider like cluster's root CA secret.
+	Namespace string
+	// ClusterName of the nodes deployed by the specs.
+	ClusterName string
+	// MakeVmReady makes node's VM get an IP, like a booted VM would. It's left nil
+	// when the infrastructure backing the provider does it on its own.
+	MakeVmReady func(ctx context.Context, namespace, name string)
+	// MakeVmServiceReady makes head node's VM service get an ingress IP. It's left
+	// nil when the infrastructure backing the provider does it on its own.
+	MakeVmServiceReady func(ctx context.Context, namespace, name string)
+	// Timeout bounds waiting for the infrastructure, defaults to 10 seconds.
+	Timeout time.Duration
+}
+
+func newDeploymentRequest(h Harness, vmname string) provider.VmDeploymentRequest {
+	return provider.VmDeploymentRequest{
+		Namespace:   h.Namespace,
+		ClusterName: h.ClusterName,
+		VmName:      vmname,
+		DockerImage: "rayproject/ray:2.9.0",
+		NodeType:    headNodeType,
+		NodeConfig: vmrayv1alpha1.CommonNodeConfig{
+			VMUser:       "rayvm-user",
+			VMImage:      "vmi-00001",
+			StorageClass: "storage-default",
+			NodeTypes: map[string]vmrayv1alpha1.NodeType{
+				headNodeType:   {VMClass: "best-effort-xlarge"},
+				workerNodeType: {VMClass: "best-effort-xsmall"},
+			},
+		},
+		RayClusterRequestor: provider.K8S,
+	}
+}
+
+// ProviderConformanceTests registers specs validating the provider returned by
+// newHarness. newHarness is called before each spec, after the test suite is set up.
+func ProviderConformanceTests(newHarness func() Harness) {
+
+	Describe("VmProvider conformance", func() {
+		var (
+			h       Harness
+			ctx     context.Context
+			timeout time.Duration
+			count   int
+		)
+
+		// Each spec deploys nodes with unique names to not depend on
+		// provider cleaning up after previous specs.
+		uniqueName := func(prefix string) string {
+			count++
+			return fmt.Sprintf("%s-conformance-%d", prefix, count)
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			h = newHarness()
+			timeout = h.Timeout
+			if timeout == 0 {
+				timeout = defaultTimeout
+			}
+		})
+
+		It("DeployVmService returns empty IP while service is created & its IP once assigned", func() {
+			req := newDeploymentRequest(h, "")
+			req.Nounce = uniqueName("svc")
+			headname := provider.GetHeadNodeName(req.ClusterName, req.Nounce)
+
+			ip, err := h.Provider.DeployVmService(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ip).To(BeEmpty())
+
+			if h.MakeVmServiceReady != nil {
+				h.MakeVmServiceReady(ctx, h.Namespace, headname)
+			}
+			Eventually(func() (string, error) {
+				return h.Provider.DeployVmService(ctx, req)
+			}, timeout, 100*time.Millisecond).ShouldNot(BeEmpty())
+
+			Expect(h.Provider.Delete(ctx, h.Namespace, headname)).To(Succeed())
+		})
+
+		It("Deploy records node's config hash & FetchVmStatus reports IP once VM is ready", func() {
+			req := newDeploymentRequest(h, uniqueName("head"))
+			Expect(h.Provider.Deploy(ctx, req)).To(Succeed())
+
+			status, err := h.Provider.FetchVmStatus(ctx, h.Namespace, req.VmName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(status).ToNot(BeNil())
+			Expect(status.ConfigHash).To(Equal(provider.GetNodeConfigHash(req)))
+
+			if h.MakeVmReady != nil {
+				h.MakeVmReady(ctx, h.Namespace, req.VmName)
+			}
+			Eventually(func() (string, error) {
+				status, err := h.Provider.FetchVmStatus(ctx, h.Namespace, req.VmName)
+				if err != nil {
+					return "", err
+				}
+				return status.Ip, nil
+			}, timeout, 100*time.Millisecond).ShouldNot(BeEmpty())
+
+			Expect(h.Provider.Delete(ctx, h.Namespace, req.VmName)).To(Succeed())
+		})
+
+		It("Deploy of an already deployed node succeeds or returns AlreadyExists", func() {
+			req := newDeploymentRequest(h, uniqueName("head"))
+			Expect(h.Provider.Deploy(ctx, req)).To(Succeed())
+
+			err := h.Provider.Deploy(ctx, req)
+			if err != nil {
+				Expect(apierrors.IsAlreadyExists(err)).To(BeTrue(), "unexpected error: %v", err)
+			}
+
+			Expect(h.Provider.Delete(ctx, h.Namespace, req.VmName)).To(Succeed())
+		})
+
+		It("ListClusterVms returns cluster's VMs only, identified by their labels", func() {
+			req := newDeploymentRequest(h, uniqueName("head"))
+			req.Nounce = "abcde"
+			Expect(h.Provider.Deploy(ctx, req)).To(Succeed())
+
+			Eventually(func() (map[string]provider.ClusterVm, error) {
+				return h.Provider.ListClusterVms(ctx, h.Namespace, h.ClusterName)
+			}, timeout, 100*time.Millisecond).Should(HaveKeyWithValue(req.VmName, And(
+				HaveField("Role", provider.NodeRoleHead),
+				HaveField("Nounce", "abcde"),
+				HaveField("Status.NodeType", headNodeType),
+				HaveField("Status.ConfigHash", provider.GetNodeConfigHash(req)))))
+
+			vms, err := h.Provider.ListClusterVms(ctx, h.Namespace, uniqueName("cluster"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vms).To(BeEmpty())
+
+			Expect(h.Provider.Delete(ctx, h.Namespace, req.VmName)).To(Succeed())
+		})
+
+		It("FetchVmStatus returns NotFound for a missing node", func() {
+			_, err := h.Provider.FetchVmStatus(ctx, h.Namespace, uniqueName("missing"))
+			Expect(apierrors.IsNotFound(err)).To(BeTrue(), "unexpected error: %v", err)
+		})
+
+		It("Delete ignores missing nodes & deleted node is eventually NotFound", func() {
+			Expect(h.Provider.Delete(ctx, h.Namespace, uniqueName("missing"))).To(Succeed())
+
+			req := newDeploymentRequest(h, uniqueName("head"))
+			Expect(h.Provider.Deploy(ctx, req)).To(Succeed())
+			Expect(h.Provider.Delete(ctx, h.Namespace, req.VmName)).To(Succeed())
+			Expect(h.Provider.Delete(ctx, h.Namespace, req.VmName)).To(Succeed())
+
+			Eventually(func() bool {
+				_, err := h.Provider.FetchVmStatus(ctx, h.Namespace, req.VmName)
+				return apierrors.IsNotFound(err)
+			}, timeout, 100*time.Millisecond).Should(BeTrue())
+		})
+
+		It("DeleteServeService & DeleteAuxiliaryResources ignore missing resources", func() {
+			Expect(h.Provider.DeleteServeService(ctx, h.Namespace, uniqueName("serve"))).To(Succeed())
+			Expect(h.Provider.DeleteAuxiliaryResources(ctx, h.Namespace, uniqueName("cluster"))).To(Succeed())
+		})
+	})
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+)
+
+const (
+	// ClusterLabel holds name of the cluster a ray node's VM belongs to.
+	ClusterLabel = "vmray.kubernetes.io/cluster"
+	// NodeRoleLabel holds role of the ray node running on the VM.
+	NodeRoleLabel  = "vmray.kubernetes.io/node-role"
+	NodeRoleHead   = "head"
+	NodeRoleWorker = "worker"
+	// NounceLabel holds nounce of the cluster's head node the VM was deployed for.
+	NounceLabel = "vmray.kubernetes.io/nounce"
+	// NodeTypeLabel holds name of the node type the VM was deployed as.
+	NodeTypeLabel = "vmray.kubernetes.io/node-type"
+
+	// ClusterIndexField indexes cached VMs by ClusterLabel, so listing VMs
+	// of a cluster doesn't scan all VMs of the namespace.
+	ClusterIndexField = "vmray.clusterName"
+)
+
+// ClusterVm is a VM listed for a cluster along with identity of its ray node, read
+// from labels the VM was deployed with. Status has node type set from the labels.
+type ClusterVm struct {
+	Role   string
+	Nounce string
+	// Deleting is set once VM's deletion was requested.
+	Deleting bool
+	Status   *vmrayv1alpha1.VMRayNodeStatus
+}
+
+// NewClusterVm identifies ray node of a VM listed for a cluster by the VM's labels.
+func NewClusterVm(vm client.Object, status *vmrayv1alpha1.VMRayNodeStatus) ClusterVm {
+	labels := vm.GetLabels()
+	status.NodeType = labels[NodeTypeLabel]
+	return ClusterVm{
+		Role:     labels[NodeRoleLabel],
+		Nounce:   labels[NounceLabel],
+		Deleting: !vm.GetDeletionTimestamp().IsZero(),
+		Status:   status,
+	}
+}
+
+// GetNodeLabels returns labels identifying cluster, nounce, role & node type of
+// the requested node's VM, letting cluster's status be rebuilt from its VMs.
+func GetNodeLabels(req VmDeploymentRequest) map[string]string {
+	role := NodeRoleWorker
+	if req.HeadNodeStatus == nil {
+		role = NodeRoleHead
+	}
+	return map[string]string{
+		ClusterLabel:  req.ClusterName,
+		NodeRoleLabel: role,
+		NounceLabel:   req.Nounce,
+		NodeTypeLabel: req.NodeType,
+	}
+}
+
+// GetClusterLabels returns labels of objects created for the cluster as a whole,
+// e.g. its secrets, letting orphaned objects be found once the cluster is gone.
+func GetClusterLabels(clustername string) map[string]string {
+	return map[string]string{
+		ClusterLabel: clustername,
+	}
+}
+
+// IndexByCluster is the indexer func of ClusterIndexField.
+func IndexByCluster(obj client.Object) []string {
+	if clustername, ok := obj.GetLabels()[ClusterLabel]; ok {
+		return []string{clustername}
+	}
+	return nil
+}
@@ -7,6 +7,8 @@ import (
 	"context"
 	"errors"
 
+	"k8s.io/apimachinery/pkg/labels"
+
 	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
 	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider"
 )
@@ -16,7 +18,8 @@ Create VmProvider mock with response tracker to be leveraged in unittest.
 
 For each function of provider we have set of functions which mock the said
 implementation of provider with mock tracker to return set Responses &
-capture Requests.
+capture Requests. Mock created with NewSimulatedMockVmProvider serves
+functions without a set Response with a simulated infrastructure instead.
 */
 
 type MockNamedNamespaceRequest struct {
@@ -24,11 +27,47 @@ type MockNamedNamespaceRequest struct {
 	Name      string
 }
 
+type MockServeServiceRequest struct {
+	Namespace  string
+	Name       string
+	HeadVmName string
+}
+
 type mockFetchVmStatusResponse struct {
 	Status *vmrayv1alpha1.VMRayNodeStatus
 	Error  error
 }
 
+type mockListClusterVmsResponse struct {
+	Vms   map[string]provider.ClusterVm
+	Error error
+}
+
+type mockResizeResponse struct {
+	Done  bool
+	Error error
+}
+
+type mockAdoptVmResponse struct {
+	Status *vmrayv1alpha1.VMRayNodeStatus
+	Error  error
+}
+
+type mockFindVmsResponse struct {
+	Names []string
+	Error error
+}
+
+type MockAdoptVmServiceRequest struct {
+	Request provider.VmDeploymentRequest
+	Name    string
+}
+
+type MockFindVmsRequest struct {
+	Namespace string
+	Selector  labels.Selector
+}
+
 type mockDeployVmServiceResponse struct {
 	Ip    string
 	Error error
@@ -54,6 +93,37 @@ type MockVmProvider struct {
 	deployVmServiceFuncResponse  map[int]mockDeployVmServiceResponse
 	deployVmServiceFuncRequest   map[int]provider.VmDeploymentRequest
 	deployVmServiceFuncCallCount int
+
+	deployServeServiceFuncResponse  map[int]mockDeployVmServiceResponse
+	deployServeServiceFuncRequest   map[int]MockServeServiceRequest
+	deployServeServiceFuncCallCount int
+
+	deleteServeServiceFuncResponse  map[int]error
+	deleteServeServiceFuncRequest   map[int]MockNamedNamespaceRequest
+	deleteServeServiceFuncCallCount int
+
+	resizeFuncResponse  map[int]mockResizeResponse
+	resizeFuncRequest   map[int]provider.VmDeploymentRequest
+	resizeFuncCallCount int
+
+	listClusterVmsFuncResponse  map[int]mockListClusterVmsResponse
+	listClusterVmsFuncRequest   map[int]MockNamedNamespaceRequest
+	listClusterVmsFuncCallCount int
+
+	adoptVmFuncResponse  map[int]mockAdoptVmResponse
+	adoptVmFuncRequest   map[int]provider.VmDeploymentRequest
+	adoptVmFuncCallCount int
+
+	adoptVmServiceFuncResponse  map[int]mockDeployVmServiceResponse
+	adoptVmServiceFuncRequest   map[int]MockAdoptVmServiceRequest
+	adoptVmServiceFuncCallCount int
+
+	findVmsFuncResponse  map[int]mockFindVmsResponse
+	findVmsFuncRequest   map[int]MockFindVmsRequest
+	findVmsFuncCallCount int
+
+	// simulation serves calls without a set response, when non-nil.
+	simulation *simulation
 }
 
 func NewMockVmProvider() *MockVmProvider {
@@ -77,6 +147,34 @@ func NewMockVmProvider() *MockVmProvider {
 		deployVmServiceFuncResponse:  make(map[int]mockDeployVmServiceResponse),
 		deployVmServiceFuncRequest:   make(map[int]provider.VmDeploymentRequest),
 		deployVmServiceFuncCallCount: 0,
+
+		deployServeServiceFuncResponse:  make(map[int]mockDeployVmServiceResponse),
+		deployServeServiceFuncRequest:   make(map[int]MockServeServiceRequest),
+		deployServeServiceFuncCallCount: 0,
+
+		deleteServeServiceFuncResponse:  make(map[int]error),
+		deleteServeServiceFuncRequest:   make(map[int]MockNamedNamespaceRequest),
+		deleteServeServiceFuncCallCount: 0,
+
+		resizeFuncResponse:  make(map[int]mockResizeResponse),
+		resizeFuncRequest:   make(map[int]provider.VmDeploymentRequest),
+		resizeFuncCallCount: 0,
+
+		listClusterVmsFuncResponse:  make(map[int]mockListClusterVmsResponse),
+		listClusterVmsFuncRequest:   make(map[int]MockNamedNamespaceRequest),
+		listClusterVmsFuncCallCount: 0,
+
+		adoptVmFuncResponse:  make(map[int]mockAdoptVmResponse),
+		adoptVmFuncRequest:   make(map[int]provider.VmDeploymentRequest),
+		adoptVmFuncCallCount: 0,
+
+		adoptVmServiceFuncResponse:  make(map[int]mockDeployVmServiceResponse),
+		adoptVmServiceFuncRequest:   make(map[int]MockAdoptVmServiceRequest),
+		adoptVmServiceFuncCallCount: 0,
+
+		findVmsFuncResponse:  make(map[int]mockFindVmsResponse),
+		findVmsFuncRequest:   make(map[int]MockFindVmsRequest),
+		findVmsFuncCallCount: 0,
 	}
 }
 
@@ -88,6 +186,9 @@ func (mvp *MockVmProvider) Deploy(ctx context.Context, req provider.VmDeployment
 	if err, ok := mvp.deployFuncResponse[mvp.deployFuncCallCount]; ok {
 		return err
 	}
+	if mvp.simulation != nil {
+		return mvp.simulation.deploy(req)
+	}
 	return errors.New("no response set for function `Deploy`")
 }
 
@@ -110,6 +211,9 @@ func (mvp *MockVmProvider) Delete(ctx context.Context, namespace string, name st
 	if err, ok := mvp.deleteFuncResponse[mvp.deleteFuncCallCount]; ok {
 		return err
 	}
+	if mvp.simulation != nil {
+		return mvp.simulation.delete(namespace, name)
+	}
 	return errors.New("no response set for function `Delete`")
 }
 
@@ -133,6 +237,9 @@ func (mvp *MockVmProvider) FetchVmStatus(ctx context.Context,
 	if resp, ok := mvp.fetchVmStatusFuncResponse[mvp.fetchVmStatusFuncCallCount]; ok {
 		return resp.Status, resp.Error
 	}
+	if mvp.simulation != nil {
+		return mvp.simulation.fetchVmStatus(namespace, name)
+	}
 	return nil, errors.New("no response set for function `FetchVmStatus`")
 }
 
@@ -158,6 +265,9 @@ func (mvp *MockVmProvider) DeleteAuxiliaryResources(ctx context.Context, namespa
 	if err, ok := mvp.deleteAuxiliaryResourcesFuncResponse[mvp.deleteAuxiliaryResourcesFuncCallCount]; ok {
 		return err
 	}
+	if mvp.simulation != nil {
+		return nil
+	}
 	return errors.New("no response set for function `DeleteAuxiliaryResources`")
 }
 
@@ -177,6 +287,9 @@ func (mvp *MockVmProvider) DeployVmService(ctx context.Context, req provider.VmD
 	if resp, ok := mvp.deployVmServiceFuncResponse[mvp.deployVmServiceFuncCallCount]; ok {
 		return resp.Ip, resp.Error
 	}
+	if mvp.simulation != nil {
+		return mvp.simulation.deployVmService(req)
+	}
 	return "", errors.New("no response set for function `DeployVmService`")
 }
 
@@ -191,3 +304,196 @@ func (mvp *MockVmProvider) DeployVmServiceGetRequest(callcount int) (string, err
 	resp := mvp.deployVmServiceFuncResponse[callcount]
 	return resp.Ip, resp.Error
 }
+
+// Mock tracker & implmenetation for `DeployServeService` function.
+func (mvp *MockVmProvider) DeployServeService(ctx context.Context, namespace, name, headVmName string) (string, error) {
+	mvp.deployServeServiceFuncCallCount = mvp.deployServeServiceFuncCallCount + 1
+
+	mvp.deployServeServiceFuncRequest[mvp.deployServeServiceFuncCallCount] = MockServeServiceRequest{
+		Namespace:  namespace,
+		Name:       name,
+		HeadVmName: headVmName,
+	}
+	if resp, ok := mvp.deployServeServiceFuncResponse[mvp.deployServeServiceFuncCallCount]; ok {
+		return resp.Ip, resp.Error
+	}
+	if mvp.simulation != nil {
+		return mvp.simulation.deployService(namespace, name, nil)
+	}
+	return "", errors.New("no response set for function `DeployServeService`")
+}
+
+func (mvp *MockVmProvider) DeployServeServiceSetResponse(callcount int, ip string, err error) {
+	mvp.deployServeServiceFuncResponse[callcount] = mockDeployVmServiceResponse{
+		Ip:    ip,
+		Error: err,
+	}
+}
+
+func (mvp *MockVmProvider) DeployServeServiceGetRequest(callcount int) MockServeServiceRequest {
+	return mvp.deployServeServiceFuncRequest[callcount]
+}
+
+// Mock tracker & implmenetation for `DeleteServeService` function.
+func (mvp *MockVmProvider) DeleteServeService(ctx context.Context, namespace, name string) error {
+	mvp.deleteServeServiceFuncCallCount = mvp.deleteServeServiceFuncCallCount + 1
+
+	mvp.deleteServeServiceFuncRequest[mvp.deleteServeServiceFuncCallCount] = MockNamedNamespaceRequest{
+		Namespace: namespace,
+		Name:      name,
+	}
+	if err, ok := mvp.deleteServeServiceFuncResponse[mvp.deleteServeServiceFuncCallCount]; ok {
+		return err
+	}
+	if mvp.simulation != nil {
+		return mvp.simulation.deleteService(namespace, name)
+	}
+	return errors.New("no response set for function `DeleteServeService`")
+}
+
+func (mvp *MockVmProvider) DeleteServeServiceSetResponse(callcount int, err error) {
+	mvp.deleteServeServiceFuncResponse[callcount] = err
+}
+
+func (mvp *MockVmProvider) DeleteServeServiceGetRequest(callcount int) MockNamedNamespaceRequest {
+	return mvp.deleteServeServiceFuncRequest[callcount]
+}
+
+// Mock tracker & implmenetation for `Resize` function.
+func (mvp *MockVmProvider) Resize(ctx context.Context, req provider.VmDeploymentRequest) (bool, error) {
+	mvp.resizeFuncCallCount = mvp.resizeFuncCallCount + 1
+
+	mvp.resizeFuncRequest[mvp.resizeFuncCallCount] = req
+	if resp, ok := mvp.resizeFuncResponse[mvp.resizeFuncCallCount]; ok {
+		return resp.Done, resp.Error
+	}
+	if mvp.simulation != nil {
+		return mvp.simulation.resize(req)
+	}
+	return false, errors.New("no response set for function `Resize`")
+}
+
+func (mvp *MockVmProvider) ResizeSetResponse(callcount int, done bool, err error) {
+	mvp.resizeFuncResponse[callcount] = mockResizeResponse{
+		Done:  done,
+		Error: err,
+	}
+}
+
+func (mvp *MockVmProvider) ResizeGetRequest(callcount int) provider.VmDeploymentRequest {
+	return mvp.resizeFuncRequest[callcount]
+}
+
+// Mock tracker & implmenetation for `ListClusterVms` function.
+func (mvp *MockVmProvider) ListClusterVms(ctx context.Context,
+	namespace, clustername string) (map[string]provider.ClusterVm, error) {
+	mvp.listClusterVmsFuncCallCount = mvp.listClusterVmsFuncCallCount + 1
+
+	mvp.listClusterVmsFuncRequest[mvp.listClusterVmsFuncCallCount] = MockNamedNamespaceRequest{
+		Namespace: namespace,
+		Name:      clustername,
+	}
+	if resp, ok := mvp.listClusterVmsFuncResponse[mvp.listClusterVmsFuncCallCount]; ok {
+		return resp.Vms, resp.Error
+	}
+	if mvp.simulation != nil {
+		return mvp.simulation.listClusterVms(namespace, clustername)
+	}
+	return nil, errors.New("no response set for function `ListClusterVms`")
+}
+
+func (mvp *MockVmProvider) ListClusterVmsSetResponse(callcount int,
+	vms map[string]provider.ClusterVm, err error) {
+	mvp.listClusterVmsFuncResponse[callcount] = mockListClusterVmsResponse{
+		Vms:   vms,
+		Error: err,
+	}
+}
+
+func (mvp *MockVmProvider) ListClusterVmsGetRequest(callcount int) MockNamedNamespaceRequest {
+	return mvp.listClusterVmsFuncRequest[callcount]
+}
+
+// Mock tracker & implmenetation for `AdoptVm` function.
+func (mvp *MockVmProvider) AdoptVm(ctx context.Context,
+	req provider.VmDeploymentRequest) (*vmrayv1alpha1.VMRayNodeStatus, error) {
+	mvp.adoptVmFuncCallCount = mvp.adoptVmFuncCallCount + 1
+
+	mvp.adoptVmFuncRequest[mvp.adoptVmFuncCallCount] = req
+	if resp, ok := mvp.adoptVmFuncResponse[mvp.adoptVmFuncCallCount]; ok {
+		return resp.Status, resp.Error
+	}
+	if mvp.simulation != nil {
+		return mvp.simulation.adoptVm(req)
+	}
+	return nil, errors.New("no response set for function `AdoptVm`")
+}
+
+func (mvp *MockVmProvider) AdoptVmSetResponse(callcount int, status *vmrayv1alpha1.VMRayNodeStatus, err error) {
+	mvp.adoptVmFuncResponse[callcount] = mockAdoptVmResponse{
+		Status: status,
+		Error:  err,
+	}
+}
+
+func (mvp *MockVmProvider) AdoptVmGetRequest(callcount int) provider.VmDeploymentRequest {
+	return mvp.adoptVmFuncRequest[callcount]
+}
+
+// Mock tracker & implmenetation for `AdoptVmService` function.
+func (mvp *MockVmProvider) AdoptVmService(ctx context.Context,
+	req provider.VmDeploymentRequest, name string) (string, error) {
+	mvp.adoptVmServiceFuncCallCount = mvp.adoptVmServiceFuncCallCount + 1
+
+	mvp.adoptVmServiceFuncRequest[mvp.adoptVmServiceFuncCallCount] = MockAdoptVmServiceRequest{
+		Request: req,
+		Name:    name,
+	}
+	if resp, ok := mvp.adoptVmServiceFuncResponse[mvp.adoptVmServiceFuncCallCount]; ok {
+		return resp.Ip, resp.Error
+	}
+	if mvp.simulation != nil {
+		return mvp.simulation.adoptVmService(req.Namespace, name)
+	}
+	return "", errors.New("no response set for function `AdoptVmService`")
+}
+
+func (mvp *MockVmProvider) AdoptVmServiceSetResponse(callcount int, ip string, err error) {
+	mvp.adoptVmServiceFuncResponse[callcount] = mockDeployVmServiceResponse{
+		Ip:    ip,
+		Error: err,
+	}
+}
+
+func (mvp *MockVmProvider) AdoptVmServiceGetRequest(callcount int) MockAdoptVmServiceRequest {
+	return mvp.adoptVmServiceFuncRequest[callcount]
+}
+
+// Mock tracker & implmenetation for `FindVms` function.
+func (mvp *MockVmProvider) FindVms(ctx context.Context,
+	namespace string, selector labels.Selector) ([]string, error) {
+	mvp.findVmsFuncCallCount = mvp.findVmsFuncCallCount + 1
+
+	mvp.findVmsFuncRequest[mvp.findVmsFuncCallCount] = MockFindVmsRequest{
+		Namespace: namespace,
+		Selector:  selector,
+	}
+	if resp, ok := mvp.findVmsFuncResponse[mvp.findVmsFuncCallCount]; ok {
+		return resp.Names, resp.Error
+	}
+	if mvp.simulation != nil {
+		return mvp.simulation.findVms(namespace, selector)
+	}
+	return nil, errors.New("no response set for function `FindVms`")
+}
+
+func (mvp *MockVmProvider) FindVmsSetResponse(callcount int, names []string, err error) {
+	mvp.findVmsFuncResponse[callcount] = mockFindVmsResponse{
+		Names: names,
+		Error: err,
+	}
+}
+
+func (mvp *MockVmProvider) FindVmsGetRequest(callcount int) MockFindVmsRequest {
+	return mvp.findVmsFuncRequest[callcount]
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package mock_test
+
+import (
+	"context"
+	"testing"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/conformance"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/mock"
+)
+
+func TestMockVmProvider(t *testing.T) {
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	conformance.ProviderConformanceTests(func() conformance.Harness {
+		provider := mock.NewSimulatedMockVmProvider()
+		return conformance.Harness{
+			Provider:    provider,
+			Namespace:   "namespace-test",
+			ClusterName: "cluster-name",
+			MakeVmReady: func(ctx context.Context, namespace, name string) {
+				provider.SetVmIp(namespace, name, "10.10.10.10")
+			},
+			MakeVmServiceReady: func(ctx context.Context, namespace, name string) {
+				provider.SetVmServiceIp(namespace, name, "192.10.10.1")
+			},
+		}
+	})
+
+	ginkgo.RunSpecs(t, "Conformance testcases to validate simulated mock Vm provider")
+}
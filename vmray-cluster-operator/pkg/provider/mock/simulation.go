@@ -0,0 +1,263 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package mock
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider"
+)
+
+/*
+Simulation backs calls of the mock which have no response set with an in-memory
+state of deployed VMs & VM services. It follows the semantics of real providers,
+validated by the provider conformance suite, e.g. deploying an existing VM returns
+AlreadyExists & fetching status of a missing VM returns NotFound.
+*/
+
+var vmGroupResource = schema.GroupResource{Group: "vmoperator.vmware.com", Resource: "virtualmachines"}
+
+// NewNotFoundError returns error real providers return for a missing VM.
+func NewNotFoundError(name string) error {
+	return k8serrors.NewNotFound(vmGroupResource, name)
+}
+
+// NewAlreadyExistsError returns error real providers return when deploying an existing VM.
+func NewAlreadyExistsError(name string) error {
+	return k8serrors.NewAlreadyExists(vmGroupResource, name)
+}
+
+type simulation struct {
+	mu       sync.Mutex
+	vms      map[types.NamespacedName]*vmrayv1alpha1.VMRayNodeStatus
+	labels   map[types.NamespacedName]map[string]string
+	images   map[types.NamespacedName]string
+	services map[types.NamespacedName]string
+}
+
+func newSimulation() *simulation {
+	return &simulation{
+		vms:      make(map[types.NamespacedName]*vmrayv1alpha1.VMRayNodeStatus),
+		labels:   make(map[types.NamespacedName]map[string]string),
+		images:   make(map[types.NamespacedName]string),
+		services: make(map[types.NamespacedName]string),
+	}
+}
+
+// NewSimulatedMockVmProvider creates VmProvider mock whose calls without a set
+// response are served by a simulated infrastructure instead of failing.
+func NewSimulatedMockVmProvider() *MockVmProvider {
+	mvp := NewMockVmProvider()
+	mvp.simulation = newSimulation()
+	return mvp
+}
+
+// SetVmIp simulates VM getting an IP once booted.
+func (mvp *MockVmProvider) SetVmIp(namespace, name, ip string) {
+	s := mvp.simulation
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if status, ok := s.vms[types.NamespacedName{Namespace: namespace, Name: name}]; ok {
+		status.Ip = ip
+	}
+}
+
+// SetVmServiceIp simulates VM service getting its ingress IP.
+func (mvp *MockVmProvider) SetVmServiceIp(namespace, name, ip string) {
+	s := mvp.simulation
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := types.NamespacedName{Namespace: namespace, Name: name}
+	if _, ok := s.services[key]; ok {
+		s.services[key] = ip
+	}
+}
+
+// AddExistingVm simulates a VM deployed outside of the operator, e.g. by `ray up`.
+func (mvp *MockVmProvider) AddExistingVm(namespace, name, vmclass, image, ip string, vmlabels map[string]string) {
+	s := mvp.simulation
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := types.NamespacedName{Namespace: namespace, Name: name}
+	s.vms[key] = &vmrayv1alpha1.VMRayNodeStatus{Ip: ip, VMClass: vmclass}
+	s.labels[key] = vmlabels
+	s.images[key] = image
+}
+
+// AddExistingVmService simulates a VM service deployed outside of the operator.
+func (mvp *MockVmProvider) AddExistingVmService(namespace, name, ip string) {
+	s := mvp.simulation
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.services[types.NamespacedName{Namespace: namespace, Name: name}] = ip
+}
+
+func (s *simulation) deploy(req provider.VmDeploymentRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := types.NamespacedName{Namespace: req.Namespace, Name: req.VmName}
+	if _, ok := s.vms[key]; ok {
+		return NewAlreadyExistsError(req.VmName)
+	}
+	s.vms[key] = &vmrayv1alpha1.VMRayNodeStatus{
+		ConfigHash: provider.GetNodeConfigHash(req),
+		VMClass:    req.NodeConfig.NodeTypes[req.NodeType].VMClass,
+	}
+	s.labels[key] = provider.GetNodeLabels(req)
+	s.images[key] = req.NodeConfig.VMImage
+	return nil
+}
+
+func (s *simulation) resize(req provider.VmDeploymentRequest) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status, ok := s.vms[types.NamespacedName{Namespace: req.Namespace, Name: req.VmName}]
+	if !ok {
+		return false, NewNotFoundError(req.VmName)
+	}
+	status.VMClass = req.NodeConfig.NodeTypes[req.NodeType].VMClass
+	status.ConfigHash = provider.GetNodeConfigHash(req)
+	return true, nil
+}
+
+func (s *simulation) delete(namespace, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Head node's VM service shares its name, like with real providers.
+	delete(s.vms, types.NamespacedName{Namespace: namespace, Name: name})
+	delete(s.labels, types.NamespacedName{Namespace: namespace, Name: name})
+	delete(s.images, types.NamespacedName{Namespace: namespace, Name: name})
+	delete(s.services, types.NamespacedName{Namespace: namespace, Name: name})
+	return nil
+}
+
+func (s *simulation) fetchVmStatus(namespace, name string) (*vmrayv1alpha1.VMRayNodeStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status, ok := s.vms[types.NamespacedName{Namespace: namespace, Name: name}]
+	if !ok {
+		return nil, NewNotFoundError(name)
+	}
+	return status.DeepCopy(), nil
+}
+
+func (s *simulation) listClusterVms(namespace, clustername string) (map[string]provider.ClusterVm, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clusterVms := make(map[string]provider.ClusterVm)
+	for key, labels := range s.labels {
+		if key.Namespace == namespace && labels[provider.ClusterLabel] == clustername {
+			status := s.vms[key].DeepCopy()
+			status.NodeType = labels[provider.NodeTypeLabel]
+			clusterVms[key.Name] = provider.ClusterVm{
+				Role:   labels[provider.NodeRoleLabel],
+				Nounce: labels[provider.NounceLabel],
+				Status: status,
+			}
+		}
+	}
+	return clusterVms, nil
+}
+
+func (s *simulation) adoptVm(req provider.VmDeploymentRequest) (*vmrayv1alpha1.VMRayNodeStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := types.NamespacedName{Namespace: req.Namespace, Name: req.VmName}
+	status, ok := s.vms[key]
+	if !ok {
+		return nil, NewNotFoundError(req.VmName)
+	}
+	if s.images[key] != req.NodeConfig.VMImage {
+		return nil, fmt.Errorf("%w: VM %s has image %s", provider.ErrAdoptionMismatch, req.VmName, s.images[key])
+	}
+	if req.NodeType == "" {
+		names := []string{}
+		for name, nt := range req.NodeConfig.NodeTypes {
+			if nt.VMClass == status.VMClass && name != req.HeadNodeConfig.NodeType {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		if len(names) > 0 {
+			req.NodeType = names[0]
+		}
+	}
+	if nt, ok := req.NodeConfig.NodeTypes[req.NodeType]; !ok || nt.VMClass != status.VMClass {
+		return nil, fmt.Errorf("%w: VM %s has VM class %s", provider.ErrAdoptionMismatch, req.VmName, status.VMClass)
+	}
+
+	vmlabels := provider.GetNodeLabels(req)
+	for k, v := range s.labels[key] {
+		if _, ok := vmlabels[k]; !ok {
+			vmlabels[k] = v
+		}
+	}
+	s.labels[key] = vmlabels
+	status.ConfigHash = provider.AdoptedConfigHash
+	adopted := status.DeepCopy()
+	adopted.NodeType = req.NodeType
+	return adopted, nil
+}
+
+func (s *simulation) adoptVmService(namespace, name string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ip, ok := s.services[types.NamespacedName{Namespace: namespace, Name: name}]
+	if !ok {
+		return "", k8serrors.NewNotFound(schema.GroupResource{Group: vmGroupResource.Group,
+			Resource: "virtualmachineservices"}, name)
+	}
+	return ip, nil
+}
+
+func (s *simulation) findVms(namespace string, selector labels.Selector) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := []string{}
+	for key, vmlabels := range s.labels {
+		if _, ok := vmlabels[provider.ClusterLabel]; key.Namespace == namespace && !ok &&
+			selector.Matches(labels.Set(vmlabels)) {
+			names = append(names, key.Name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *simulation) deployService(namespace, name string, pendingErr error) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := types.NamespacedName{Namespace: namespace, Name: name}
+	ip, ok := s.services[key]
+	if !ok {
+		// Service is being created, its IP isn't assigned yet.
+		s.services[key] = ""
+		return "", nil
+	}
+	if ip == "" {
+		return "", pendingErr
+	}
+	return ip, nil
+}
+
+func (s *simulation) deployVmService(req provider.VmDeploymentRequest) (string, error) {
+	headvmname := provider.GetHeadNodeName(req.ClusterName, req.Nounce)
+	return s.deployService(req.Namespace, headvmname, errors.New("Head node VM service IP is not assigned"))
+}
+
+func (s *simulation) deleteService(namespace, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.services, types.NamespacedName{Namespace: namespace, Name: name})
+	return nil
+}
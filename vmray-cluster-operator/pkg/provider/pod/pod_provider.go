@@ -0,0 +1,404 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package pod
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/vmop"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/vmop/cloudinit"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/vmop/tls"
+)
+
+const (
+	ClusterLabel            = provider.ClusterLabel
+	NodeLabel               = "vmray.kubernetes.io/node"
+	ConfigSecretSuffix      = "-pod-config"
+	RayContainerName        = "ray"
+	genCertScriptKey        = "gencert.sh"
+	configVolumeName        = "ray-config"
+	rayHomeDir              = "/home/ray/"
+	errResizeNotSupported   = "resizing ray nodes in place isn't supported by pod provider"
+	errAdoptNotSupported    = "adopting existing VMs isn't supported by pod provider"
+	errServiceIPNotAssigned = "Head node service IP is not assigned"
+)
+
+// +kubebuilder:rbac:groups="",resources=pods;services;secrets,verbs=get;list;watch;create;update;patch;delete
+
+// PodProvider deploys ray nodes as plain pods running the ray image, with services
+// standing in for VM services. It lets the operator run on any kubernetes cluster,
+// e.g. kind, for development & CI. Ray is started without ray autoscaler as it
+// manages workers over ssh, so workers are only deployed as desired in the spec.
+type PodProvider struct {
+	kubeClient client.Client
+	log        logr.Logger
+	// indexed is set once pods are indexed by cluster in client's cache.
+	indexed bool
+}
+
+func NewPodProvider(kubeClient client.Client) *PodProvider {
+	return &PodProvider{
+		kubeClient: kubeClient,
+		log:        ctrl.Log.WithName("PodProvider"),
+	}
+}
+
+// ManagedObjectLists returns lists of the kinds of objects the provider labels with
+// their cluster, letting objects orphaned by deleted clusters be garbage collected.
+func (podprovider *PodProvider) ManagedObjectLists() []client.ObjectList {
+	return []client.ObjectList{
+		&corev1.PodList{},
+		&corev1.ServiceList{},
+		&corev1.SecretList{},
+	}
+}
+
+// SetupFieldIndexer indexes pods by their cluster in manager's cache, letting
+// ListClusterVms list pods of a cluster by the index instead of a label selector.
+func (podprovider *PodProvider) SetupFieldIndexer(ctx context.Context, indexer client.FieldIndexer) error {
+	if err := indexer.IndexField(ctx, &corev1.Pod{}, provider.ClusterIndexField, provider.IndexByCluster); err != nil {
+		return err
+	}
+	podprovider.indexed = true
+	return nil
+}
+
+func (podprovider *PodProvider) Deploy(ctx context.Context, req provider.VmDeploymentRequest) error {
+
+	labels := provider.GetNodeLabels(req)
+	labels[NodeLabel] = req.VmName
+	env := cloudinit.GetRayEnv(req.EnableTLS)
+	if req.HeadNodeStatus == nil {
+		// Selector of head node's service.
+		labels[vmop.HeadVMServiceAnnotation] = req.VmName
+		env = append(env, corev1.EnvVar{Name: "RAY_VMSERVICE_IP", Value: req.VmService})
+	} else {
+		env = append(env, corev1.EnvVar{Name: "RAY_HEAD_IP", Value: req.HeadNodeStatus.Ip})
+	}
+
+	// Step 1: Create secret holding TLS certificates & script shared by all pods of the cluster.
+	secretName, err := podprovider.createConfigSecret(ctx, req)
+	if err != nil {
+		podprovider.log.Error(err, "Failed to create pod config secret")
+		return err
+	}
+
+	// Step 2: Create pod running the same ray start commands as VM's ray container.
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      req.VmName,
+			Namespace: req.Namespace,
+			Labels:    labels,
+			Annotations: map[string]string{
+				provider.ConfigHashAnnotation: provider.GetNodeConfigHash(req),
+			},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyAlways,
+			Containers: []corev1.Container{
+				{
+					Name:         RayContainerName,
+					Image:        req.DockerImage,
+					Command:      []string{"/bin/bash", "-c", strings.Join(cloudinit.GetRayStartCommands(req), ";")},
+					Env:          env,
+					VolumeMounts: getConfigVolumeMounts(),
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name: configVolumeName,
+					VolumeSource: corev1.VolumeSource{
+						Secret: &corev1.SecretVolumeSource{SecretName: secretName},
+					},
+				},
+			},
+		},
+	}
+	return podprovider.kubeClient.Create(ctx, pod)
+}
+
+func getConfigVolumeMounts() []corev1.VolumeMount {
+	mounts := []corev1.VolumeMount{}
+	for _, key := range []string{cloudinit.Ca_cert_file, cloudinit.Ca_key_file, genCertScriptKey} {
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      configVolumeName,
+			MountPath: rayHomeDir + key,
+			SubPath:   key,
+			ReadOnly:  true,
+		})
+	}
+	return mounts
+}
+
+// createConfigSecret checks if cluster's pod config secret exists, otherwise creates it.
+func (podprovider *PodProvider) createConfigSecret(ctx context.Context, req provider.VmDeploymentRequest) (string, error) {
+	key := client.ObjectKey{
+		Namespace: req.Namespace,
+		Name:      req.ClusterName + ConfigSecretSuffix,
+	}
+	secret := &corev1.Secret{}
+	if err := podprovider.kubeClient.Get(ctx, key, secret); err == nil {
+		return key.Name, nil
+	} else if client.IgnoreNotFound(err) != nil {
+		return "", err
+	}
+
+	caKey, caCrt, err := tls.ReadCaCrtAndCaKeyFromSecret(ctx, podprovider.kubeClient,
+		req.Namespace, req.ClusterName+tls.RootCaSecretSuffix)
+	if err != nil {
+		return "", err
+	}
+	secret = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+			Labels:    provider.GetClusterLabels(req.ClusterName),
+		},
+		Type: corev1.SecretTypeOpaque,
+		StringData: map[string]string{
+			cloudinit.Ca_cert_file: caCrt,
+			cloudinit.Ca_key_file:  caKey,
+			genCertScriptKey:       tls.GetRayTLSConfigString(),
+		},
+	}
+	if err := podprovider.kubeClient.Create(ctx, secret); client.IgnoreAlreadyExists(err) != nil {
+		return "", err
+	}
+	return key.Name, nil
+}
+
+// Resize isn't supported, pods are replaced instead when their configuration changes.
+func (podprovider *PodProvider) Resize(ctx context.Context, req provider.VmDeploymentRequest) (bool, error) {
+	return false, errors.New(errResizeNotSupported)
+}
+
+// AdoptVm isn't supported, there are no pods deployed by `ray up` to take over.
+func (podprovider *PodProvider) AdoptVm(ctx context.Context,
+	req provider.VmDeploymentRequest) (*vmrayv1alpha1.VMRayNodeStatus, error) {
+	return nil, errors.New(errAdoptNotSupported)
+}
+
+func (podprovider *PodProvider) AdoptVmService(ctx context.Context,
+	req provider.VmDeploymentRequest, name string) (string, error) {
+	return "", errors.New(errAdoptNotSupported)
+}
+
+func (podprovider *PodProvider) FindVms(ctx context.Context,
+	namespace string, selector labels.Selector) ([]string, error) {
+	return nil, errors.New(errAdoptNotSupported)
+}
+
+func (podprovider *PodProvider) DeleteAuxiliaryResources(ctx context.Context,
+	namespace, clusterName string) error {
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      clusterName + ConfigSecretSuffix,
+			Namespace: namespace,
+		},
+	}
+	return client.IgnoreNotFound(podprovider.kubeClient.Delete(ctx, secret))
+}
+
+func (podprovider *PodProvider) Delete(ctx context.Context, namespace string, name string) error {
+
+	// step 1: Delete head node's service, workers don't have one.
+	if err := podprovider.DeleteServeService(ctx, namespace, name); err != nil {
+		return err
+	}
+
+	// step 2: Delete the pod, a missing pod is assumed to be deleted already.
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	return client.IgnoreNotFound(podprovider.kubeClient.Delete(ctx, pod))
+}
+
+func (podprovider *PodProvider) FetchVmStatus(ctx context.Context,
+	namespace string, name string) (*vmrayv1alpha1.VMRayNodeStatus, error) {
+
+	pod := &corev1.Pod{}
+	key := client.ObjectKey{
+		Namespace: namespace,
+		Name:      name,
+	}
+	if err := podprovider.kubeClient.Get(ctx, key, pod); err != nil {
+		return nil, err
+	}
+
+	return extractPodStatus(pod), nil
+}
+
+func (podprovider *PodProvider) ListClusterVms(ctx context.Context,
+	namespace, clustername string) (map[string]provider.ClusterVm, error) {
+
+	opts := []client.ListOption{client.InNamespace(namespace)}
+	if podprovider.indexed {
+		opts = append(opts, client.MatchingFields{provider.ClusterIndexField: clustername})
+	} else {
+		opts = append(opts, client.MatchingLabels{provider.ClusterLabel: clustername})
+	}
+	pods := &corev1.PodList{}
+	if err := podprovider.kubeClient.List(ctx, pods, opts...); err != nil {
+		return nil, err
+	}
+
+	clusterVms := make(map[string]provider.ClusterVm, len(pods.Items))
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		clusterVms[pod.ObjectMeta.Name] = provider.NewClusterVm(pod, extractPodStatus(pod))
+	}
+	return clusterVms, nil
+}
+
+func extractPodStatus(pod *corev1.Pod) *vmrayv1alpha1.VMRayNodeStatus {
+	// Pod's IP is reported only once its ray container is running,
+	// like VM's IP is reported once the VM is powered on.
+	var ip string
+	if pod.Status.Phase == corev1.PodRunning {
+		ip = pod.Status.PodIP
+	}
+	return &vmrayv1alpha1.VMRayNodeStatus{
+		Ip:         ip,
+		ConfigHash: pod.ObjectMeta.Annotations[provider.ConfigHashAnnotation],
+	}
+}
+
+func createService(ctx context.Context, kubeclient client.Client, namespace, name string,
+	ports map[string]int32, selector, labels map[string]string) error {
+
+	serviceports := []corev1.ServicePort{}
+	for n, p := range ports {
+		serviceports = append(serviceports, corev1.ServicePort{
+			Name:       n,
+			Protocol:   corev1.ProtocolTCP,
+			Port:       p,
+			TargetPort: intstr.FromInt32(p),
+		})
+	}
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: selector,
+			Ports:    serviceports,
+			Type:     corev1.ServiceTypeClusterIP,
+		},
+	}
+	return kubeclient.Create(ctx, service)
+}
+
+func (podprovider *PodProvider) DeployVmService(ctx context.Context,
+	req provider.VmDeploymentRequest) (string, error) {
+
+	headname := provider.GetHeadNodeName(req.ClusterName, req.Nounce)
+	key := client.ObjectKey{
+		Namespace: req.Namespace,
+		Name:      headname,
+	}
+
+	// Check if service exists and fetch its cluster IP.
+	service := &corev1.Service{}
+	if err := podprovider.kubeClient.Get(ctx, key, service); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return "", err
+		}
+
+		port := cloudinit.RayHeadDefaultPort
+		if req.HeadNodeConfig.Port != nil {
+			port = int32(*req.HeadNodeConfig.Port)
+		}
+		ports := map[string]int32{
+			vmop.RayHeadDefaultPortName: port,
+			vmop.RayDashboardPortName:   vmop.RayDashboardPort,
+			vmop.RayClientPortName:      vmop.RayClientPort,
+			vmop.RayServePortName:       vmop.RayServePort,
+		}
+		selector := map[string]string{
+			vmop.HeadVMServiceAnnotation: headname,
+		}
+		labels := provider.GetClusterLabels(req.ClusterName)
+		labels[provider.NounceLabel] = req.Nounce
+		if err := createService(ctx, podprovider.kubeClient, req.Namespace, headname, ports, selector, labels); err != nil {
+			podprovider.log.Error(err, "Failed to create head node service")
+			return "", err
+		}
+		return "", nil
+	}
+
+	if service.Spec.ClusterIP != "" && service.Spec.ClusterIP != corev1.ClusterIPNone {
+		return service.Spec.ClusterIP, nil
+	}
+	podprovider.log.Info("Service IP is not assigned for ray head node", "pod", headname)
+	return "", errors.New(errServiceIPNotAssigned)
+}
+
+func (podprovider *PodProvider) DeployServeService(ctx context.Context,
+	namespace, name, headVmName string) (string, error) {
+
+	selector := map[string]string{
+		vmop.HeadVMServiceAnnotation: headVmName,
+	}
+	key := client.ObjectKey{
+		Namespace: namespace,
+		Name:      name,
+	}
+
+	// Create the serve service if it doesn't exist.
+	service := &corev1.Service{}
+	if err := podprovider.kubeClient.Get(ctx, key, service); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return "", err
+		}
+		ports := map[string]int32{
+			vmop.RayServePortName: vmop.RayServePort,
+		}
+		if err := createService(ctx, podprovider.kubeClient, namespace, name, ports, selector, nil); err != nil {
+			podprovider.log.Error(err, "Failed to create serve service")
+			return "", err
+		}
+		return "", nil
+	}
+
+	// Point the service to the provided head node.
+	if !reflect.DeepEqual(service.Spec.Selector, selector) {
+		podprovider.log.Info("Switching serve service", "service", name, "head pod", headVmName)
+		patch := client.MergeFrom(service.DeepCopy())
+		service.Spec.Selector = selector
+		if err := podprovider.kubeClient.Patch(ctx, service, patch); err != nil {
+			return "", err
+		}
+	}
+	return service.Spec.ClusterIP, nil
+}
+
+func (podprovider *PodProvider) DeleteServeService(ctx context.Context, namespace, name string) error {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	return client.IgnoreNotFound(podprovider.kubeClient.Delete(ctx, service))
+}
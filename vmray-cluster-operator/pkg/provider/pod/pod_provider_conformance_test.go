@@ -0,0 +1,50 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package pod_test
+
+import (
+	"context"
+
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/conformance"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/pod"
+	tls_utils "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/vmop/tls"
+)
+
+const (
+	conformanceNamespace   = "namespace-pod-conformance"
+	conformanceClusterName = "conformance-cluster"
+)
+
+// PodProviderConformanceTests runs provider conformance suite against pod provider.
+// envtest doesn't run kubelet, so pod's status is patched. Service's cluster IP is
+// assigned by kube-apiserver.
+func PodProviderConformanceTests() {
+	conformance.ProviderConformanceTests(func() conformance.Harness {
+		ctx := context.Background()
+		k8sClient := suite.GetK8sClient()
+
+		nsSpec := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: conformanceNamespace}}
+		Expect(client.IgnoreAlreadyExists(k8sClient.Create(ctx, nsSpec))).To(Succeed())
+		Expect(tls_utils.CreateVMRayClusterRootSecret(ctx, k8sClient, conformanceNamespace, conformanceClusterName)).To(Succeed())
+
+		return conformance.Harness{
+			Provider:    pod.NewPodProvider(k8sClient),
+			Namespace:   conformanceNamespace,
+			ClusterName: conformanceClusterName,
+			MakeVmReady: func(ctx context.Context, namespace, name string) {
+				p := &corev1.Pod{}
+				Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, p)).To(Succeed())
+				p.Status.Phase = corev1.PodRunning
+				p.Status.PodIP = "10.244.0.5"
+				Expect(k8sClient.Status().Update(ctx, p)).To(Succeed())
+			},
+		}
+	})
+}
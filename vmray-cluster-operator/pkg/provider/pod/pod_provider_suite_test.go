@@ -0,0 +1,25 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package pod_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/test/builder"
+)
+
+var suite = builder.NewTestSuiteWithoutManager()
+
+var _ = BeforeSuite(suite.BeforeSuite)
+
+var _ = AfterSuite(suite.AfterSuite)
+
+func TestPodProvider(t *testing.T) {
+	suite.Register(t, "Unit testcases to validate pod provider", func() {
+		PodProviderTests()
+		PodProviderConformanceTests()
+	})
+}
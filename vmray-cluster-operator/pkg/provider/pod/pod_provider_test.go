@@ -0,0 +1,124 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package pod_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+	vmprovider "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/pod"
+	tls_utils "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/vmop/tls"
+)
+
+const (
+	namespace = "namespace-pod-test"
+)
+
+func PodProviderTests() {
+
+	Describe("Validate functions exposed to support ray node lifecycle via pods", func() {
+
+		Context("Validate DeployVmService, Deploy, Delete and FetchVmStatus", func() {
+			ctx := context.Background()
+
+			It("Create pod provider and validate pods & services in local env", func() {
+
+				clustername := "cluster-name"
+				k8sClient := suite.GetK8sClient()
+				provider := pod.NewPodProvider(k8sClient)
+
+				nsSpec := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
+				Expect(k8sClient.Create(ctx, nsSpec)).To(Succeed())
+				Expect(tls_utils.CreateVMRayClusterRootSecret(ctx, k8sClient, namespace, clustername)).To(Succeed())
+
+				headname := vmprovider.GetHeadNodeName(clustername, "")
+				req := vmprovider.VmDeploymentRequest{
+					Namespace:   namespace,
+					ClusterName: clustername,
+					VmName:      headname,
+					DockerImage: "rayproject/ray:2.9.0",
+					EnableTLS:   true,
+					NodeType:    "ray_head",
+					NodeConfig: vmrayv1alpha1.CommonNodeConfig{
+						NodeTypes: map[string]vmrayv1alpha1.NodeType{
+							"ray_head": {VMClass: "best-effort-xlarge"},
+							"worker_1": {VMClass: "best-effort-xsmall"},
+						},
+					},
+				}
+
+				// 1. Head node's service is created, labeled for garbage collection,
+				// and its cluster IP is returned afterwards.
+				ip, err := provider.DeployVmService(ctx, req)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ip).To(BeEmpty())
+				service := &corev1.Service{}
+				Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: headname}, service)).To(Succeed())
+				Expect(service.ObjectMeta.Labels).To(HaveKeyWithValue(vmprovider.ClusterLabel, clustername))
+				Expect(service.ObjectMeta.Labels).To(HaveKeyWithValue(vmprovider.NounceLabel, req.Nounce))
+				ip, err = provider.DeployVmService(ctx, req)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ip).ToNot(BeEmpty())
+
+				// 2. Head pod starts ray head with TLS configuration mounted.
+				req.VmService = ip
+				Expect(provider.Deploy(ctx, req)).To(Succeed())
+				head := &corev1.Pod{}
+				Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: headname}, head)).To(Succeed())
+				Expect(head.ObjectMeta.Annotations).To(HaveKeyWithValue(vmprovider.ConfigHashAnnotation, vmprovider.GetNodeConfigHash(req)))
+				container := head.Spec.Containers[0]
+				Expect(container.Image).To(Equal("rayproject/ray:2.9.0"))
+				Expect(container.Command[2]).To(ContainSubstring("ray start --head --port=6379"))
+				Expect(container.Env).To(ContainElement(corev1.EnvVar{Name: "RAY_USE_TLS", Value: "1"}))
+				Expect(container.Env).To(ContainElement(corev1.EnvVar{Name: "RAY_VMSERVICE_IP", Value: ip}))
+				Expect(container.VolumeMounts).To(HaveLen(3))
+
+				// 3. Pod IP is reported once the pod is running.
+				status, err := provider.FetchVmStatus(ctx, namespace, headname)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(status.Ip).To(BeEmpty())
+				head.Status.Phase = corev1.PodRunning
+				head.Status.PodIP = "10.244.0.5"
+				Expect(k8sClient.Status().Update(ctx, head)).To(Succeed())
+				status, err = provider.FetchVmStatus(ctx, namespace, headname)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(status.Ip).To(Equal("10.244.0.5"))
+
+				// 4. Worker pod joins the head node.
+				req.VmName = "worker-1"
+				req.NodeType = "worker_1"
+				req.HeadNodeStatus = status
+				Expect(provider.Deploy(ctx, req)).To(Succeed())
+				worker := &corev1.Pod{}
+				Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: "worker-1"}, worker)).To(Succeed())
+				Expect(worker.Spec.Containers[0].Command[2]).To(ContainSubstring("ray start --block --address=$RAY_HEAD_IP:6379"))
+				Expect(worker.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "RAY_HEAD_IP", Value: "10.244.0.5"}))
+
+				// 5. Resize isn't supported.
+				_, err = provider.Resize(ctx, req)
+				Expect(err).To(HaveOccurred())
+
+				// 6. Delete pods, head node's service & aux resources, deleting twice succeeds.
+				Expect(provider.Delete(ctx, namespace, "worker-1")).To(Succeed())
+				Expect(provider.Delete(ctx, namespace, headname)).To(Succeed())
+				Expect(provider.Delete(ctx, namespace, headname)).To(Succeed())
+				err = k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: headname}, &corev1.Service{})
+				Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+				Expect(provider.DeleteAuxiliaryResources(ctx, namespace, clustername)).To(Succeed())
+				err = k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: clustername + pod.ConfigSecretSuffix}, &corev1.Secret{})
+				Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			})
+		})
+	})
+}
@@ -5,6 +5,9 @@ package provider
 
 import (
 	"context"
+	"errors"
+
+	"k8s.io/apimachinery/pkg/labels"
 
 	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
 )
@@ -15,6 +18,10 @@ const (
 	RayClusterRequestorRayCLI = "ray-cli"
 )
 
+// ErrAdoptionMismatch is wrapped by errors of adopting a VM whose configuration
+// doesn't match the spec of the cluster adopting it.
+var ErrAdoptionMismatch = errors.New("VM doesn't match cluster's spec")
+
 type RayClusterRequestor int
 
 const (
@@ -35,6 +42,10 @@ type VmDeploymentRequest struct {
 	NodeType    string
 	ApiServer   vmrayv1alpha1.ApiServerInfo
 	EnableTLS   bool
+	// vSphere zone the VM is deployed to, empty to let vm-operator place it.
+	Zone string
+	// vSphere host the VM runs on, only known once it's placed.
+	Host string
 
 	// Head & common node configs.
 	HeadNodeConfig   vmrayv1alpha1.HeadNodeConfig
@@ -62,6 +73,27 @@ type VmProvider interface {
 	Delete(context.Context, string, string) error
 	FetchVmStatus(context.Context, string, string) (*vmrayv1alpha1.VMRayNodeStatus, error)
 	DeleteAuxiliaryResources(context.Context, string, string) error
+	// DeployServeService creates (or re-targets) a stable service exposing ray serve's
+	// HTTP port of the given head node and returns its ingress IP once assigned.
+	DeployServeService(ctx context.Context, namespace, name, headVmName string) (string, error)
+	DeleteServeService(ctx context.Context, namespace, name string) error
+	// Resize switches VM of the requested node to the VM class of its node type in place,
+	// powering it off & back on. It's called repeatedly till it reports the resize is done.
+	Resize(ctx context.Context, req VmDeploymentRequest) (bool, error)
+	// ListClusterVms returns every VM labeled with the cluster's name keyed by
+	// VM name, fetched with a single list.
+	ListClusterVms(ctx context.Context, namespace, clustername string) (map[string]ClusterVm, error)
+	// AdoptVm takes over an existing VM deployed outside of the operator as the requested
+	// node. It verifies VM's class & image match the node's config, labels VM like the
+	// VMs the provider deploys & returns VM's status. A worker's node type is resolved
+	// from its VM class when the request leaves it empty.
+	AdoptVm(ctx context.Context, req VmDeploymentRequest) (*vmrayv1alpha1.VMRayNodeStatus, error)
+	// AdoptVmService takes over the named existing VM service as the VM service of the
+	// requested head node and returns its ingress IP once assigned.
+	AdoptVmService(ctx context.Context, req VmDeploymentRequest, name string) (string, error)
+	// FindVms returns names of VMs in the namespace matching the selector which aren't
+	// labeled with a cluster yet, i.e. which may be adopted.
+	FindVms(ctx context.Context, namespace string, selector labels.Selector) ([]string, error)
 }
 
 func GetHeadNodeName(clustername, nounce string) string {
@@ -60,19 +60,26 @@ type AvailableNodeTypes struct {
 }
 type FileMounts struct {
 }
-type Resources struct {
-	CPU    uint8 `json:"cpu"`
-	Memory uint  `json:"memory"`
-	GPU    uint8 `json:"gpu"`
-}
+
+// Resources are ray's resources of a node type by their names in ray, e.g. CPU,
+// GPU, memory & custom resources. Resources which aren't set are detected by ray.
+type Resources map[string]interface{}
 type NodeConfig struct {
 	VMclass string `yaml:"vmclass"`
 }
+
+// NodeDocker overrides docker options of the cluster for the nodes of a node type.
+type NodeDocker struct {
+	WorkerRunOptions []string `yaml:"worker_run_options,omitempty"`
+}
 type Node struct {
 	NodeConfig NodeConfig `yaml:"node_config"`
 	MinWorkers uint       `yaml:"min_workers"`
 	MaxWorkers uint       `yaml:"max_workers"`
-	Resources  Resources  `yaml:"resources"`
+	Resources  Resources  `yaml:"resources,omitempty"`
+	// Node type's docker options & initialization commands replace the cluster's ones.
+	Docker                 *NodeDocker `yaml:"docker,omitempty"`
+	InitializationCommands []string    `yaml:"initialization_commands,omitempty"`
 }
 
 func getRayBootstrapConfig(cloudConfig CloudConfig) *RayBootstrapConfig {
@@ -82,7 +89,7 @@ func getRayBootstrapConfig(cloudConfig CloudConfig) *RayBootstrapConfig {
 		UpscalingSpeed: constants.UpscalingSpeed,
 		Docker: Docker{
 			Image:            cloudConfig.VmDeploymentRequest.DockerImage,
-			ContainerName:    ray_container_name,
+			ContainerName:    RayContainerName,
 			pullBeforeRun:    true,
 			RunOptions:       []string{},
 			WorkerRunOptions: []string{},
@@ -123,18 +130,21 @@ func getAvailableNodeTypes(cloudConfig CloudConfig) map[string]Node {
 	availabletypes := map[string]Node{}
 
 	for key, nt := range cloudConfig.VmDeploymentRequest.NodeConfig.NodeTypes {
-		availabletypes[key] = Node{
+		node := Node{
 			MinWorkers: nt.MinWorkers,
 			MaxWorkers: nt.MaxWorkers,
-			Resources: Resources{
-				CPU:    nt.Resources.CPU,
-				Memory: nt.Resources.Memory,
-				GPU:    nt.Resources.GPU,
-			},
+			Resources:  getRayResources(nt.Resources),
 			NodeConfig: NodeConfig{
 				VMclass: nt.VMClass,
 			},
 		}
+		// Workers with GPUs wait for their GPU setup & get access to their GPUs.
+		if nt.Resources.HasGPU() {
+			node.Docker = &NodeDocker{WorkerRunOptions: []string{GPUDockerFlag}}
+			node.InitializationCommands = append([]string{waitForCloudInitCmd},
+				cloudConfig.VmDeploymentRequest.NodeConfig.InitializationCommands...)
+		}
+		availabletypes[key] = node
 	}
 	return availabletypes
 }
@@ -0,0 +1,80 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cloudinit
+
+import (
+	vmprovider "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider"
+)
+
+/*
+Nodes of node types with GPUs run a setup script on boot, before ray's container is started:
+it verifies the NVIDIA driver & container toolkit, installs them from Ubuntu's & NVIDIA's
+repositories when missing, and configures docker's NVIDIA runtime. vGPU guest drivers are
+licensed by NVIDIA and have to be part of the VM image, the script only verifies them.
+NVIDIA's repository is added with a pinned URL & signing key, the key is only trusted once
+its fingerprint matches.
+
+The worker secret is shared by all node types of the cluster, so it carries the script if any
+of them has GPUs. The script exits early on VMs without NVIDIA PCI devices, i.e. workers of
+node types without GPUs.
+
+Ray's container is run with access to all GPUs of the VM, which fails unless the driver
+is loaded & the devices are visible, so ray only starts on nodes whose GPUs are usable.
+Workers started by ray autoscaler wait for the setup, as cloud-init has to finish first.
+*/
+
+const (
+	gpu_setup_file = "gpu-setup.sh"
+	// GPUDockerFlag gives ray's container access to all GPUs of the VM.
+	GPUDockerFlag = "--gpus all"
+	// waitForCloudInitCmd makes ray autoscaler wait for the GPU setup run by cloud-init.
+	waitForCloudInitCmd = "cloud-init status --wait > /dev/null"
+
+	gpuSetupScript = `#!/bin/bash
+set -ex
+exec >> /var/log/vmray-gpu-setup.log 2>&1
+if ! grep -qsx 0x10de /sys/bus/pci/devices/*/vendor; then
+  echo "No NVIDIA devices, skipping GPU setup"
+  exit 0
+fi
+export DEBIAN_FRONTEND=noninteractive
+NVIDIA_REPO_URL=https://nvidia.github.io/libnvidia-container
+NVIDIA_KEY_FINGERPRINT=C95B321B61E88C1809C4F759DDCAE044F796ECB0
+NVIDIA_KEYRING=/usr/share/keyrings/nvidia-container-toolkit-keyring.gpg
+if ! nvidia-smi -L; then
+  apt-get update
+  ubuntu-drivers install --gpgpu
+  modprobe nvidia
+  nvidia-smi -L
+fi
+if ! command -v nvidia-ctk; then
+  curl -fsSL -o /tmp/nvidia-container-toolkit.gpgkey $NVIDIA_REPO_URL/gpgkey
+  gpg --show-keys --with-colons /tmp/nvidia-container-toolkit.gpgkey | grep -q "^fpr:*$NVIDIA_KEY_FINGERPRINT:"
+  gpg --batch --yes --dearmor -o $NVIDIA_KEYRING /tmp/nvidia-container-toolkit.gpgkey
+  echo "deb [signed-by=$NVIDIA_KEYRING] $NVIDIA_REPO_URL/stable/deb/$(dpkg --print-architecture) /" > /etc/apt/sources.list.d/nvidia-container-toolkit.list
+  apt-get update
+  apt-get install -y nvidia-container-toolkit
+fi
+nvidia-ctk runtime configure --runtime=docker
+systemctl restart docker`
+)
+
+// isGPUNode returns true if the requested node's type has GPUs.
+func isGPUNode(req vmprovider.VmDeploymentRequest) bool {
+	return req.NodeConfig.NodeTypes[req.NodeType].Resources.HasGPU()
+}
+
+// HasGPUSetup returns true if the cloud init secret of the requested node carries the
+// GPU setup script, i.e. head node has GPUs or any node type does for worker secret.
+func HasGPUSetup(req vmprovider.VmDeploymentRequest) bool {
+	if req.HeadNodeStatus == nil {
+		return isGPUNode(req)
+	}
+	for _, nt := range req.NodeConfig.NodeTypes {
+		if nt.Resources.HasGPU() {
+			return true
+		}
+	}
+	return false
+}
@@ -6,14 +6,17 @@ package cloudinit
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"text/template"
 
+	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
 	vmprovider "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider"
 	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/vmop/tls"
 	"gopkg.in/yaml.v2"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -34,13 +37,25 @@ const (
 	Ca_cert_file               = "ca.crt"
 	Ca_key_file                = "ca.key"
 	svc_account_token_env_file = "svc-account-token.env"
-	ray_container_name         = "ray_container"
-	RayHeadDefaultPort         = int32(6379)
+	RayContainerName           = "ray_container"
+	RayHeadDefaultPort         = int32(vmrayv1alpha1.DefaultHeadPort)
 	RayHeadStartCmd            = "ray start --head --port=%d --block --autoscaling-config=/home/ray/ray_bootstrap_config.yaml --dashboard-host=0.0.0.0"
 	RayWorkerStartCmd          = "ray start --block --address=$RAY_HEAD_IP:%d"
 	ray_bootstrap_config_file  = "ray_bootstrap_config.yaml"
+	ray_placement_env_file     = "ray_placement.env"
 	RunScriptToGenCerts        = "sh /home/ray/gencert.sh"
 
+	// Prefixes of the custom resources advertising zone & host of a node's VM to ray.
+	PlacementZoneResourcePrefix = "zone:"
+	PlacementHostResourcePrefix = "host:"
+
+	// Environment variable holding custom resources of a node's placement as JSON, it's
+	// read from the node's placement env file by workers started by ray autoscaler.
+	RayPlacementResourcesEnv = "RAY_PLACEMENT_RESOURCES"
+
+	// Head start command for ray nodes which aren't managed by ray autoscaler.
+	rayHeadStartNoAutoscalerCmd = "ray start --head --port=%d --block --dashboard-host=0.0.0.0"
+
 	// Templates to generate cloud config for Ray head & worker nodes.
 	cloudConfigHeadNodeTemplate = `#cloud-config
 ssh_pwauth: true
@@ -64,6 +79,9 @@ write_files:
 runcmd:
   - chown -R {{ (index .users 0).user }}:{{ (index .users 0).user }} /home/{{ (index .users 0).user }}
   - usermod -aG docker {{ (index .users 0).user }}
+{{- if .gpu_setup_path }}
+  - bash {{ .gpu_setup_path }}
+{{- end }}
   - su {{ (index .users 0).user }} -c 'ssh-keygen -f {{ .ssh_rsa_key_path }} -t RSA -y > {{ .ssh_rsa_key_path }}.pub'
   - su {{ (index .users 0).user }} -c 'cat {{ .ssh_rsa_key_path }}.pub >> ~/.ssh/authorized_keys'
   - su {{ (index .users 0).user }} -c 'echo "" >> ~/.bashrc'
@@ -101,6 +119,9 @@ write_files:
 runcmd:
   - chown -R {{ (index .users 0).user }}:{{ (index .users 0).user }} /home/{{ (index .users 0).user }}
   - usermod -aG docker {{ (index .users 0).user }}
+{{- if .gpu_setup_path }}
+  - bash {{ .gpu_setup_path }}
+{{- end }}
   - su {{ (index .users 0).user }} -c 'ssh-keygen -f {{ .ssh_rsa_key_path }} -t RSA -y > {{ .ssh_rsa_key_path }}.pub'
   - su {{ (index .users 0).user }} -c 'cat {{ .ssh_rsa_key_path }}.pub >> ~/.ssh/authorized_keys'
   - su {{ (index .users 0).user }} -c 'echo "" >> ~/.bashrc'
@@ -148,14 +169,179 @@ func setDockerCommand(rbc *RayBootstrapConfig, cloudConfig CloudConfig) {
 	rbc.WorkerStartRayCommands = append(rbc.WorkerStartRayCommands,
 		RunScriptToGenCerts,
 		"ray stop",
-		fmt.Sprintf(RayWorkerStartCmd, port))
+		fmt.Sprintf(RayWorkerStartCmd+` --resources="$%s"`, port, RayPlacementResourcesEnv))
 	rbc.HeadStartRayCommands = append(rbc.HeadStartRayCommands,
 		RunScriptToGenCerts,
 		"ray stop",
-		fmt.Sprintf(RayHeadStartCmd, port),
+		withResourceFlags(fmt.Sprintf(RayHeadStartCmd, port), getPlacedResources(cloudConfig.VmDeploymentRequest)),
 	)
 }
 
+// getRayResources returns the resources ray advertises for a node of the node type,
+// CPUs, GPUs & memory are integers while custom resources may be fractional.
+func getRayResources(resources vmrayv1alpha1.NodeResource) Resources {
+	rayResources := Resources{}
+	if resources.CPU != nil {
+		rayResources["CPU"] = resources.CPU.Value()
+	}
+	if resources.GPU != nil {
+		rayResources["GPU"] = resources.GPU.Value()
+	}
+	if resources.Memory != nil {
+		rayResources["memory"] = resources.Memory.Value()
+	}
+	for name, quantity := range resources.CustomResources {
+		rayResources[name] = getCustomResourceValue(quantity)
+	}
+	if len(rayResources) == 0 {
+		return nil
+	}
+	return rayResources
+}
+
+func getCustomResourceValue(quantity resource.Quantity) interface{} {
+	if value, ok := quantity.AsInt64(); ok {
+		return value
+	}
+	return quantity.AsApproximateFloat64()
+}
+
+// GetRayStartResourceFlags returns the flags of ray start which advertise the
+// resources of the node type, i.e. --num-cpus, --num-gpus, --memory & --resources.
+func GetRayStartResourceFlags(resources vmrayv1alpha1.NodeResource) []string {
+	flags := []string{}
+	if resources.CPU != nil {
+		flags = append(flags, fmt.Sprintf("--num-cpus=%d", resources.CPU.Value()))
+	}
+	if resources.GPU != nil {
+		flags = append(flags, fmt.Sprintf("--num-gpus=%d", resources.GPU.Value()))
+	}
+	if resources.Memory != nil {
+		flags = append(flags, fmt.Sprintf("--memory=%d", resources.Memory.Value()))
+	}
+	if len(resources.CustomResources) > 0 {
+		custom := make(map[string]interface{}, len(resources.CustomResources))
+		for name, quantity := range resources.CustomResources {
+			custom[name] = getCustomResourceValue(quantity)
+		}
+		// Marshalling a map of strings to numbers can't fail, keys are sorted.
+		b, _ := json.Marshal(custom)
+		flags = append(flags, "--resources="+shellQuote(string(b)))
+	}
+	return flags
+}
+
+// withResourceFlags appends the flags advertising resources to the ray start command.
+func withResourceFlags(start string, resources vmrayv1alpha1.NodeResource) string {
+	flags := GetRayStartResourceFlags(resources)
+	return strings.Join(append([]string{start}, flags...), " ")
+}
+
+// getPlacement returns zone:<zone> & host:<host> custom resources of the requested
+// node's VM placement as far as it's known, so ray tasks can be scheduled to zones & hosts.
+func getPlacement(req vmprovider.VmDeploymentRequest) map[string]int64 {
+	placement := map[string]int64{}
+	if req.Zone != "" {
+		placement[PlacementZoneResourcePrefix+req.Zone] = 1
+	}
+	if req.Host != "" {
+		placement[PlacementHostResourcePrefix+req.Host] = 1
+	}
+	return placement
+}
+
+// getPlacedResources returns resources of the requested node's type along with
+// custom resources of its VM's placement.
+func getPlacedResources(req vmprovider.VmDeploymentRequest) vmrayv1alpha1.NodeResource {
+	resources := req.NodeConfig.NodeTypes[req.NodeType].Resources
+	placement := getPlacement(req)
+	custom := make(map[string]resource.Quantity, len(resources.CustomResources)+len(placement))
+	for name, quantity := range resources.CustomResources {
+		custom[name] = quantity
+	}
+	for name, value := range placement {
+		custom[name] = *resource.NewQuantity(value, resource.DecimalSI)
+	}
+	if len(custom) > 0 {
+		resources.CustomResources = custom
+	}
+	return resources
+}
+
+// shellQuote quotes s as a single word of a shell command.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// dockerCmdEscaper escapes commands run by bash in ray container, they're
+// double quoted in docker run which is single quoted in cloud init's runcmd.
+var dockerCmdEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "'", `'\''`)
+
+// GetRayEnv returns environment variables of ray containers, they configure
+// TLS on ray's grpc channels using certificates generated by gencert.sh.
+func GetRayEnv(enableTLS bool) []corev1.EnvVar {
+	useTLS := "0"
+	if enableTLS {
+		useTLS = "1"
+	}
+	return []corev1.EnvVar{
+		{Name: "RAY_USE_TLS", Value: useTLS},
+		{Name: "RAY_TLS_CA_CERT", Value: "/home/ray/ca.crt"},
+		{Name: "RAY_TLS_SERVER_KEY", Value: "/home/ray/tls.key"},
+		{Name: "RAY_TLS_SERVER_CERT", Value: "/home/ray/tls.crt"},
+	}
+}
+
+// GetRayStartCommands returns commands run in ray container of the requested node
+// to start ray without ray autoscaler, workers join the head node at $RAY_HEAD_IP.
+func GetRayStartCommands(req vmprovider.VmDeploymentRequest) []string {
+	port := getRayPort(CloudConfig{VmDeploymentRequest: req})
+	start := fmt.Sprintf(RayWorkerStartCmd, port)
+	if req.HeadNodeStatus == nil {
+		start = fmt.Sprintf(rayHeadStartNoAutoscalerCmd, port)
+	}
+	return []string{RunScriptToGenCerts, "ray stop", withResourceFlags(start, req.NodeConfig.NodeTypes[req.NodeType].Resources)}
+}
+
+// getDockerFlags returns docker run options shared by ray containers of head & worker nodes.
+func getDockerFlags(vmuser string, enableTLS int) []string {
+	flags := []string{
+		fmt.Sprintf("-v /home/%s/%s:/home/ray/ca.crt", vmuser, Ca_cert_file),
+		fmt.Sprintf("-v /home/%s/%s:/home/ray/ca.key", vmuser, Ca_key_file),
+		fmt.Sprintf("-v /home/%s/gencert.sh:/home/ray/gencert.sh", vmuser),
+	}
+	for _, env := range GetRayEnv(enableTLS == 1) {
+		flags = append(flags, fmt.Sprintf("--env \"%s=%s\"", env.Name, env.Value))
+	}
+	return append(flags, "--ulimit nofile=65536:65536")
+}
+
+// GetWorkerRestartCommand returns command which re-creates ray container of a worker
+// node and joins it to the ray head at headIp, e.g. after worker's VM was restarted.
+func GetWorkerRestartCommand(req vmprovider.VmDeploymentRequest, headIp string) string {
+	enableTLS := 0
+	if req.EnableTLS {
+		enableTLS = 1
+	}
+	flags := append(getDockerFlags(req.NodeConfig.VMUser, enableTLS),
+		"--rm",
+		fmt.Sprintf("--name %s", RayContainerName),
+		"-d",
+		"--network host",
+	)
+	if isGPUNode(req) {
+		flags = append(flags, GPUDockerFlag)
+	}
+	port := getRayPort(CloudConfig{VmDeploymentRequest: req})
+	cmd := []string{
+		RunScriptToGenCerts,
+		"ray stop",
+		withResourceFlags(fmt.Sprintf("ray start --block --address=%s:%d", headIp, port), getPlacedResources(req)),
+	}
+	return fmt.Sprintf("docker rm -f %s; docker run %s %s /bin/bash -c %s", RayContainerName,
+		strings.Join(flags, " "), req.DockerImage, shellQuote(strings.Join(cmd, ";")))
+}
+
 // produceCloudInitConfigYamlTemplate consumes user infos & files to mount to produce cloudinit configuration.
 func produceCloudInitConfigYamlTemplate(cloudConfig CloudConfig) ([]byte, error) {
 
@@ -170,6 +356,8 @@ func produceCloudInitConfigYamlTemplate(cloudConfig CloudConfig) ([]byte, error)
 	ray_bootstrap_config_file_path := fmt.Sprintf("/home/%s/%s", vmuser, ray_bootstrap_config_file)
 	gen_cert_file_path := fmt.Sprintf("/home/%s/gencert.sh", vmuser)
 	svc_acc_token_env_path := fmt.Sprintf("/home/%s/%s", vmuser, svc_account_token_env_file)
+	ray_placement_env_path := fmt.Sprintf("/home/%s/%s", vmuser, ray_placement_env_file)
+	gpu_setup_path := ""
 
 	// Enable tls by default.
 	cloudConfig.EnableTLS = 1
@@ -205,27 +393,37 @@ func produceCloudInitConfigYamlTemplate(cloudConfig CloudConfig) ([]byte, error)
 			"permissions": "0444",
 		},
 		map[string]string{
-			"path":        fmt.Sprintf("/home/%s/gencert.sh", vmuser),
+			"path":        gen_cert_file_path,
 			"content":     addIndentation(tls.GetRayTLSConfigString(), 5),
 			"permissions": "0777",
 		},
 	)
 
+	// Placement of the node's VM is only known to its own cloud init, ray containers
+	// started by ray autoscaler read it from the env file to advertise it to ray.
+	placement, err := json.Marshal(getPlacement(cloudConfig.VmDeploymentRequest))
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, map[string]string{
+		"path":        ray_placement_env_path,
+		"content":     addIndentation(fmt.Sprintf("%s=%s", RayPlacementResourcesEnv, placement), 5),
+		"permissions": "0444",
+	})
+	if HasGPUSetup(cloudConfig.VmDeploymentRequest) {
+		gpu_setup_path = fmt.Sprintf("/home/%s/%s", vmuser, gpu_setup_file)
+		files = append(files, map[string]string{
+			"path":        gpu_setup_path,
+			"content":     addIndentation(gpuSetupScript, 5),
+			"permissions": "0755",
+		})
+	}
+
 	// Commands to be run on head and worker nodes before ray start
 	var docker_cmd []string = []string{}
-	var docker_flags []string = []string{
-		fmt.Sprintf("-v %s:/home/ray/ca.crt", ca_cert_file_path),
-		fmt.Sprintf("-v %s:/home/ray/ca.key", ca_key_file_path),
-		fmt.Sprintf("-v %s:/home/ray/gencert.sh", gen_cert_file_path),
-		fmt.Sprintf("--env \"RAY_USE_TLS=%d\"", cloudConfig.EnableTLS),
-		"--env \"RAY_TLS_CA_CERT=/home/ray/ca.crt\"",
-		"--env \"RAY_TLS_SERVER_KEY=/home/ray/tls.key\"",
-		"--env \"RAY_TLS_SERVER_CERT=/home/ray/tls.crt\"",
-		"--ulimit nofile=65536:65536",
-	}
+	var docker_flags []string = getDockerFlags(vmuser, cloudConfig.EnableTLS)
 
 	var templ *template.Template
-	var err error
 	if cloudConfig.VmDeploymentRequest.HeadNodeStatus == nil {
 
 		// Don't create bootstrap config yaml, if requestor is ray cli
@@ -234,8 +432,10 @@ func produceCloudInitConfigYamlTemplate(cloudConfig CloudConfig) ([]byte, error)
 
 			rbc := getRayBootstrapConfig(cloudConfig)
 
-			// Add docker run options & initialization commands
-			rbc.Docker.RunOptions = docker_flags
+			// Add docker run options & initialization commands, containers of workers
+			// get their placement from the placement env file of their VM.
+			rbc.Docker.RunOptions = append(append([]string{}, docker_flags...),
+				fmt.Sprintf("--env-file %s", ray_placement_env_path))
 			setDockerCommand(rbc, cloudConfig)
 			docker_cmd = append(docker_cmd,
 				rbc.HeadStartRayCommands...)
@@ -249,7 +449,7 @@ func produceCloudInitConfigYamlTemplate(cloudConfig CloudConfig) ([]byte, error)
 
 			docker_flags = append(docker_flags,
 				"--rm",
-				fmt.Sprintf("--name %s", ray_container_name),
+				fmt.Sprintf("--name %s", RayContainerName),
 				"-d",
 				"--network host",
 				fmt.Sprintf("--env-file %s", svc_acc_token_env_path),
@@ -257,6 +457,9 @@ func produceCloudInitConfigYamlTemplate(cloudConfig CloudConfig) ([]byte, error)
 				fmt.Sprintf("-v %s:/home/ray/.ssh/id_rsa_ray", ssh_rsa_key_path),
 			)
 		}
+		if isGPUNode(cloudConfig.VmDeploymentRequest) {
+			docker_flags = append(docker_flags, GPUDockerFlag)
+		}
 		content := fmt.Sprintf("SVC_ACCOUNT_TOKEN=%s\nRAY_VMSERVICE_IP=%s",
 			cloudConfig.SvcAccToken,
 			cloudConfig.VmDeploymentRequest.VmService)
@@ -284,11 +487,12 @@ func produceCloudInitConfigYamlTemplate(cloudConfig CloudConfig) ([]byte, error)
 		"files":                   files,
 		"docker_image":            cloudConfig.VmDeploymentRequest.DockerImage,
 		"initialization_commands": cloudConfig.VmDeploymentRequest.NodeConfig.InitializationCommands,
-		"docker_cmd":              strings.Join(docker_cmd, ";"),
+		"docker_cmd":              dockerCmdEscaper.Replace(strings.Join(docker_cmd, ";")),
 		"docker_flags":            strings.Join(docker_flags, " "),
 		"enable_docker_execution": !cloudConfig.VmDeploymentRequest.RayClusterRequestor.IsRayCli(),
 		"ssh_rsa_key_path":        ssh_rsa_key_path,
 		"docker_login_cmd":        cloudConfig.DockerLoginCmd,
+		"gpu_setup_path":          gpu_setup_path,
 	})
 	if err != nil {
 		return []byte{}, err
@@ -312,6 +516,7 @@ func CreateCloudInitConfigSecret(cloudConfig CloudConfig) (*corev1.Secret, error
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      cloudConfig.SecretName,
 			Namespace: cloudConfig.VmDeploymentRequest.Namespace,
+			Labels:    vmprovider.GetClusterLabels(cloudConfig.VmDeploymentRequest.ClusterName),
 		},
 		StringData: dataMap,
 	}, nil
@@ -5,6 +5,7 @@ package cloudinit_test
 
 import (
 	"encoding/base64"
+	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -13,6 +14,7 @@ import (
 	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
 	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider"
 	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/vmop/cloudinit"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 const (
@@ -140,6 +142,218 @@ func templatingTests() {
 
 				Expect(dataStr).To(ContainSubstring("/home/rayvm-user2/.ssh/id_rsa_ray.pub"))
 			})
+
+			It("Create command to restart ray on worker node", func() {
+				vmDeploymentRequest.NodeConfig.VMUser = "rayvm-user2"
+				vmDeploymentRequest.DockerImage = "rayproject/ray:2.9.0"
+				vmDeploymentRequest.EnableTLS = true
+
+				cmd := cloudinit.GetWorkerRestartCommand(vmDeploymentRequest, "10.0.0.1")
+				Expect(cmd).To(HavePrefix("docker rm -f ray_container; docker run "))
+				Expect(cmd).To(ContainSubstring("-v /home/rayvm-user2/ca.crt:/home/ray/ca.crt"))
+				Expect(cmd).To(ContainSubstring(`--env "RAY_USE_TLS=1"`))
+				Expect(cmd).To(ContainSubstring("--name ray_container -d --network host rayproject/ray:2.9.0"))
+				Expect(cmd).To(HaveSuffix("/bin/bash -c 'sh /home/ray/gencert.sh;ray stop;ray start --block --address=10.0.0.1:6379'"))
+			})
+
+			It("Create command to restart ray on worker node with its node type's resources", func() {
+				vmDeploymentRequest.NodeType = "worker_1"
+				vmDeploymentRequest.NodeConfig.NodeTypes = map[string]vmrayv1alpha1.NodeType{
+					"worker_1": {Resources: vmrayv1alpha1.NodeResource{
+						CPU:             resource.NewQuantity(4, resource.DecimalSI),
+						CustomResources: map[string]resource.Quantity{"TPU": resource.MustParse("1")},
+					}},
+				}
+
+				cmd := cloudinit.GetWorkerRestartCommand(vmDeploymentRequest, "10.0.0.1")
+				Expect(cmd).To(HaveSuffix(`/bin/bash -c 'sh /home/ray/gencert.sh;ray stop;ray start --block ` +
+					`--address=10.0.0.1:6379 --num-cpus=4 --resources='\''{"TPU":1}'\'''`))
+			})
+
+			It("Create command to restart ray on worker node with its zone & host", func() {
+				vmDeploymentRequest.NodeType = "worker_1"
+				vmDeploymentRequest.NodeConfig.NodeTypes = map[string]vmrayv1alpha1.NodeType{
+					"worker_1": {Resources: vmrayv1alpha1.NodeResource{
+						CustomResources: map[string]resource.Quantity{"TPU": resource.MustParse("1")},
+					}},
+				}
+				vmDeploymentRequest.Zone = "zone-a"
+				vmDeploymentRequest.Host = "esx-1.local"
+
+				cmd := cloudinit.GetWorkerRestartCommand(vmDeploymentRequest, "10.0.0.1")
+				Expect(cmd).To(HaveSuffix(`--address=10.0.0.1:6379 --resources='\''{"TPU":1,"host:esx-1.local":1,"zone:zone-a":1}'\'''`))
+				// Node type's own custom resources are left as they are.
+				Expect(vmDeploymentRequest.NodeConfig.NodeTypes["worker_1"].Resources.CustomResources).To(HaveLen(1))
+			})
+
+			It("Advertise zone of head node to ray", func() {
+				vmDeploymentRequest.Zone = "zone-a"
+				cloudConfig.VmDeploymentRequest = vmDeploymentRequest
+
+				secret, err := cloudinit.CreateCloudInitConfigSecret(cloudConfig)
+				Expect(err).ToNot(HaveOccurred())
+				data, err := base64.StdEncoding.DecodeString(secret.StringData[cloudinit.CloudInitConfigUserDataKey])
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(data)).To(ContainSubstring("zone:zone-a"))
+			})
+
+			It("Advertise zone of worker node to ray", func() {
+				vmDeploymentRequest.HeadNodeStatus = &vmrayv1alpha1.VMRayNodeStatus{}
+				vmDeploymentRequest.Zone = "zone-a"
+				cloudConfig.VmDeploymentRequest = vmDeploymentRequest
+
+				secret, err := cloudinit.CreateCloudInitConfigSecret(cloudConfig)
+				Expect(err).ToNot(HaveOccurred())
+				data, err := base64.StdEncoding.DecodeString(secret.StringData[cloudinit.CloudInitConfigUserDataKey])
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(data)).To(ContainSubstring(`RAY_PLACEMENT_RESOURCES={"zone:zone-a":1}`))
+				Expect(string(data)).To(ContainSubstring("path: /home/rayvm-user/ray_placement.env"))
+			})
+
+			It("Start workers of ray autoscaler with placement of their VM", func() {
+				secret, err := cloudinit.CreateCloudInitConfigSecret(cloudConfig)
+				Expect(err).ToNot(HaveOccurred())
+				data, err := base64.StdEncoding.DecodeString(secret.StringData[cloudinit.CloudInitConfigUserDataKey])
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(data)).To(ContainSubstring("--env-file /home/rayvm-user/ray_placement.env"))
+				Expect(string(data)).To(ContainSubstring(`ray start --block --address=$RAY_HEAD_IP:6379 --resources="$RAY_PLACEMENT_RESOURCES"`))
+			})
+		})
+
+		Context("Validate resources of node types", func() {
+			BeforeEach(func() {
+				vmDeploymentRequest.NodeType = "head"
+				vmDeploymentRequest.NodeConfig.NodeTypes = map[string]vmrayv1alpha1.NodeType{
+					"head": {},
+					"worker_1": {Resources: vmrayv1alpha1.NodeResource{
+						CPU:    resource.NewQuantity(8, resource.DecimalSI),
+						Memory: resource.NewQuantity(16*1024*1024*1024, resource.BinarySI),
+						GPU:    resource.NewQuantity(1, resource.DecimalSI),
+						CustomResources: map[string]resource.Quantity{
+							"accelerator_type:T4": resource.MustParse("0.5"),
+						},
+					}},
+				}
+				cloudConfig.VmDeploymentRequest = vmDeploymentRequest
+			})
+
+			It("Render resources into bootstrap config by their ray names", func() {
+				secret, err := cloudinit.CreateCloudInitConfigSecret(cloudConfig)
+				Expect(err).ToNot(HaveOccurred())
+				data, err := base64.StdEncoding.DecodeString(secret.StringData[cloudinit.CloudInitConfigUserDataKey])
+				Expect(err).ToNot(HaveOccurred())
+
+				dataStr := string(data)
+				Expect(dataStr).To(MatchRegexp(`resources:\n\s+CPU: 8\n\s+GPU: 1\n` +
+					`\s+accelerator_type:T4: 0.5\n\s+memory: 17179869184\n`))
+				// Head node type's resources are detected by ray.
+				Expect(strings.Count(dataStr, "resources:")).To(Equal(1))
+			})
+
+			It("Start ray with resources of the node type", func() {
+				vmDeploymentRequest.NodeType = "worker_1"
+
+				Expect(cloudinit.GetRayStartCommands(vmDeploymentRequest)).To(Equal([]string{
+					"sh /home/ray/gencert.sh",
+					"ray stop",
+					"ray start --head --port=6379 --block --dashboard-host=0.0.0.0 --num-cpus=8 --num-gpus=1 " +
+						`--memory=17179869184 --resources='{"accelerator_type:T4":0.5}'`,
+				}))
+			})
+
+			It("Escape resources of head node in cloud config", func() {
+				nt := vmDeploymentRequest.NodeConfig.NodeTypes["head"]
+				nt.Resources.CustomResources = map[string]resource.Quantity{"TPU": resource.MustParse("2")}
+				vmDeploymentRequest.NodeConfig.NodeTypes["head"] = nt
+				cloudConfig.VmDeploymentRequest = vmDeploymentRequest
+
+				secret, err := cloudinit.CreateCloudInitConfigSecret(cloudConfig)
+				Expect(err).ToNot(HaveOccurred())
+				data, err := base64.StdEncoding.DecodeString(secret.StringData[cloudinit.CloudInitConfigUserDataKey])
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(string(data)).To(ContainSubstring(`--dashboard-host=0.0.0.0 --resources='\''{\"TPU\":2}'\''"'`))
+			})
+		})
+
+		Context("Validate GPU node types", func() {
+			BeforeEach(func() {
+				vmDeploymentRequest.NodeType = "head"
+				vmDeploymentRequest.NodeConfig.InitializationCommands = []string{"echo init"}
+				vmDeploymentRequest.NodeConfig.NodeTypes = map[string]vmrayv1alpha1.NodeType{
+					"head": {},
+					"gpu": {Resources: vmrayv1alpha1.NodeResource{
+						GPU: resource.NewQuantity(1, resource.DecimalSI),
+					}},
+				}
+				cloudConfig.VmDeploymentRequest = vmDeploymentRequest
+			})
+
+			It("Give workers of GPU node types access to GPUs once set up", func() {
+				secret, err := cloudinit.CreateCloudInitConfigSecret(cloudConfig)
+				Expect(err).ToNot(HaveOccurred())
+				data, err := base64.StdEncoding.DecodeString(secret.StringData[cloudinit.CloudInitConfigUserDataKey])
+				Expect(err).ToNot(HaveOccurred())
+
+				dataStr := string(data)
+				Expect(dataStr).To(MatchRegexp(`docker:\n\s+worker_run_options:\n\s+- --gpus all\n` +
+					`\s+initialization_commands:\n\s+- cloud-init status --wait > /dev/null\n\s+- echo init\n`))
+				// Head node without GPUs isn't set up for them.
+				Expect(strings.Count(dataStr, cloudinit.GPUDockerFlag)).To(Equal(1))
+				Expect(dataStr).NotTo(ContainSubstring("gpu-setup.sh"))
+			})
+
+			It("Set up GPUs of head node before starting ray", func() {
+				vmDeploymentRequest.NodeType = "gpu"
+				cloudConfig.VmDeploymentRequest = vmDeploymentRequest
+
+				secret, err := cloudinit.CreateCloudInitConfigSecret(cloudConfig)
+				Expect(err).ToNot(HaveOccurred())
+				data, err := base64.StdEncoding.DecodeString(secret.StringData[cloudinit.CloudInitConfigUserDataKey])
+				Expect(err).ToNot(HaveOccurred())
+
+				dataStr := string(data)
+				Expect(dataStr).To(ContainSubstring("path: /home/rayvm-user/gpu-setup.sh"))
+				Expect(dataStr).To(ContainSubstring("nvidia-ctk runtime configure --runtime=docker"))
+				Expect(dataStr).To(MatchRegexp(`- bash /home/rayvm-user/gpu-setup.sh\n(.*\n)*.*docker run .*--gpus all`))
+			})
+
+			It("Set up GPUs of worker node & restart ray with access to them", func() {
+				vmDeploymentRequest.NodeType = "gpu"
+				vmDeploymentRequest.HeadNodeStatus = &vmrayv1alpha1.VMRayNodeStatus{}
+				cloudConfig.VmDeploymentRequest = vmDeploymentRequest
+
+				secret, err := cloudinit.CreateCloudInitConfigSecret(cloudConfig)
+				Expect(err).ToNot(HaveOccurred())
+				data, err := base64.StdEncoding.DecodeString(secret.StringData[cloudinit.CloudInitConfigUserDataKey])
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(data)).To(ContainSubstring("- bash /home/rayvm-user/gpu-setup.sh"))
+
+				cmd := cloudinit.GetWorkerRestartCommand(vmDeploymentRequest, "10.0.0.1")
+				Expect(cmd).To(ContainSubstring("--network host --gpus all"))
+			})
+
+			It("Skip GPU setup on workers without NVIDIA devices sharing the worker secret", func() {
+				vmDeploymentRequest.NodeType = "head"
+				vmDeploymentRequest.HeadNodeStatus = &vmrayv1alpha1.VMRayNodeStatus{}
+				cloudConfig.VmDeploymentRequest = vmDeploymentRequest
+				Expect(cloudinit.HasGPUSetup(vmDeploymentRequest)).To(BeTrue())
+
+				secret, err := cloudinit.CreateCloudInitConfigSecret(cloudConfig)
+				Expect(err).ToNot(HaveOccurred())
+				data, err := base64.StdEncoding.DecodeString(secret.StringData[cloudinit.CloudInitConfigUserDataKey])
+				Expect(err).ToNot(HaveOccurred())
+
+				dataStr := string(data)
+				Expect(dataStr).To(ContainSubstring("- bash /home/rayvm-user/gpu-setup.sh"))
+				Expect(dataStr).To(MatchRegexp(`grep -qsx 0x10de /sys/bus/pci/devices/\*/vendor; then\n\s+echo "No NVIDIA devices, skipping GPU setup"\n\s+exit 0`))
+				// NVIDIA's repository is pinned rather than taken from a downloaded list.
+				Expect(dataStr).To(ContainSubstring("NVIDIA_KEY_FINGERPRINT=C95B321B61E88C1809C4F759DDCAE044F796ECB0"))
+				Expect(dataStr).NotTo(ContainSubstring("nvidia-container-toolkit.list |"))
+
+				cmd := cloudinit.GetWorkerRestartCommand(vmDeploymentRequest, "10.0.0.1")
+				Expect(cmd).NotTo(ContainSubstring(cloudinit.GPUDockerFlag))
+			})
 		})
 	})
 }
@@ -14,6 +14,8 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vmprovider "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider"
 )
 
 const (
@@ -95,6 +97,7 @@ func CreateVMRayClusterRootSecret(ctx context.Context, kubeclient client.Client,
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      secretName,
 			Namespace: namespace,
+			Labels:    vmprovider.GetClusterLabels(clusterName),
 		},
 		Data: map[string][]byte{
 			rootCaCertKey: caPEM,
@@ -7,21 +7,30 @@ import (
 	vmopv1 "github.com/vmware-tanzu/vm-operator/api/v1alpha2"
 	vmopv1common "github.com/vmware-tanzu/vm-operator/api/v1alpha2/common"
 	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider"
 	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/vmop/cloudinit"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// ClusterModuleGroupAnnotation makes vm-operator add the VM to a cluster module group of
+// its resource policy, VMs of the same group are kept on separate hosts.
+const ClusterModuleGroupAnnotation = "vsphere-cluster-module-group"
+
 func TranslateToVmCRD(namespace,
 	vmName,
 	cloudConfigSecretName string,
 	labels map[string]string,
+	annotations map[string]string,
 	vmclass string,
+	zone string,
 	nodeconfig vmrayv1alpha1.CommonNodeConfig) (*vmopv1.VirtualMachine, error) {
-	return &vmopv1.VirtualMachine{
+	vm := &vmopv1.VirtualMachine{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      vmName,
-			Namespace: namespace,
-			Labels:    labels,
+			Name:        vmName,
+			Namespace:   namespace,
+			Labels:      labels,
+			Annotations: annotations,
 		},
 		Spec: vmopv1.VirtualMachineSpec{
 			ImageName:    nodeconfig.VMImage,
@@ -38,7 +47,26 @@ func TranslateToVmCRD(namespace,
 				},
 			},
 		},
-	}, nil
+	}
+	// vm-operator places VMs labeled with a zone into that zone.
+	if zone != "" {
+		if vm.ObjectMeta.Labels == nil {
+			vm.ObjectMeta.Labels = make(map[string]string)
+		}
+		vm.ObjectMeta.Labels[corev1.LabelTopologyZone] = zone
+	}
+	if policy := nodeconfig.ResourcePolicy; policy != nil {
+		vm.Spec.Reserved = &vmopv1.VirtualMachineReservedSpec{
+			ResourcePolicyName: policy.Name,
+		}
+		if policy.ClusterModuleGroup != "" {
+			if vm.ObjectMeta.Annotations == nil {
+				vm.ObjectMeta.Annotations = make(map[string]string)
+			}
+			vm.ObjectMeta.Annotations[ClusterModuleGroupAnnotation] = policy.ClusterModuleGroup
+		}
+	}
+	return vm, nil
 }
 
 func ExtractVmStatus(vm *vmopv1.VirtualMachine) *vmrayv1alpha1.VMRayNodeStatus {
@@ -50,6 +78,10 @@ func ExtractVmStatus(vm *vmopv1.VirtualMachine) *vmrayv1alpha1.VMRayNodeStatus {
 	return &vmrayv1alpha1.VMRayNodeStatus{
 		Ip:         ip,
 		Conditions: vm.Status.Conditions,
+		ConfigHash: vm.ObjectMeta.Annotations[provider.ConfigHashAnnotation],
+		VMClass:    vm.Spec.ClassName,
+		Zone:       vm.Status.Zone,
+		Host:       vm.Status.Host,
 		// status change depends on previous status of the VM & ray process.
 		VmStatus:  "",
 		RayStatus: "",
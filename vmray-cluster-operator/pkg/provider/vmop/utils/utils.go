@@ -6,6 +6,7 @@ package utils
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	vmprovider "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider"
 	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/vmop/cloudinit"
@@ -51,7 +52,8 @@ var (
 )
 
 // CreateCloudInitSecret checks if secret exists, otherwise creates it
-// with necessary cloud init configuration.
+// with necessary cloud init configuration. An existing secret created
+// from a different cluster configuration is regenerated in place.
 func CreateCloudInitSecret(ctx context.Context,
 	kubeclient client.Client,
 	req vmprovider.VmDeploymentRequest) (*corev1.Secret, bool, error) {
@@ -59,7 +61,11 @@ func CreateCloudInitSecret(ctx context.Context,
 	var cloudConfig cloudinit.CloudConfig
 	var err error
 
+	// Workers share the secret of their zone, which they advertise to ray.
 	nodeSecretName := req.ClusterName + WorkerNodeSecretSuffix
+	if req.Zone != "" {
+		nodeSecretName += "-" + req.Zone
+	}
 	if req.HeadNodeStatus == nil {
 		nodeSecretName = req.ClusterName + HeadNodeSecretSuffix
 		var err error
@@ -83,10 +89,15 @@ func CreateCloudInitSecret(ctx context.Context,
 		Name:      nodeSecretName,
 	}
 
-	// Check if secret exists.
+	// Check if secret exists and is up to date with cluster's configuration.
+	configHash := vmprovider.GetCloudInitConfigHash(req, cloudinit.HasGPUSetup(req))
 	var validSecret corev1.Secret
+	exists := false
 	if err := kubeclient.Get(ctx, nodeSecretObjectkey, &validSecret); err == nil {
-		return &validSecret, true, nil
+		if validSecret.ObjectMeta.Annotations[vmprovider.ConfigHashAnnotation] == configHash {
+			return &validSecret, true, nil
+		}
+		exists = true
 	} else if client.IgnoreNotFound(err) != nil {
 		return nil, false, err
 	}
@@ -118,11 +129,23 @@ func CreateCloudInitSecret(ctx context.Context,
 	}
 	cloudConfig.DockerLoginCmd = dockerLoginCmd
 
-	// If secret was not found, then create the secret.
+	// If secret was not found or is outdated, then generate the secret.
 	cloudInitSecret, err := cloudinit.CreateCloudInitConfigSecret(cloudConfig)
 	if err != nil {
 		return nil, false, err
 	}
+	cloudInitSecret.ObjectMeta.Annotations = map[string]string{
+		vmprovider.ConfigHashAnnotation: configHash,
+	}
+
+	// Update the outdated secret, VMs already deployed using it are left untouched.
+	if exists {
+		cloudInitSecret.ObjectMeta.ResourceVersion = validSecret.ObjectMeta.ResourceVersion
+		if err = kubeclient.Update(ctx, cloudInitSecret); err != nil {
+			return nil, true, err
+		}
+		return cloudInitSecret, true, nil
+	}
 
 	// create the secret.
 	if err = kubeclient.Create(ctx, cloudInitSecret); err != nil {
@@ -141,11 +164,21 @@ func DeleteAllCloudInitSecret(ctx context.Context,
 		return err
 	}
 
-	// Delete worker config secret.
-	err = deleteSecret(ctx, kubeclient, namespace, clusterName+WorkerNodeSecretSuffix)
+	// Delete worker config secrets of all zones.
+	secrets := &corev1.SecretList{}
+	err = kubeclient.List(ctx, secrets, client.InNamespace(namespace),
+		client.MatchingLabels(vmprovider.GetClusterLabels(clusterName)))
 	if err != nil {
 		return err
 	}
+	for i := range secrets.Items {
+		if !strings.HasPrefix(secrets.Items[i].Name, clusterName+WorkerNodeSecretSuffix) {
+			continue
+		}
+		if err = kubeclient.Delete(ctx, &secrets.Items[i]); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
 
 	// Delete CA cert secret.
 	err = deleteSecret(ctx, kubeclient, namespace, clusterName+tls.RootCaSecretSuffix)
@@ -432,6 +465,7 @@ func getOrCreatePrivateKeySecret(ctx context.Context,
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      GetSshKeysSecretName(name),
 			Namespace: namespace,
+			Labels:    vmprovider.GetClusterLabels(name),
 		},
 		StringData: dataMap,
 	}
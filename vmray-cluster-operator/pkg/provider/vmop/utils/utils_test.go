@@ -9,6 +9,7 @@ import (
 	"regexp"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -240,8 +241,8 @@ func cloudInitSecretCreationTests() {
 							MinWorkers: 1,
 							MaxWorkers: 3,
 							Resources: vmrayv1alpha1.NodeResource{
-								CPU:    2,
-								Memory: 1024,
+								CPU:    resource.NewQuantity(2, resource.DecimalSI),
+								Memory: resource.NewQuantity(1024, resource.BinarySI),
 							},
 						},
 					},
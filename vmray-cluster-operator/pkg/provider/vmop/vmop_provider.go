@@ -7,6 +7,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
+	"sort"
 
 	"github.com/go-logr/logr"
 	vmopv1 "github.com/vmware-tanzu/vm-operator/api/v1alpha2"
@@ -15,7 +17,9 @@ import (
 	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/vmop/cloudinit"
 	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/vmop/translator"
 	vmoputils "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/vmop/utils"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -29,12 +33,16 @@ const (
 	SshPort                 int32  = 22
 	RayClientPortName       string = "ray-client-port"
 	RayClientPort           int32  = 10001
+	RayServePortName        string = "ray-serve-port"
+	RayServePort            int32  = 8000
 	Protocol_TCP                   = "TCP"
 )
 
 type VmOperatorProvider struct {
 	kubeClient client.Client
 	log        logr.Logger
+	// indexed is set once VMs are indexed by cluster in client's cache.
+	indexed bool
 }
 
 func NewVmOperatorProvider(kubeClient client.Client) *VmOperatorProvider {
@@ -44,9 +52,30 @@ func NewVmOperatorProvider(kubeClient client.Client) *VmOperatorProvider {
 	}
 }
 
+// ManagedObjectLists returns lists of the kinds of objects the provider labels with
+// their cluster, letting objects orphaned by deleted clusters be garbage collected.
+func (vmopprovider *VmOperatorProvider) ManagedObjectLists() []client.ObjectList {
+	return []client.ObjectList{
+		&vmopv1.VirtualMachineList{},
+		&vmopv1.VirtualMachineServiceList{},
+		&corev1.SecretList{},
+	}
+}
+
+// SetupFieldIndexer indexes VMs by their cluster in manager's cache, letting
+// ListClusterVms list VMs of a cluster by the index instead of a label selector.
+func (vmopprovider *VmOperatorProvider) SetupFieldIndexer(ctx context.Context, indexer client.FieldIndexer) error {
+	if err := indexer.IndexField(ctx, &vmopv1.VirtualMachine{}, provider.ClusterIndexField, provider.IndexByCluster); err != nil {
+		return err
+	}
+	vmopprovider.indexed = true
+	return nil
+}
+
 func (vmopprovider *VmOperatorProvider) Deploy(ctx context.Context, req provider.VmDeploymentRequest) error {
 
-	annotationmap := make(map[string]string)
+	// Label VM with its cluster & role to list VMs of a cluster at once.
+	annotationmap := provider.GetNodeLabels(req)
 
 	// Step 1:
 	// a. Create k8s service account, when its head node deployment.
@@ -75,8 +104,12 @@ func (vmopprovider *VmOperatorProvider) Deploy(ctx context.Context, req provider
 	}
 
 	// Step 3: Get VM CRD obj ref using translator functon while consuming VmInfo.
+	// Record hash of node's configuration to detect drift on spec changes.
+	annotations := map[string]string{
+		provider.ConfigHashAnnotation: provider.GetNodeConfigHash(req),
+	}
 	vm, err := translator.TranslateToVmCRD(req.Namespace,
-		req.VmName, secret.ObjectMeta.Name, annotationmap, vmclass, req.NodeConfig)
+		req.VmName, secret.ObjectMeta.Name, annotationmap, annotations, vmclass, req.Zone, req.NodeConfig)
 	if err != nil {
 		errmsg := fmt.Sprintf("Failure while translating VM info to VM CRD for %s:%s", req.Namespace, req.VmName)
 		vmopprovider.log.Error(err, errmsg)
@@ -88,6 +121,179 @@ func (vmopprovider *VmOperatorProvider) Deploy(ctx context.Context, req provider
 	return vmopprovider.kubeClient.Create(ctx, vm)
 }
 
+// Resize powers off the node's VM, switches it to the VM class of its node type and
+// powers it back on, as vm-operator only allows changing class of a powered off VM.
+// Each call progresses the resize by a step, it returns true once VM is powered on
+// with the new VM class.
+func (vmopprovider *VmOperatorProvider) Resize(ctx context.Context, req provider.VmDeploymentRequest) (bool, error) {
+	vmclass, err := getVmClass(req.NodeType, req.NodeConfig)
+	if err != nil {
+		return false, err
+	}
+
+	key := client.ObjectKey{
+		Namespace: req.Namespace,
+		Name:      req.VmName,
+	}
+	vm := &vmopv1.VirtualMachine{}
+	if err := vmopprovider.kubeClient.Get(ctx, key, vm); err != nil {
+		return false, err
+	}
+
+	patch := client.MergeFrom(vm.DeepCopy())
+	switch {
+	case vm.Spec.ClassName == vmclass:
+		// Class is switched, wait for VM to be powered back on.
+		if vm.Spec.PowerState == vmopv1.VirtualMachinePowerStateOn {
+			return vm.Status.PowerState == vmopv1.VirtualMachinePowerStateOn, nil
+		}
+		vm.Spec.PowerState = vmopv1.VirtualMachinePowerStateOn
+	case vm.Spec.PowerState != vmopv1.VirtualMachinePowerStateOff:
+		vmopprovider.log.Info("Powering off VM to resize it", "vm", req.VmName, "vm class", vmclass)
+		vm.Spec.PowerState = vmopv1.VirtualMachinePowerStateOff
+	case vm.Status.PowerState != vmopv1.VirtualMachinePowerStateOff:
+		// Wait for VM to be powered off.
+		return false, nil
+	default:
+		vmopprovider.log.Info("Switching VM class & powering on VM", "vm", req.VmName, "vm class", vmclass)
+		vm.Spec.ClassName = vmclass
+		vm.Spec.PowerState = vmopv1.VirtualMachinePowerStateOn
+		if vm.ObjectMeta.Annotations == nil {
+			vm.ObjectMeta.Annotations = make(map[string]string)
+		}
+		vm.ObjectMeta.Annotations[provider.ConfigHashAnnotation] = provider.GetNodeConfigHash(req)
+	}
+	return false, vmopprovider.kubeClient.Patch(ctx, vm, patch)
+}
+
+// AdoptVm takes over an existing VM, e.g. deployed by `ray up` with the vSphere provider,
+// as the requested node. VM is labeled & annotated like VMs deployed by Deploy, so it's
+// managed like them from then on without being recreated.
+func (vmopprovider *VmOperatorProvider) AdoptVm(ctx context.Context,
+	req provider.VmDeploymentRequest) (*vmrayv1alpha1.VMRayNodeStatus, error) {
+
+	key := client.ObjectKey{
+		Namespace: req.Namespace,
+		Name:      req.VmName,
+	}
+	vm := &vmopv1.VirtualMachine{}
+	if err := vmopprovider.kubeClient.Get(ctx, key, vm); err != nil {
+		return nil, err
+	}
+
+	// Step 1: Verify VM isn't managed by another cluster & matches node's config.
+	if clustername, ok := vm.ObjectMeta.Labels[provider.ClusterLabel]; ok && clustername != req.ClusterName {
+		return nil, fmt.Errorf("%w: VM %s belongs to cluster %s", provider.ErrAdoptionMismatch, req.VmName, clustername)
+	}
+	if vm.Spec.ImageName != req.NodeConfig.VMImage {
+		return nil, fmt.Errorf("%w: VM %s has image %s instead of %s", provider.ErrAdoptionMismatch,
+			req.VmName, vm.Spec.ImageName, req.NodeConfig.VMImage)
+	}
+	if req.NodeType == "" {
+		req.NodeType = getWorkerNodeType(vm.Spec.ClassName, req)
+	}
+	vmclass, err := getVmClass(req.NodeType, req.NodeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("%w: no node type has VM class %s of VM %s", provider.ErrAdoptionMismatch,
+			vm.Spec.ClassName, req.VmName)
+	}
+	if vm.Spec.ClassName != vmclass {
+		return nil, fmt.Errorf("%w: VM %s has VM class %s instead of %s", provider.ErrAdoptionMismatch,
+			req.VmName, vm.Spec.ClassName, vmclass)
+	}
+
+	// Step 2: Label VM with its cluster & role and mark it as adopted, as it
+	// wasn't deployed with node's configuration.
+	patch := client.MergeFrom(vm.DeepCopy())
+	if vm.ObjectMeta.Labels == nil {
+		vm.ObjectMeta.Labels = make(map[string]string)
+	}
+	for k, v := range provider.GetNodeLabels(req) {
+		vm.ObjectMeta.Labels[k] = v
+	}
+	if req.HeadNodeStatus == nil {
+		vm.ObjectMeta.Labels[HeadVMServiceAnnotation] = req.VmName
+	}
+	if vm.ObjectMeta.Annotations == nil {
+		vm.ObjectMeta.Annotations = make(map[string]string)
+	}
+	vm.ObjectMeta.Annotations[provider.ConfigHashAnnotation] = provider.AdoptedConfigHash
+	if err := vmopprovider.kubeClient.Patch(ctx, vm, patch); err != nil {
+		return nil, err
+	}
+
+	status := translator.ExtractVmStatus(vm)
+	status.NodeType = req.NodeType
+	return status, nil
+}
+
+// getWorkerNodeType returns name of the worker node type with the given VM class,
+// node types are sorted to pick the same one for VMs of the same class.
+func getWorkerNodeType(vmclass string, req provider.VmDeploymentRequest) string {
+	names := make([]string, 0, len(req.NodeConfig.NodeTypes))
+	for name, nt := range req.NodeConfig.NodeTypes {
+		if nt.VMClass == vmclass && name != req.HeadNodeConfig.NodeType {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	return names[0]
+}
+
+// AdoptVmService labels the named existing VM service like head node's VM service
+// deployed by DeployVmService & returns its ingress IP.
+func (vmopprovider *VmOperatorProvider) AdoptVmService(ctx context.Context,
+	req provider.VmDeploymentRequest, name string) (string, error) {
+
+	key := client.ObjectKey{
+		Namespace: req.Namespace,
+		Name:      name,
+	}
+	vmservice := &vmopv1.VirtualMachineService{}
+	if err := vmopprovider.kubeClient.Get(ctx, key, vmservice); err != nil {
+		return "", err
+	}
+	if clustername, ok := vmservice.ObjectMeta.Labels[provider.ClusterLabel]; ok && clustername != req.ClusterName {
+		return "", fmt.Errorf("%w: VM service %s belongs to cluster %s", provider.ErrAdoptionMismatch, name, clustername)
+	}
+
+	patch := client.MergeFrom(vmservice.DeepCopy())
+	if vmservice.ObjectMeta.Labels == nil {
+		vmservice.ObjectMeta.Labels = make(map[string]string)
+	}
+	vmservice.ObjectMeta.Labels[provider.ClusterLabel] = req.ClusterName
+	vmservice.ObjectMeta.Labels[provider.NounceLabel] = req.Nounce
+	if err := vmopprovider.kubeClient.Patch(ctx, vmservice, patch); err != nil {
+		return "", err
+	}
+
+	ingress := vmservice.Status.LoadBalancer.Ingress
+	if len(ingress) > 0 {
+		return ingress[0].IP, nil
+	}
+	return "", nil
+}
+
+func (vmopprovider *VmOperatorProvider) FindVms(ctx context.Context,
+	namespace string, selector labels.Selector) ([]string, error) {
+
+	vms := &vmopv1.VirtualMachineList{}
+	if err := vmopprovider.kubeClient.List(ctx, vms, client.InNamespace(namespace),
+		client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, vm := range vms.Items {
+		if _, ok := vm.ObjectMeta.Labels[provider.ClusterLabel]; !ok {
+			names = append(names, vm.ObjectMeta.Name)
+		}
+	}
+	return names, nil
+}
+
 func getVmClass(nodetype string, nodeconfig vmrayv1alpha1.CommonNodeConfig) (string, error) {
 	if nt, ok := nodeconfig.NodeTypes[nodetype]; ok {
 		return nt.VMClass, nil
@@ -161,8 +367,30 @@ func (vmopprovider *VmOperatorProvider) FetchVmStatus(ctx context.Context,
 	return translator.ExtractVmStatus(vm), nil
 }
 
+func (vmopprovider *VmOperatorProvider) ListClusterVms(ctx context.Context,
+	namespace, clustername string) (map[string]provider.ClusterVm, error) {
+
+	opts := []client.ListOption{client.InNamespace(namespace)}
+	if vmopprovider.indexed {
+		opts = append(opts, client.MatchingFields{provider.ClusterIndexField: clustername})
+	} else {
+		opts = append(opts, client.MatchingLabels{provider.ClusterLabel: clustername})
+	}
+	vms := &vmopv1.VirtualMachineList{}
+	if err := vmopprovider.kubeClient.List(ctx, vms, opts...); err != nil {
+		return nil, err
+	}
+
+	clusterVms := make(map[string]provider.ClusterVm, len(vms.Items))
+	for i := range vms.Items {
+		vm := &vms.Items[i]
+		clusterVms[vm.ObjectMeta.Name] = provider.NewClusterVm(vm, translator.ExtractVmStatus(vm))
+	}
+	return clusterVms, nil
+}
+
 func createVMService(ctx context.Context, kubeclient client.Client, namespace, name string,
-	ports map[string]int32, selector map[string]string) error {
+	ports map[string]int32, selector, labels map[string]string) error {
 
 	vmserviceport := []vmopv1.VirtualMachineServicePort{}
 	for n, p := range ports {
@@ -223,9 +451,14 @@ func (vmopprovider *VmOperatorProvider) DeployVmService(ctx context.Context,
 			// moving forward give users ability to pass it via CRD.
 			ports[RayDashboardPortName] = RayDashboardPort
 			ports[RayClientPortName] = RayClientPort
+			ports[RayServePortName] = RayServePort
 			ports[SshPortName] = SshPort
 
-			err = createVMService(ctx, vmopprovider.kubeClient, req.Namespace, headvmname, ports, annotationmap)
+			// Label VM service with cluster & nounce of its head node like the VM.
+			labels := provider.GetClusterLabels(req.ClusterName)
+			labels[provider.NounceLabel] = req.Nounce
+
+			err = createVMService(ctx, vmopprovider.kubeClient, req.Namespace, headvmname, ports, annotationmap, labels)
 			if err != nil {
 				vmopprovider.log.Error(err, "Failed to create VM service")
 				return "", err
@@ -241,3 +474,53 @@ func (vmopprovider *VmOperatorProvider) DeployVmService(ctx context.Context,
 	vmopprovider.log.Info("VM service IP is not assigned for ray head node", "vm", headvmname)
 	return "", errors.New("Head node VM service IP is not assigned")
 }
+
+func (vmopprovider *VmOperatorProvider) DeployServeService(ctx context.Context,
+	namespace, name, headVmName string) (string, error) {
+
+	selector := map[string]string{
+		HeadVMServiceAnnotation: headVmName,
+	}
+
+	key := client.ObjectKey{
+		Namespace: namespace,
+		Name:      name,
+	}
+
+	// Create the serve VM service if it doesn't exist.
+	vmservice := &vmopv1.VirtualMachineService{}
+	if err := vmopprovider.kubeClient.Get(ctx, key, vmservice); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return "", err
+		}
+		ports := map[string]int32{
+			RayServePortName: RayServePort,
+		}
+		if err := createVMService(ctx, vmopprovider.kubeClient, namespace, name, ports, selector, nil); err != nil {
+			vmopprovider.log.Error(err, "Failed to create serve VM service")
+			return "", err
+		}
+		return "", nil
+	}
+
+	// Point the service to the provided head node, this switches
+	// traffic without changing service's ingress IP.
+	if !reflect.DeepEqual(vmservice.Spec.Selector, selector) {
+		vmopprovider.log.Info("Switching serve VM service", "service", name, "head vm", headVmName)
+		patch := client.MergeFrom(vmservice.DeepCopy())
+		vmservice.Spec.Selector = selector
+		if err := vmopprovider.kubeClient.Patch(ctx, vmservice, patch); err != nil {
+			return "", err
+		}
+	}
+
+	ingress := vmservice.Status.LoadBalancer.Ingress
+	if len(ingress) > 0 {
+		return ingress[0].IP, nil
+	}
+	return "", nil
+}
+
+func (vmopprovider *VmOperatorProvider) DeleteServeService(ctx context.Context, namespace, name string) error {
+	return deleteVMService(ctx, vmopprovider.kubeClient, namespace, name)
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package vmop_test
+
+import (
+	"context"
+
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	vmopv1 "github.com/vmware-tanzu/vm-operator/api/v1alpha2"
+
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/conformance"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/vmop"
+	tls_utils "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/vmop/tls"
+)
+
+const (
+	conformanceNamespace   = "namespace-conformance"
+	conformanceClusterName = "conformance-cluster"
+)
+
+// VmOpProviderConformanceTests runs provider conformance suite against vm-operator
+// provider. envtest doesn't run vm-operator, so VM & VM service status is patched.
+func VmOpProviderConformanceTests() {
+	conformance.ProviderConformanceTests(func() conformance.Harness {
+		ctx := context.Background()
+		k8sClient := suite.GetK8sClient()
+
+		nsSpec := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: conformanceNamespace}}
+		Expect(client.IgnoreAlreadyExists(k8sClient.Create(ctx, nsSpec))).To(Succeed())
+		Expect(tls_utils.CreateVMRayClusterRootSecret(ctx, k8sClient, conformanceNamespace, conformanceClusterName)).To(Succeed())
+
+		return conformance.Harness{
+			Provider:    vmop.NewVmOperatorProvider(k8sClient),
+			Namespace:   conformanceNamespace,
+			ClusterName: conformanceClusterName,
+			MakeVmReady: func(ctx context.Context, namespace, name string) {
+				vm := &vmopv1.VirtualMachine{}
+				Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, vm)).To(Succeed())
+				vm.Status.PowerState = vmopv1.VirtualMachinePowerStateOn
+				vm.Status.Network = &vmopv1.VirtualMachineNetworkStatus{PrimaryIP4: "10.10.10.10"}
+				Expect(k8sClient.Status().Update(ctx, vm)).To(Succeed())
+			},
+			MakeVmServiceReady: func(ctx context.Context, namespace, name string) {
+				vmservice := &vmopv1.VirtualMachineService{}
+				Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, vmservice)).To(Succeed())
+				vmservice.Status.LoadBalancer.Ingress = []vmopv1.LoadBalancerIngress{{IP: "192.10.10.1"}}
+				Expect(k8sClient.Status().Update(ctx, vmservice)).To(Succeed())
+			},
+		}
+	})
+}
@@ -18,5 +18,8 @@ var _ = BeforeSuite(suite.BeforeSuite)
 var _ = AfterSuite(suite.AfterSuite)
 
 func TestUtils(t *testing.T) {
-	suite.Register(t, "Unit testcases to validate Vm provider", VmOpProviderTests)
+	suite.Register(t, "Unit testcases to validate Vm provider", func() {
+		VmOpProviderTests()
+		VmOpProviderConformanceTests()
+	})
 }
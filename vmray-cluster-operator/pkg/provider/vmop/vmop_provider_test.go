@@ -5,13 +5,16 @@ package vmop_test
 
 import (
 	"context"
+	"errors"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 
 	vmopv1 "github.com/vmware-tanzu/vm-operator/api/v1alpha2"
@@ -20,6 +23,7 @@ import (
 	vmprovider "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider"
 	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/vmop"
 	tls_utils "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/vmop/tls"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/vmop/translator"
 	vmoputils "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/vmop/utils"
 )
 
@@ -59,6 +63,7 @@ func VmOpProviderTests() {
 					EnableTLS:      true,
 					NodeType:       "ray_head",
 					HeadNodeStatus: nil,
+					Zone:           "zone-a",
 					// Head & common node configs.
 					HeadNodeConfig: vmrayv1alpha1.HeadNodeConfig{},
 					NodeConfig: vmrayv1alpha1.CommonNodeConfig{
@@ -67,14 +72,19 @@ func VmOpProviderTests() {
 						VMImage:            "vmi-00001",
 						StorageClass:       "storage-default",
 						MaxWorkers:         3,
+						Zones:              []string{"zone-a", "zone-b"},
+						ResourcePolicy: &vmrayv1alpha1.ResourcePolicyReference{
+							Name:               "ray-policy",
+							ClusterModuleGroup: "ray-nodes",
+						},
 						NodeTypes: map[string]vmrayv1alpha1.NodeType{
 							"worker_1": {
 								VMClass:    "best-effort-xsmall",
 								MaxWorkers: 5,
 								MinWorkers: 3,
 								Resources: vmrayv1alpha1.NodeResource{
-									CPU:    4,
-									Memory: 1000,
+									CPU:    resource.NewQuantity(4, resource.DecimalSI),
+									Memory: resource.NewQuantity(1000, resource.BinarySI),
 								},
 							},
 							"ray_head": {
@@ -96,12 +106,51 @@ func VmOpProviderTests() {
 				err = k8sClient.Get(ctx, vmNamespaceName, vminstance)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(vminstance.Spec.ClassName).To(Equal("best-effort-xlarge"))
+				Expect(vminstance.ObjectMeta.Labels).To(HaveKeyWithValue(vmprovider.ClusterLabel, clustername))
+				Expect(vminstance.ObjectMeta.Labels).To(HaveKeyWithValue(vmprovider.NodeRoleLabel, vmprovider.NodeRoleHead))
+				Expect(vminstance.ObjectMeta.Labels).To(HaveKeyWithValue(corev1.LabelTopologyZone, "zone-a"))
+				Expect(vminstance.ObjectMeta.Annotations).To(HaveKeyWithValue(translator.ClusterModuleGroupAnnotation, "ray-nodes"))
+				Expect(vminstance.Spec.Reserved).ToNot(BeNil())
+				Expect(vminstance.Spec.Reserved.ResourcePolicyName).To(Equal("ray-policy"))
+
+				// 2. Fetch VM status, on its own & along with other VMs of the cluster.
+				vminstance.Status.Zone = "zone-a"
+				vminstance.Status.Host = "esx-01"
+				Expect(k8sClient.Status().Update(ctx, vminstance)).To(Succeed())
+				status, err := provider.FetchVmStatus(ctx, namespace, vmname)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(status.Zone).To(Equal("zone-a"))
+				Expect(status.Host).To(Equal("esx-01"))
+				statuses, err := provider.ListClusterVms(ctx, namespace, clustername)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(statuses).To(HaveKey(vmname))
 
-				// 2. Fetch VM status.
-				_, err = provider.FetchVmStatus(ctx, namespace, vmname)
+				// 3. Resize VM in place to a new VM class.
+				vmDeploymentRequest.NodeConfig.NodeTypes["ray_head"] = vmrayv1alpha1.NodeType{VMClass: "best-effort-2xlarge"}
+				done, err := provider.Resize(ctx, vmDeploymentRequest)
 				Expect(err).ToNot(HaveOccurred())
+				Expect(done).To(BeFalse())
+				Expect(k8sClient.Get(ctx, vmNamespaceName, vminstance)).To(Succeed())
+				Expect(vminstance.Spec.PowerState).To(Equal(vmopv1.VirtualMachinePowerStateOff))
+				Expect(vminstance.Spec.ClassName).To(Equal("best-effort-xlarge"))
 
-				// 3. Validate aux resources exists and later delete them.
+				vminstance.Status.PowerState = vmopv1.VirtualMachinePowerStateOff
+				Expect(k8sClient.Status().Update(ctx, vminstance)).To(Succeed())
+				done, err = provider.Resize(ctx, vmDeploymentRequest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(done).To(BeFalse())
+				Expect(k8sClient.Get(ctx, vmNamespaceName, vminstance)).To(Succeed())
+				Expect(vminstance.Spec.PowerState).To(Equal(vmopv1.VirtualMachinePowerStateOn))
+				Expect(vminstance.Spec.ClassName).To(Equal("best-effort-2xlarge"))
+				Expect(vminstance.ObjectMeta.Annotations[vmprovider.ConfigHashAnnotation]).To(Equal(vmprovider.GetNodeConfigHash(vmDeploymentRequest)))
+
+				vminstance.Status.PowerState = vmopv1.VirtualMachinePowerStateOn
+				Expect(k8sClient.Status().Update(ctx, vminstance)).To(Succeed())
+				done, err = provider.Resize(ctx, vmDeploymentRequest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(done).To(BeTrue())
+
+				// 4. Validate aux resources exists and later delete them.
 				exists, err := checkIfSecretExists(k8sClient, namespace, clustername+vmoputils.HeadNodeSecretSuffix)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(exists).To(BeTrue())
@@ -113,11 +162,119 @@ func VmOpProviderTests() {
 				Expect(err).ToNot(HaveOccurred())
 				Expect(exists).To(BeFalse())
 
-				// 4. Delete VM and its VM Service.
+				// 5. Delete VM and its VM Service.
 				err = provider.Delete(ctx, namespace, vmname)
 				Expect(err).ToNot(HaveOccurred())
 			})
 		})
+
+		Context("Validate AdoptVm, AdoptVmService and FindVms", func() {
+			ctx := context.Background()
+
+			It("Adopts VMs deployed outside of the operator matching node's config", func() {
+				k8sClient := suite.GetK8sClient()
+				provider := vmop.NewVmOperatorProvider(k8sClient)
+				nsSpec := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
+				Expect(client.IgnoreAlreadyExists(k8sClient.Create(ctx, nsSpec))).To(Succeed())
+
+				// VMs & VM service deployed by `ray up`.
+				newVm := func(name, vmclass string) *vmopv1.VirtualMachine {
+					vm := &vmopv1.VirtualMachine{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      name,
+							Namespace: namespace,
+							Labels:    map[string]string{"ray-cluster-name": "rayup"},
+						},
+						Spec: vmopv1.VirtualMachineSpec{
+							ImageName:    "vmi-00001",
+							ClassName:    vmclass,
+							StorageClass: "storage-default",
+						},
+					}
+					Expect(k8sClient.Create(ctx, vm)).To(Succeed())
+					return vm
+				}
+				newVm("rayup-head", "best-effort-xlarge")
+				newVm("rayup-worker", "best-effort-xsmall")
+				newVm("rayup-worker-gpu", "best-effort-gpu")
+				vmservice := &vmopv1.VirtualMachineService{
+					ObjectMeta: metav1.ObjectMeta{Name: "rayup-head-svc", Namespace: namespace},
+					Spec: vmopv1.VirtualMachineServiceSpec{
+						Type:     vmopv1.VirtualMachineServiceTypeLoadBalancer,
+						Selector: map[string]string{"ray-cluster-name": "rayup"},
+						Ports:    []vmopv1.VirtualMachineServicePort{{Name: "ray", Protocol: "TCP", Port: 6379, TargetPort: 6379}},
+					},
+				}
+				Expect(k8sClient.Create(ctx, vmservice)).To(Succeed())
+
+				req := vmprovider.VmDeploymentRequest{
+					Namespace:      namespace,
+					ClusterName:    "adopting-cluster",
+					Nounce:         "abcde",
+					VmName:         "rayup-head",
+					NodeType:       "ray_head",
+					HeadNodeConfig: vmrayv1alpha1.HeadNodeConfig{NodeType: "ray_head"},
+					NodeConfig: vmrayv1alpha1.CommonNodeConfig{
+						VMImage: "vmi-00001",
+						NodeTypes: map[string]vmrayv1alpha1.NodeType{
+							"ray_head": {VMClass: "best-effort-xlarge"},
+							"worker_1": {VMClass: "best-effort-xsmall"},
+						},
+					},
+				}
+
+				// 1. Head VM is labeled & annotated like deployed head VMs.
+				status, err := provider.AdoptVm(ctx, req)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(status.NodeType).To(Equal("ray_head"))
+				vm := &vmopv1.VirtualMachine{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "rayup-head"}, vm)).To(Succeed())
+				Expect(vm.ObjectMeta.Labels).To(HaveKeyWithValue(vmprovider.ClusterLabel, "adopting-cluster"))
+				Expect(vm.ObjectMeta.Labels).To(HaveKeyWithValue(vmprovider.NodeRoleLabel, vmprovider.NodeRoleHead))
+				Expect(vm.ObjectMeta.Labels).To(HaveKeyWithValue(vmop.HeadVMServiceAnnotation, "rayup-head"))
+				Expect(vm.ObjectMeta.Labels).To(HaveKeyWithValue("ray-cluster-name", "rayup"))
+				Expect(vm.ObjectMeta.Annotations[vmprovider.ConfigHashAnnotation]).To(Equal(vmprovider.AdoptedConfigHash))
+
+				// 2. VM service is labeled with the cluster.
+				ip, err := provider.AdoptVmService(ctx, req, "rayup-head-svc")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ip).To(BeEmpty())
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "rayup-head-svc"}, vmservice)).To(Succeed())
+				Expect(vmservice.ObjectMeta.Labels).To(HaveKeyWithValue(vmprovider.ClusterLabel, "adopting-cluster"))
+
+				// 3. Only VMs not yet labeled with a cluster are found.
+				selector, err := labels.Parse("ray-cluster-name=rayup")
+				Expect(err).ToNot(HaveOccurred())
+				names, err := provider.FindVms(ctx, namespace, selector)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(names).To(ConsistOf("rayup-worker", "rayup-worker-gpu"))
+
+				// 4. Worker's node type is resolved from its VM class.
+				req.VmName = "rayup-worker"
+				req.NodeType = ""
+				req.HeadNodeStatus = status
+				status, err = provider.AdoptVm(ctx, req)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(status.NodeType).To(Equal("worker_1"))
+
+				// 5. VM whose class doesn't match any node type isn't adopted.
+				req.VmName = "rayup-worker-gpu"
+				req.NodeType = ""
+				_, err = provider.AdoptVm(ctx, req)
+				Expect(errors.Is(err, vmprovider.ErrAdoptionMismatch)).To(BeTrue())
+
+				// 6. VM of another cluster isn't adopted.
+				req.VmName = "rayup-worker"
+				req.ClusterName = "another-cluster"
+				_, err = provider.AdoptVm(ctx, req)
+				Expect(errors.Is(err, vmprovider.ErrAdoptionMismatch)).To(BeTrue())
+
+				for _, name := range []string{"rayup-head", "rayup-worker", "rayup-worker-gpu"} {
+					Expect(provider.Delete(ctx, namespace, name)).To(Succeed())
+				}
+				Expect(k8sClient.Delete(ctx, vmservice)).To(Succeed())
+			})
+		})
 	})
 }
 
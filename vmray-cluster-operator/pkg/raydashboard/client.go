@@ -0,0 +1,185 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+Package raydashboard is a client of the REST APIs served by ray dashboard on the
+head node, i.e. cluster status, nodes, logs, jobs & serve APIs.
+
+Ray dashboard is served over plain http, enable_tls only secures ray's grpc
+channels, so the client doesn't support https.
+
+Idempotent requests (GET, PUT & DELETE) failing due to network errors or server
+side errors are retried with exponential backoff, while POST requests such as job
+submission are never retried as ray may have already acted on them.
+*/
+package raydashboard
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	// DefaultPort is the port ray dashboard & jobs REST APIs are served on.
+	DefaultPort = 8265
+
+	defaultTimeout      = 10 * time.Second
+	defaultMaxRetries   = 2
+	defaultRetryBackoff = 500 * time.Millisecond
+)
+
+type Options struct {
+	// Timeout of a single request attempt.
+	Timeout time.Duration
+	// Number of times a failed idempotent request is retried.
+	MaxRetries int
+	// Delay before the first retry, doubled for every subsequent retry.
+	RetryBackoff time.Duration
+}
+
+// DefaultOptions returns options used by `NewClient`.
+func DefaultOptions() Options {
+	return Options{
+		Timeout:      defaultTimeout,
+		MaxRetries:   defaultMaxRetries,
+		RetryBackoff: defaultRetryBackoff,
+	}
+}
+
+// Client talks to the ray dashboard REST APIs exposed by a ray head node.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	options    Options
+}
+
+// NewClient creates a dashboard client with default options for given
+// address, e.g. `http://10.0.0.1:8265`.
+func NewClient(address string) *Client {
+	return NewClientWithOptions(address, DefaultOptions())
+}
+
+// NewClientWithOptions creates a dashboard client for given address & options.
+func NewClientWithOptions(address string, options Options) *Client {
+	return &Client{
+		baseURL:    address,
+		httpClient: &http.Client{Timeout: options.Timeout},
+		options:    options,
+	}
+}
+
+// GetDashboardAddress returns the plain http dashboard address for provided head IP.
+func GetDashboardAddress(ip string) string {
+	return fmt.Sprintf("http://%s:%d", ip, DefaultPort)
+}
+
+// IsNotFound returns true if error is caused by dashboard responding with 404.
+func IsNotFound(err error) bool {
+	var reqErr *requestError
+	return errors.As(err, &reqErr) && reqErr.statusCode == http.StatusNotFound
+}
+
+// do sends a JSON request and decodes JSON response into `out` when it's non nil.
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	body, err := c.doRaw(ctx, method, path, in)
+	if err != nil || out == nil {
+		return err
+	}
+	return json.Unmarshal(body, out)
+}
+
+// doRaw sends a JSON request, retrying idempotent ones, and returns raw response body.
+func (c *Client) doRaw(ctx context.Context, method, path string, in interface{}) ([]byte, error) {
+	var data []byte
+	if in != nil {
+		var err error
+		if data, err = json.Marshal(in); err != nil {
+			return nil, err
+		}
+	}
+
+	retries := 0
+	if method != http.MethodPost {
+		retries = c.options.MaxRetries
+	}
+	backoff := c.options.RetryBackoff
+
+	for attempt := 0; ; attempt++ {
+		body, err := c.send(ctx, method, path, data)
+		if err == nil || attempt >= retries || !isRetryable(err) {
+			return body, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = backoff * 2
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, path string, data []byte) ([]byte, error) {
+	var reqBody io.Reader
+	if data != nil {
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if data != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, &requestError{
+			method:     method,
+			path:       path,
+			statusCode: resp.StatusCode,
+			body:       string(body),
+		}
+	}
+	return body, nil
+}
+
+// isRetryable returns true for transport errors and server side errors.
+func isRetryable(err error) bool {
+	var reqErr *requestError
+	if !errors.As(err, &reqErr) {
+		// Context cancellation is final, everything else is a transport error.
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	return reqErr.statusCode >= http.StatusInternalServerError || reqErr.statusCode == http.StatusTooManyRequests
+}
+
+type requestError struct {
+	method     string
+	path       string
+	statusCode int
+	body       string
+}
+
+func (e *requestError) Error() string {
+	return fmt.Sprintf("ray dashboard request %s %s failed with status %d: %s",
+		e.method, e.path, e.statusCode, e.body)
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package raydashboard_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/raydashboard"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/raydashboard/fake"
+)
+
+const (
+	jobsResponse = `[
+  {"type": "SUBMISSION", "job_id": "02000000", "submission_id": "raysubmit_1", "status": "RUNNING", "entrypoint": "python a.py"},
+  {"type": "SUBMISSION", "job_id": "01000000", "submission_id": "raysubmit_0", "status": "SUCCEEDED", "entrypoint": "python b.py"},
+  {"type": "DRIVER", "job_id": "03000000", "submission_id": null, "status": "RUNNING", "entrypoint": ""},
+  {"type": "DRIVER", "job_id": "04000000", "submission_id": null, "status": "FAILED", "entrypoint": ""},
+  {"type": "SUBMISSION", "job_id": "05000000", "submission_id": "vmray-drain-n1", "status": "RUNNING", "entrypoint": "ray drain-node",
+   "metadata": {"vmray.broadcom.com/operator-job": "drain"}}
+]`
+)
+
+func dashboardClientTests() {
+	ctx := context.Background()
+
+	Describe("Jobs API", func() {
+		It("Lists jobs and counts active ones", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				Expect(r.Method).To(Equal(http.MethodGet))
+				Expect(r.URL.Path).To(Equal("/api/jobs/"))
+				_, _ = w.Write([]byte(jobsResponse))
+			}))
+			defer server.Close()
+
+			jobs, err := raydashboard.NewClient(server.URL).ListJobs(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(jobs).To(HaveLen(5))
+			Expect(jobs[0].SubmissionID).To(Equal("raysubmit_1"))
+			Expect(jobs[2].SubmissionID).To(BeEmpty())
+
+			submissions, drivers := raydashboard.CountActiveJobs(jobs)
+			Expect(submissions).To(Equal(1))
+			Expect(drivers).To(Equal(1))
+		})
+
+		It("Returns error on non successful response", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte("boom"))
+			}))
+			defer server.Close()
+
+			_, err := raydashboard.NewClient(server.URL).ListJobs(ctx)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("failed with status 500: boom"))
+			Expect(raydashboard.IsNotFound(err)).To(BeFalse())
+		})
+
+		It("Submits a job and tracks its status & logs", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				switch {
+				case r.Method == http.MethodPost && r.URL.Path == "/api/jobs/":
+					request := raydashboard.JobSubmitRequest{}
+					Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+					Expect(request.Entrypoint).To(Equal("python main.py"))
+					Expect(request.SubmissionID).To(Equal("job-1"))
+					Expect(string(request.RuntimeEnv)).To(MatchJSON(`{"pip": ["numpy"]}`))
+					Expect(request.Metadata).To(HaveKeyWithValue("owner", "test"))
+					_, _ = w.Write([]byte(`{"job_id": "01000000", "submission_id": "job-1"}`))
+				case r.Method == http.MethodGet && r.URL.Path == "/api/jobs/job-1":
+					_, _ = w.Write([]byte(`{"type": "SUBMISSION", "submission_id": "job-1", "status": "SUCCEEDED", "message": "done"}`))
+				case r.Method == http.MethodGet && r.URL.Path == "/api/jobs/job-1/logs":
+					_, _ = w.Write([]byte(`{"logs": "hello\n"}`))
+				case r.Method == http.MethodPost && r.URL.Path == "/api/jobs/job-1/stop":
+					_, _ = w.Write([]byte(`{"stopped": false}`))
+				case r.Method == http.MethodDelete && r.URL.Path == "/api/jobs/job-1":
+					_, _ = w.Write([]byte(`{"deleted": true}`))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			client := raydashboard.NewClient(server.URL)
+			id, err := client.SubmitJob(ctx, &raydashboard.JobSubmitRequest{
+				Entrypoint:   "python main.py",
+				SubmissionID: "job-1",
+				RuntimeEnv:   json.RawMessage(`{"pip": ["numpy"]}`),
+				Metadata:     map[string]string{"owner": "test"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(id).To(Equal("job-1"))
+
+			job, err := client.GetJob(ctx, id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(job.Status).To(Equal(raydashboard.JobStatusSucceeded))
+			Expect(job.Status.IsTerminal()).To(BeTrue())
+			Expect(job.Message).To(Equal("done"))
+
+			logs, err := client.GetJobLogs(ctx, id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(logs).To(Equal("hello\n"))
+
+			stopped, err := client.StopJob(ctx, id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stopped).To(BeFalse())
+
+			deleted, err := client.DeleteJob(ctx, id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deleted).To(BeTrue())
+
+			_, err = client.GetJob(ctx, "unknown")
+			Expect(raydashboard.IsNotFound(err)).To(BeTrue())
+		})
+	})
+}
+
+func dashboardConnectionTests() {
+	ctx := context.Background()
+
+	Describe("Connection handling", func() {
+		It("Builds plain http dashboard address", func() {
+			Expect(raydashboard.GetDashboardAddress("10.0.0.1")).To(Equal("http://10.0.0.1:8265"))
+		})
+
+		It("Retries idempotent requests on server errors only", func() {
+			server := fake.NewServer()
+			defer server.Close()
+			server.SetError("/api/", http.StatusServiceUnavailable)
+
+			options := raydashboard.DefaultOptions()
+			options.MaxRetries = 2
+			options.RetryBackoff = time.Millisecond
+			client := raydashboard.NewClientWithOptions(server.URL, options)
+
+			_, err := client.ListJobs(ctx)
+			Expect(err).To(HaveOccurred())
+			Expect(server.RequestCount(http.MethodGet, "/api/jobs/")).To(Equal(3))
+
+			// Job submission is never retried.
+			_, err = client.SubmitJob(ctx, &raydashboard.JobSubmitRequest{Entrypoint: "python main.py"})
+			Expect(err).To(HaveOccurred())
+			Expect(server.RequestCount(http.MethodPost, "/api/jobs/")).To(Equal(1))
+
+			// Client errors are not retried.
+			server.SetError("/api/", 0)
+			_, err = client.GetJob(ctx, "unknown")
+			Expect(raydashboard.IsNotFound(err)).To(BeTrue())
+			Expect(server.RequestCount(http.MethodGet, "/api/jobs/unknown")).To(Equal(1))
+		})
+
+		It("Stops retrying once context is cancelled", func() {
+			server := fake.NewServer()
+			defer server.Close()
+			server.SetError("/api/", http.StatusInternalServerError)
+
+			options := raydashboard.DefaultOptions()
+			options.MaxRetries = 10
+			options.RetryBackoff = time.Hour
+			client := raydashboard.NewClientWithOptions(server.URL, options)
+
+			cancelCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+			defer cancel()
+			_, err := client.ListJobs(cancelCtx)
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+			Expect(server.RequestCount(http.MethodGet, "/api/jobs/")).To(Equal(1))
+		})
+	})
+}
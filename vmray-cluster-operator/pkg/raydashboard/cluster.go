@@ -0,0 +1,57 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package raydashboard
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+const (
+	versionPath       = "/api/version"
+	gcsHealthzPath    = "/api/gcs_healthz"
+	clusterStatusPath = "/api/cluster_status"
+	nodesPath         = "/api/v0/nodes"
+)
+
+// GetVersion returns version of the ray dashboard & ray.
+func (c *Client) GetVersion(ctx context.Context) (*VersionInfo, error) {
+	version := &VersionInfo{}
+	if err := c.do(ctx, http.MethodGet, versionPath, nil, version); err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+// CheckGcsHealth returns nil if head node's GCS is reachable by the dashboard.
+func (c *Client) CheckGcsHealth(ctx context.Context) error {
+	_, err := c.doRaw(ctx, http.MethodGet, gcsHealthzPath, nil)
+	return err
+}
+
+// GetClusterStatus returns autoscaler's view of the cluster i.e. pending &
+// active nodes along with resource usage.
+func (c *Client) GetClusterStatus(ctx context.Context) (*ClusterStatus, error) {
+	resp := &apiResponse[ClusterStatus]{}
+	if err := c.do(ctx, http.MethodGet, clusterStatusPath, nil, resp); err != nil {
+		return nil, err
+	}
+	if !resp.Result {
+		return nil, fmt.Errorf("failed to get ray cluster status: %s", resp.Msg)
+	}
+	return &resp.Data, nil
+}
+
+// ListNodes returns all alive & dead nodes known to the ray cluster.
+func (c *Client) ListNodes(ctx context.Context) ([]NodeState, error) {
+	resp := &apiResponse[stateListResult[NodeState]]{}
+	if err := c.do(ctx, http.MethodGet, nodesPath+"?detail=true", nil, resp); err != nil {
+		return nil, err
+	}
+	if !resp.Result {
+		return nil, fmt.Errorf("failed to list ray nodes: %s", resp.Msg)
+	}
+	return resp.Data.Result.Result, nil
+}
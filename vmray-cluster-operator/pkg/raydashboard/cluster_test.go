@@ -0,0 +1,129 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package raydashboard_test
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/raydashboard"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/raydashboard/fake"
+)
+
+func clusterClientTests() {
+	ctx := context.Background()
+
+	Describe("Cluster, nodes & logs API", func() {
+		var (
+			server *fake.Server
+			client *raydashboard.Client
+		)
+
+		BeforeEach(func() {
+			server = fake.NewServer()
+			client = raydashboard.NewClient(server.URL)
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("Reports version, GCS health & cluster status", func() {
+			version, err := client.GetVersion(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(version.Version).To(Equal("4"))
+
+			Expect(client.CheckGcsHealth(ctx)).To(Succeed())
+			server.SetGcsHealthy(false)
+			Expect(client.CheckGcsHealth(ctx)).ToNot(Succeed())
+
+			server.SetClusterStatus(raydashboard.ClusterStatus{AutoscalingStatus: "Healthy:\n 1 head"})
+			status, err := client.GetClusterStatus(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(status.AutoscalingStatus).To(ContainSubstring("1 head"))
+		})
+
+		It("Lists ray nodes", func() {
+			server.SetNodes([]raydashboard.NodeState{
+				{NodeID: "n1", NodeIP: "10.0.0.1", IsHeadNode: true, State: raydashboard.NodeStatusAlive,
+					ResourcesTotal: map[string]float64{"CPU": 4}},
+				{NodeID: "n2", NodeIP: "10.0.0.2", State: raydashboard.NodeStatusDead},
+			})
+
+			nodes, err := client.ListNodes(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nodes).To(HaveLen(2))
+			Expect(nodes[0].IsHeadNode).To(BeTrue())
+			Expect(nodes[0].ResourcesTotal).To(HaveKeyWithValue("CPU", 4.0))
+			Expect(nodes[1].State).To(Equal(raydashboard.NodeStatusDead))
+		})
+
+		It("Drains a node once and counts its running tasks", func() {
+			server.SetRunningTasks("n1", 3)
+
+			job, err := client.DrainNode(ctx, "n1", "scale down", 2*time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(job.SubmissionID).To(Equal("vmray-drain-n1"))
+			Expect(job.Metadata).To(HaveKeyWithValue(raydashboard.OperatorJobMetadataKey, "drain"))
+			Expect(job.Entrypoint).To(Equal(`ray drain-node --node-id n1 --reason DRAIN_NODE_REASON_PREEMPTION ` +
+				`--reason-message "scale down" --deadline-remaining-seconds 120`))
+
+			_, err = client.DrainNode(ctx, "n1", "scale down", time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.RequestCount(http.MethodPost, "/api/jobs/")).To(Equal(1))
+
+			tasks, err := client.CountRunningTasks(ctx, "n1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tasks).To(Equal(3))
+
+			tasks, err = client.CountRunningTasks(ctx, "n2")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tasks).To(BeZero())
+		})
+
+		It("Restarts a node once per restart ID", func() {
+			job, err := client.RestartNode(ctx, "w1-1", "ray@10.0.0.5", "/home/ray/.ssh/id_rsa_ray", "ray stop")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(job.SubmissionID).To(Equal("vmray-restart-w1-1"))
+			Expect(job.Entrypoint).To(Equal(`ssh -o StrictHostKeyChecking=no -i /home/ray/.ssh/id_rsa_ray ray@10.0.0.5 'ray stop'`))
+
+			_, err = client.RestartNode(ctx, "w1-1", "ray@10.0.0.5", "/home/ray/.ssh/id_rsa_ray", "ray stop")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.RequestCount(http.MethodPost, "/api/jobs/")).To(Equal(1))
+
+			_, err = client.RestartNode(ctx, "w1-2", "ray@10.0.0.5", "/home/ray/.ssh/id_rsa_ray", "ray stop")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.RequestCount(http.MethodPost, "/api/jobs/")).To(Equal(2))
+		})
+
+		It("Quotes restart command for the remote shell", func() {
+			job, err := client.RestartNode(ctx, "w1-3", "ray@10.0.0.5", "/home/ray/.ssh/id_rsa_ray", `echo "$HOME" 'a'`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(job.Entrypoint).To(HaveSuffix(`ray@10.0.0.5 'echo "$HOME" '\''a'\'''`))
+		})
+
+		It("Lists and tails node logs", func() {
+			server.SetLog("n1", "raylet.out", "line1\nline2\nline3\n")
+
+			logs, err := client.ListLogs(ctx, "n1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(logs).To(HaveKeyWithValue("raylet.out", []string{"raylet.out"}))
+
+			content, err := client.GetLog(ctx, "n1", "raylet.out", 2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(content).To(Equal("line2\nline3\n"))
+
+			content, err = client.GetLog(ctx, "n1", "raylet.out", 0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(content).To(Equal("line1\nline2\nline3\n"))
+
+			_, err = client.GetLog(ctx, "n1", "unknown.log", 0)
+			Expect(raydashboard.IsNotFound(err)).To(BeTrue())
+		})
+	})
+}
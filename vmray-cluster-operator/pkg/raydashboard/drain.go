@@ -0,0 +1,64 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package raydashboard
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	tasksPath = "/api/v0/tasks"
+
+	drainSubmissionPrefix = "vmray-drain-"
+	// Preemption drains are accepted by ray even if the node is busy, ray stops
+	// scheduling new tasks on the node and lets running ones finish till the deadline.
+	drainReason = "DRAIN_NODE_REASON_PREEMPTION"
+)
+
+// DrainNode asks ray to drain the given node by running `ray drain-node` as a
+// job on the head node, with the given deadline for the node to finish its
+// running tasks. It's safe to call repeatedly as a node is only drained once.
+func (c *Client) DrainNode(ctx context.Context, nodeID, message string, deadline time.Duration) (*JobDetails, error) {
+	submissionID := drainSubmissionPrefix + nodeID
+	job, err := c.GetJob(ctx, submissionID)
+	if err == nil || !IsNotFound(err) {
+		return job, err
+	}
+
+	entrypoint := fmt.Sprintf("ray drain-node --node-id %s --reason %s --reason-message %q --deadline-remaining-seconds %d",
+		nodeID, drainReason, message, int64(deadline.Seconds()))
+	if _, err := c.SubmitJob(ctx, &JobSubmitRequest{
+		Entrypoint:   entrypoint,
+		SubmissionID: submissionID,
+		Metadata:     map[string]string{OperatorJobMetadataKey: "drain"},
+	}); err != nil {
+		return nil, err
+	}
+	return c.GetJob(ctx, submissionID)
+}
+
+// CountRunningTasks returns number of tasks running on the given node.
+func (c *Client) CountRunningTasks(ctx context.Context, nodeID string) (int, error) {
+	query := url.Values{}
+	query.Add("filter_keys", "node_id")
+	query.Add("filter_predicates", "=")
+	query.Add("filter_values", nodeID)
+	query.Add("filter_keys", "state")
+	query.Add("filter_predicates", "=")
+	query.Add("filter_values", "RUNNING")
+	query.Set("detail", "false")
+
+	resp := &apiResponse[stateListResult[TaskState]]{}
+	if err := c.do(ctx, http.MethodGet, tasksPath+"?"+query.Encode(), nil, resp); err != nil {
+		return 0, err
+	}
+	if !resp.Result {
+		return 0, fmt.Errorf("failed to list tasks of ray node %s: %s", nodeID, resp.Msg)
+	}
+	return resp.Data.Result.Total, nil
+}
@@ -0,0 +1,405 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+Package fake provides an in-memory stand-in of the ray dashboard REST APIs,
+served by an httptest server, to unit test consumers of the raydashboard client.
+*/
+package fake
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/raydashboard"
+)
+
+const (
+	jobsPath  = "/api/jobs/"
+	logsPath  = "/api/v0/logs"
+	servePath = "/api/serve/applications/"
+)
+
+// Server is a fake ray dashboard. Submitted jobs start in RUNNING state and
+// applied serve config is reported with applications set via SetServeApplications.
+type Server struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	jobIDs        []string
+	jobs          map[string]*raydashboard.JobDetails
+	jobLogs       map[string]string
+	nodes         []raydashboard.NodeState
+	runningTasks  map[string]int
+	logs          map[string]map[string]string
+	serveConfig   json.RawMessage
+	serveDetails  raydashboard.ServeDetails
+	clusterStatus raydashboard.ClusterStatus
+	version       raydashboard.VersionInfo
+	gcsHealthy    bool
+	errors        map[string]int
+	requests      map[string]int
+}
+
+// NewServer starts a fake ray dashboard served over plain HTTP.
+func NewServer() *Server {
+	s := &Server{
+		jobs:         map[string]*raydashboard.JobDetails{},
+		jobLogs:      map[string]string{},
+		runningTasks: map[string]int{},
+		logs:         map[string]map[string]string{},
+		version: raydashboard.VersionInfo{
+			Version:    "4",
+			RayVersion: "2.9.0",
+		},
+		gcsHealthy: true,
+		errors:     map[string]int{},
+		requests:   map[string]int{},
+	}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// SetError makes requests whose path starts with given prefix fail with the
+// given status code, a zero status code clears the error.
+func (s *Server) SetError(pathPrefix string, statusCode int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if statusCode == 0 {
+		delete(s.errors, pathPrefix)
+		return
+	}
+	s.errors[pathPrefix] = statusCode
+}
+
+// RequestCount returns number of requests received for the given method & path.
+func (s *Server) RequestCount(method, path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[method+" "+path]
+}
+
+// AddJob adds a job, e.g. a driver, as if it was already known to ray.
+func (s *Server) AddJob(job raydashboard.JobDetails) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addJob(&job)
+}
+
+// GetJob returns a copy of the job with given submission ID, if any.
+func (s *Server) GetJob(id string) (raydashboard.JobDetails, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return raydashboard.JobDetails{}, false
+	}
+	return *job, true
+}
+
+// SetJobStatus updates status & message of the job with given submission ID.
+func (s *Server) SetJobStatus(id string, status raydashboard.JobStatus, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job, ok := s.jobs[id]; ok {
+		job.Status = status
+		job.Message = message
+	}
+}
+
+// SetJobLogs sets logs of the job with given submission ID.
+func (s *Server) SetJobLogs(id, logs string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobLogs[id] = logs
+}
+
+// SetNodes sets nodes reported by the state API.
+func (s *Server) SetNodes(nodes []raydashboard.NodeState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nodes = nodes
+}
+
+// SetRunningTasks sets number of tasks running on the given node.
+func (s *Server) SetRunningTasks(nodeID string, count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runningTasks[nodeID] = count
+}
+
+// SetLog sets content of a log file of the given node.
+func (s *Server) SetLog(nodeID, filename, content string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.logs[nodeID]; !ok {
+		s.logs[nodeID] = map[string]string{}
+	}
+	s.logs[nodeID][filename] = content
+}
+
+// SetClusterStatus sets autoscaler status of the cluster.
+func (s *Server) SetClusterStatus(status raydashboard.ClusterStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clusterStatus = status
+}
+
+// SetGcsHealthy sets whether GCS health check succeeds.
+func (s *Server) SetGcsHealthy(healthy bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gcsHealthy = healthy
+}
+
+// SetServeApplications sets status of serve applications reported once serve config is applied.
+func (s *Server) SetServeApplications(details raydashboard.ServeDetails) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.serveDetails = details
+}
+
+// ServeConfig returns the last applied serve config, nil if none is applied.
+func (s *Server) ServeConfig() json.RawMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.serveConfig
+}
+
+// ResetServe simulates a fresh cluster to which serve config is not yet applied.
+func (s *Server) ResetServe() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.serveConfig = nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := r.URL.Path
+	s.requests[r.Method+" "+path]++
+	for prefix, statusCode := range s.errors {
+		if strings.HasPrefix(path, prefix) {
+			http.Error(w, "injected error", statusCode)
+			return
+		}
+	}
+
+	switch {
+	case path == "/api/version":
+		writeJSON(w, s.version)
+	case path == "/api/gcs_healthz":
+		if !s.gcsHealthy {
+			http.Error(w, "GCS is not reachable", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("success"))
+	case path == "/api/cluster_status":
+		writeJSON(w, envelope(s.clusterStatus))
+	case path == "/api/v0/nodes":
+		nodes := s.nodes
+		if nodes == nil {
+			nodes = []raydashboard.NodeState{}
+		}
+		writeJSON(w, envelope(map[string]interface{}{
+			"result": map[string]interface{}{"total": len(nodes), "result": nodes},
+		}))
+	case path == "/api/v0/tasks":
+		s.serveTasks(w, r)
+	case path == logsPath:
+		s.serveLogList(w, r)
+	case path == logsPath+"/file":
+		s.serveLogFile(w, r)
+	case strings.HasPrefix(path, jobsPath):
+		s.serveJobs(w, r, strings.TrimPrefix(path, jobsPath))
+	case path == servePath:
+		s.serveApplications(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) serveJobs(w http.ResponseWriter, r *http.Request, subpath string) {
+	parts := strings.Split(subpath, "/")
+	id := parts[0]
+
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		jobs := []raydashboard.JobDetails{}
+		for _, id := range s.jobIDs {
+			jobs = append(jobs, *s.jobs[id])
+		}
+		writeJSON(w, jobs)
+		return
+	case id == "" && r.Method == http.MethodPost:
+		request := raydashboard.JobSubmitRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if request.SubmissionID == "" {
+			request.SubmissionID = "raysubmit_" + strconv.Itoa(len(s.jobIDs))
+		}
+		if _, ok := s.jobs[request.SubmissionID]; ok {
+			http.Error(w, "job "+request.SubmissionID+" already exists", http.StatusBadRequest)
+			return
+		}
+		s.addJob(&raydashboard.JobDetails{
+			Type:         raydashboard.JobTypeSubmission,
+			SubmissionID: request.SubmissionID,
+			Status:       raydashboard.JobStatusRunning,
+			Entrypoint:   request.Entrypoint,
+			Metadata:     request.Metadata,
+		})
+		writeJSON(w, raydashboard.JobSubmitResponse{SubmissionID: request.SubmissionID})
+		return
+	}
+
+	job, ok := s.jobs[id]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	action := ""
+	if len(parts) > 1 {
+		action = parts[1]
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		writeJSON(w, job)
+	case action == "" && r.Method == http.MethodDelete:
+		if !job.Status.IsTerminal() {
+			http.Error(w, "job "+id+" is not terminated", http.StatusBadRequest)
+			return
+		}
+		delete(s.jobs, id)
+		for i, jobID := range s.jobIDs {
+			if jobID == id {
+				s.jobIDs = append(s.jobIDs[:i], s.jobIDs[i+1:]...)
+				break
+			}
+		}
+		writeJSON(w, raydashboard.JobDeleteResponse{Deleted: true})
+	case action == "logs" && r.Method == http.MethodGet:
+		writeJSON(w, raydashboard.JobLogsResponse{Logs: s.jobLogs[id]})
+	case action == "stop" && r.Method == http.MethodPost:
+		stopped := !job.Status.IsTerminal()
+		if stopped {
+			job.Status = raydashboard.JobStatusStopped
+		}
+		writeJSON(w, raydashboard.JobStopResponse{Stopped: stopped})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) serveApplications(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		if s.serveConfig == nil {
+			writeJSON(w, raydashboard.ServeDetails{Applications: map[string]raydashboard.ServeApplicationDetails{}})
+			return
+		}
+		writeJSON(w, s.serveDetails)
+	case http.MethodPut:
+		config := json.RawMessage{}
+		if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.serveConfig = config
+	case http.MethodDelete:
+		s.serveConfig = nil
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveTasks reports running tasks of the node filtered by, as set via SetRunningTasks.
+func (s *Server) serveTasks(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	keys, values := query["filter_keys"], query["filter_values"]
+	nodeID, running := "", false
+	for i := range keys {
+		if i >= len(values) {
+			break
+		}
+		switch keys[i] {
+		case "node_id":
+			nodeID = values[i]
+		case "state":
+			running = values[i] == "RUNNING"
+		}
+	}
+
+	tasks := []raydashboard.TaskState{}
+	if running {
+		for i := 0; i < s.runningTasks[nodeID]; i++ {
+			tasks = append(tasks, raydashboard.TaskState{
+				TaskID: nodeID + "-" + strconv.Itoa(i),
+				State:  "RUNNING",
+				NodeID: nodeID,
+			})
+		}
+	}
+	writeJSON(w, envelope(map[string]interface{}{
+		"result": map[string]interface{}{"total": len(tasks), "result": tasks},
+	}))
+}
+
+func (s *Server) serveLogList(w http.ResponseWriter, r *http.Request) {
+	files, ok := s.logs[r.URL.Query().Get("node_id")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	categories := map[string][]string{}
+	for filename := range files {
+		category := strings.TrimSuffix(filename, ".log")
+		categories[category] = append(categories[category], filename)
+	}
+	writeJSON(w, envelope(map[string]interface{}{"result": categories}))
+}
+
+func (s *Server) serveLogFile(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	content, ok := s.logs[query.Get("node_id")][query.Get("filename")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if lines, err := strconv.Atoi(query.Get("lines")); err == nil && lines > 0 {
+		all := strings.SplitAfter(content, "\n")
+		if all[len(all)-1] == "" {
+			all = all[:len(all)-1]
+		}
+		if len(all) > lines {
+			content = strings.Join(all[len(all)-lines:], "")
+		}
+	}
+	_, _ = w.Write([]byte(content))
+}
+
+func (s *Server) addJob(job *raydashboard.JobDetails) {
+	id := job.SubmissionID
+	if id == "" {
+		id = job.JobID
+	}
+	s.jobIDs = append(s.jobIDs, id)
+	s.jobs[id] = job
+}
+
+func envelope(data interface{}) map[string]interface{} {
+	return map[string]interface{}{"result": true, "msg": "", "data": data}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package raydashboard
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+const (
+	jobsPath = "/api/jobs/"
+)
+
+// ListJobs returns all submission & driver jobs known to the ray cluster.
+func (c *Client) ListJobs(ctx context.Context) ([]JobDetails, error) {
+	jobs := []JobDetails{}
+	if err := c.do(ctx, http.MethodGet, jobsPath, nil, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// SubmitJob submits a new job to the ray cluster and returns its submission ID.
+func (c *Client) SubmitJob(ctx context.Context, request *JobSubmitRequest) (string, error) {
+	resp := JobSubmitResponse{}
+	if err := c.do(ctx, http.MethodPost, jobsPath, request, &resp); err != nil {
+		return "", err
+	}
+	return resp.SubmissionID, nil
+}
+
+// GetJob returns details of the job with given submission or job ID.
+func (c *Client) GetJob(ctx context.Context, id string) (*JobDetails, error) {
+	job := &JobDetails{}
+	if err := c.do(ctx, http.MethodGet, jobsPath+url.PathEscape(id), nil, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// GetJobLogs returns all logs of the job with given submission or job ID.
+func (c *Client) GetJobLogs(ctx context.Context, id string) (string, error) {
+	resp := JobLogsResponse{}
+	if err := c.do(ctx, http.MethodGet, jobsPath+url.PathEscape(id)+"/logs", nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.Logs, nil
+}
+
+// StopJob requests ray to stop the job, returns false if job was already terminated.
+func (c *Client) StopJob(ctx context.Context, id string) (bool, error) {
+	resp := JobStopResponse{}
+	if err := c.do(ctx, http.MethodPost, jobsPath+url.PathEscape(id)+"/stop", nil, &resp); err != nil {
+		return false, err
+	}
+	return resp.Stopped, nil
+}
+
+// DeleteJob deletes a terminated job and its logs from the ray cluster.
+func (c *Client) DeleteJob(ctx context.Context, id string) (bool, error) {
+	resp := JobDeleteResponse{}
+	if err := c.do(ctx, http.MethodDelete, jobsPath+url.PathEscape(id), nil, &resp); err != nil {
+		return false, err
+	}
+	return resp.Deleted, nil
+}
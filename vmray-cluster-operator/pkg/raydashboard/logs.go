@@ -0,0 +1,54 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package raydashboard
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const (
+	logsPath     = "/api/v0/logs"
+	logsFilePath = "/api/v0/logs/file"
+)
+
+// ListLogs returns names of log files of the given ray node, grouped by
+// category e.g. `worker_out`, `raylet`, `gcs_server`.
+func (c *Client) ListLogs(ctx context.Context, nodeID string) (map[string][]string, error) {
+	query := url.Values{}
+	query.Set("node_id", nodeID)
+
+	resp := &apiResponse[struct {
+		Result map[string][]string `json:"result"`
+	}]{}
+	if err := c.do(ctx, http.MethodGet, logsPath+"?"+query.Encode(), nil, resp); err != nil {
+		return nil, err
+	}
+	if !resp.Result {
+		return nil, fmt.Errorf("failed to list logs of ray node %s: %s", nodeID, resp.Msg)
+	}
+	return resp.Data.Result, nil
+}
+
+// GetLog returns the last given number of lines of a log file of the given ray
+// node, whole file is returned when lines isn't positive.
+func (c *Client) GetLog(ctx context.Context, nodeID, filename string, lines int) (string, error) {
+	query := url.Values{}
+	query.Set("node_id", nodeID)
+	query.Set("filename", filename)
+	if lines > 0 {
+		query.Set("lines", strconv.Itoa(lines))
+	} else {
+		query.Set("lines", "-1")
+	}
+
+	body, err := c.doRaw(ctx, http.MethodGet, logsFilePath+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
@@ -0,0 +1,26 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package raydashboard_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestRayDashboard(t *testing.T) {
+
+	// Register failure handler.
+	RegisterFailHandler(Fail)
+
+	// Register unit testcases.
+	Describe("Ray dashboard client unit testcases", dashboardClientTests)
+	Describe("Ray dashboard connection unit testcases", dashboardConnectionTests)
+	Describe("Ray cluster client unit testcases", clusterClientTests)
+	Describe("Ray serve client unit testcases", serveClientTests)
+
+	// Run the tests.
+	RunSpecs(t, "Ray dashboard client Suite")
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package raydashboard
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	restartSubmissionPrefix = "vmray-restart-"
+)
+
+// RestartNode runs the given command on a ray node over ssh as a job on the
+// head node, the command is expected to restart ray on the node. restartID
+// identifies a single restart, so it's safe to call repeatedly with the same ID.
+func (c *Client) RestartNode(ctx context.Context, restartID, sshTarget, sshKeyPath, command string) (*JobDetails, error) {
+	submissionID := restartSubmissionPrefix + restartID
+	job, err := c.GetJob(ctx, submissionID)
+	if err == nil || !IsNotFound(err) {
+		return job, err
+	}
+
+	entrypoint := fmt.Sprintf("ssh -o StrictHostKeyChecking=no -i %s %s %s", sshKeyPath, sshTarget, shellQuote(command))
+	if _, err := c.SubmitJob(ctx, &JobSubmitRequest{
+		Entrypoint:   entrypoint,
+		SubmissionID: submissionID,
+		Metadata:     map[string]string{OperatorJobMetadataKey: "restart"},
+	}); err != nil {
+		return nil, err
+	}
+	return c.GetJob(ctx, submissionID)
+}
+
+// shellQuote quotes s as a single word of a shell command, the remote shell
+// then runs it as is without expanding it.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package raydashboard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const (
+	// DefaultServePort is the port ray serve HTTP proxy listens on.
+	DefaultServePort = 8000
+
+	serveApplicationsPath = "/api/serve/applications/"
+)
+
+// ServeApplicationStatus mirrors ray's `ApplicationStatus` enum.
+type ServeApplicationStatus string
+
+const (
+	ServeApplicationNotStarted   ServeApplicationStatus = "NOT_STARTED"
+	ServeApplicationDeploying    ServeApplicationStatus = "DEPLOYING"
+	ServeApplicationDeployFailed ServeApplicationStatus = "DEPLOY_FAILED"
+	ServeApplicationRunning      ServeApplicationStatus = "RUNNING"
+	ServeApplicationUnhealthy    ServeApplicationStatus = "UNHEALTHY"
+	ServeApplicationDeleting     ServeApplicationStatus = "DELETING"
+)
+
+// ServeDeploymentStatus mirrors ray's `DeploymentStatus` enum.
+type ServeDeploymentStatus string
+
+const (
+	ServeDeploymentUpdating  ServeDeploymentStatus = "UPDATING"
+	ServeDeploymentHealthy   ServeDeploymentStatus = "HEALTHY"
+	ServeDeploymentUnhealthy ServeDeploymentStatus = "UNHEALTHY"
+)
+
+type ServeDeploymentDetails struct {
+	Name    string                `json:"name"`
+	Status  ServeDeploymentStatus `json:"status"`
+	Message string                `json:"message,omitempty"`
+}
+
+type ServeApplicationDetails struct {
+	Name        string                            `json:"name"`
+	RoutePrefix string                            `json:"route_prefix,omitempty"`
+	Status      ServeApplicationStatus            `json:"status"`
+	Message     string                            `json:"message,omitempty"`
+	Deployments map[string]ServeDeploymentDetails `json:"deployments,omitempty"`
+}
+
+// ServeDetails mirrors response of ray's `GET /api/serve/applications/` endpoint.
+type ServeDetails struct {
+	Applications map[string]ServeApplicationDetails `json:"applications"`
+}
+
+// GetServeAddress returns ray serve's HTTP address for provided IP.
+func GetServeAddress(ip string) string {
+	return fmt.Sprintf("http://%s:%d", ip, DefaultServePort)
+}
+
+// IsHealthy returns true if there is at least one serve application
+// and all applications & their deployments are running.
+func (d *ServeDetails) IsHealthy() bool {
+	if len(d.Applications) == 0 {
+		return false
+	}
+	for _, app := range d.Applications {
+		if app.Status != ServeApplicationRunning {
+			return false
+		}
+		for _, deployment := range app.Deployments {
+			if deployment.Status != ServeDeploymentHealthy {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// GetServeApplications returns status of all serve applications of the ray cluster.
+func (c *Client) GetServeApplications(ctx context.Context) (*ServeDetails, error) {
+	details := &ServeDetails{}
+	if err := c.do(ctx, http.MethodGet, serveApplicationsPath, nil, details); err != nil {
+		return nil, err
+	}
+	return details, nil
+}
+
+// DeployServeApplications declaratively applies serve config, i.e. applications
+// not present in the config are deleted and the present ones are updated.
+func (c *Client) DeployServeApplications(ctx context.Context, config json.RawMessage) error {
+	return c.do(ctx, http.MethodPut, serveApplicationsPath, config, nil)
+}
+
+// DeleteServeApplications shuts down all serve applications of the ray cluster.
+func (c *Client) DeleteServeApplications(ctx context.Context) error {
+	return c.do(ctx, http.MethodDelete, serveApplicationsPath, nil, nil)
+}
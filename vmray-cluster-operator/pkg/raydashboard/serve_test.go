@@ -0,0 +1,88 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package raydashboard_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/raydashboard"
+)
+
+const (
+	serveConfig = `{"applications": [{"name": "app1", "import_path": "main:app", "route_prefix": "/"}]}`
+
+	serveResponse = `{
+  "proxy_location": "EveryNode",
+  "applications": {
+    "app1": {
+      "name": "app1",
+      "route_prefix": "/",
+      "status": "RUNNING",
+      "message": "",
+      "deployments": {
+        "Model": {"name": "Model", "status": "HEALTHY", "message": ""},
+        "Ingress": {"name": "Ingress", "status": "UPDATING", "message": "scaling up"}
+      }
+    }
+  }
+}`
+)
+
+func serveClientTests() {
+	ctx := context.Background()
+
+	Describe("Serve API", func() {
+		It("Applies serve config and reports application health", func() {
+			applied := ""
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				Expect(r.URL.Path).To(Equal("/api/serve/applications/"))
+				switch r.Method {
+				case http.MethodPut:
+					body, err := io.ReadAll(r.Body)
+					Expect(err).ToNot(HaveOccurred())
+					applied = string(body)
+				case http.MethodGet:
+					_, _ = w.Write([]byte(serveResponse))
+				case http.MethodDelete:
+					applied = ""
+				}
+			}))
+			defer server.Close()
+
+			client := raydashboard.NewClient(server.URL)
+			Expect(client.DeployServeApplications(ctx, json.RawMessage(serveConfig))).To(Succeed())
+			Expect(applied).To(MatchJSON(serveConfig))
+
+			details, err := client.GetServeApplications(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(details.Applications).To(HaveKey("app1"))
+			Expect(details.Applications["app1"].Status).To(Equal(raydashboard.ServeApplicationRunning))
+			Expect(details.Applications["app1"].Deployments["Ingress"].Message).To(Equal("scaling up"))
+
+			// One of the deployments is still updating.
+			Expect(details.IsHealthy()).To(BeFalse())
+			details.Applications["app1"].Deployments["Ingress"] = raydashboard.ServeDeploymentDetails{
+				Name:   "Ingress",
+				Status: raydashboard.ServeDeploymentHealthy,
+			}
+			Expect(details.IsHealthy()).To(BeTrue())
+
+			Expect(client.DeleteServeApplications(ctx)).To(Succeed())
+			Expect(applied).To(BeEmpty())
+		})
+
+		It("Doesn't treat cluster without applications as healthy", func() {
+			details := &raydashboard.ServeDetails{}
+			Expect(details.IsHealthy()).To(BeFalse())
+		})
+	})
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package raydashboard
+
+import "encoding/json"
+
+// JobStatus mirrors ray's `JobStatus` enum.
+type JobStatus string
+
+const (
+	JobStatusPending   JobStatus = "PENDING"
+	JobStatusRunning   JobStatus = "RUNNING"
+	JobStatusStopped   JobStatus = "STOPPED"
+	JobStatusSucceeded JobStatus = "SUCCEEDED"
+	JobStatusFailed    JobStatus = "FAILED"
+)
+
+// IsTerminal returns true if job will not transition to any other state.
+func (s JobStatus) IsTerminal() bool {
+	return s == JobStatusStopped || s == JobStatusSucceeded || s == JobStatusFailed
+}
+
+// JobType distinguishes jobs submitted via jobs API from drivers
+// started by a connected client (e.g. `ray.init("ray://...")`).
+type JobType string
+
+const (
+	JobTypeSubmission JobType = "SUBMISSION"
+	JobTypeDriver     JobType = "DRIVER"
+)
+
+// JobDetails mirrors response of ray's `GET /api/jobs/` endpoint.
+type JobDetails struct {
+	Type         JobType           `json:"type"`
+	JobID        string            `json:"job_id,omitempty"`
+	SubmissionID string            `json:"submission_id,omitempty"`
+	Status       JobStatus         `json:"status"`
+	Entrypoint   string            `json:"entrypoint,omitempty"`
+	Message      string            `json:"message,omitempty"`
+	StartTime    int64             `json:"start_time,omitempty"`
+	EndTime      int64             `json:"end_time,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+// JobSubmitRequest mirrors request body of ray's `POST /api/jobs/` endpoint.
+type JobSubmitRequest struct {
+	Entrypoint   string            `json:"entrypoint"`
+	SubmissionID string            `json:"submission_id,omitempty"`
+	RuntimeEnv   json.RawMessage   `json:"runtime_env,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+type JobSubmitResponse struct {
+	JobID        string `json:"job_id,omitempty"`
+	SubmissionID string `json:"submission_id"`
+}
+
+type JobLogsResponse struct {
+	Logs string `json:"logs"`
+}
+
+type JobStopResponse struct {
+	Stopped bool `json:"stopped"`
+}
+
+type JobDeleteResponse struct {
+	Deleted bool `json:"deleted"`
+}
+
+// OperatorJobMetadataKey is set in metadata of jobs submitted by the operator
+// to manage the cluster itself, e.g. to drain or restart a ray node.
+const OperatorJobMetadataKey = "vmray.broadcom.com/operator-job"
+
+// CountActiveJobs returns number of non terminal submission jobs and number of
+// non terminal drivers i.e. connected clients. Jobs of the operator aren't counted.
+func CountActiveJobs(jobs []JobDetails) (submissions int, drivers int) {
+	for _, job := range jobs {
+		if job.Status.IsTerminal() || job.Metadata[OperatorJobMetadataKey] != "" {
+			continue
+		}
+		if job.Type == JobTypeDriver {
+			drivers++
+		} else {
+			submissions++
+		}
+	}
+	return submissions, drivers
+}
+
+// apiResponse is the envelope of responses of ray dashboard's
+// cluster status & state APIs.
+type apiResponse[T any] struct {
+	Result bool   `json:"result"`
+	Msg    string `json:"msg,omitempty"`
+	Data   T      `json:"data"`
+}
+
+// stateListResult is the payload of ray state API's list endpoints.
+type stateListResult[T any] struct {
+	Result struct {
+		Total  int `json:"total"`
+		Result []T `json:"result"`
+	} `json:"result"`
+}
+
+// VersionInfo mirrors response of ray's `GET /api/version` endpoint.
+type VersionInfo struct {
+	Version    string `json:"version"`
+	RayVersion string `json:"ray_version"`
+	RayCommit  string `json:"ray_commit,omitempty"`
+}
+
+// ClusterStatus mirrors data of ray's `GET /api/cluster_status` endpoint.
+type ClusterStatus struct {
+	// Human readable autoscaler status, same as output of `ray status`.
+	AutoscalingStatus string `json:"autoscalingStatus,omitempty"`
+	AutoscalingError  string `json:"autoscalingError,omitempty"`
+	// Raw load metrics reported by the GCS.
+	ClusterStatus json.RawMessage `json:"clusterStatus,omitempty"`
+}
+
+// NodeStatus mirrors ray's node `state` enum.
+type NodeStatus string
+
+const (
+	NodeStatusAlive NodeStatus = "ALIVE"
+	NodeStatusDead  NodeStatus = "DEAD"
+)
+
+// NodeState mirrors an entry of ray's `GET /api/v0/nodes` state API.
+type NodeState struct {
+	NodeID         string             `json:"node_id"`
+	NodeIP         string             `json:"node_ip"`
+	IsHeadNode     bool               `json:"is_head_node"`
+	State          NodeStatus         `json:"state"`
+	StateMessage   string             `json:"state_message,omitempty"`
+	NodeName       string             `json:"node_name,omitempty"`
+	ResourcesTotal map[string]float64 `json:"resources_total,omitempty"`
+	Labels         map[string]string  `json:"labels,omitempty"`
+	StartTimeMs    int64              `json:"start_time_ms,omitempty"`
+	EndTimeMs      int64              `json:"end_time_ms,omitempty"`
+}
+
+// TaskState mirrors an entry of ray's `GET /api/v0/tasks` state API.
+type TaskState struct {
+	TaskID string `json:"task_id"`
+	Name   string `json:"name,omitempty"`
+	State  string `json:"state"`
+	NodeID string `json:"node_id,omitempty"`
+}
@@ -17,12 +17,14 @@ import (
 
 	vmopv1 "github.com/vmware-tanzu/vm-operator/api/v1alpha2"
 	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+	vmrayv1alpha2 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha2"
 	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	storagev1 "k8s.io/api/storage/v1"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -34,6 +36,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	testutil "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/test/builder/utils"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/test/builder/vmoperator"
 )
 
 /*
@@ -55,6 +58,7 @@ type TestSuite struct {
 	isWebhook      bool
 	config         *rest.Config
 	k8sClient      client.Client
+	vmOperator     *vmoperator.Controller
 }
 
 func NewTestSuite(
@@ -82,13 +86,34 @@ func NewTestSuiteWithoutManager() *TestSuite {
 	return testSuite
 }
 
+// NewTestSuiteWithFakeVmOperator creates a test suite whose manager runs a simulated
+// vm-operator, assigning IPs to VMs & VM services created by the test cases.
+func NewTestSuiteWithFakeVmOperator(options vmoperator.Options) *TestSuite {
+
+	testSuite := &TestSuite{
+		isWebhook:      false,
+		startupManager: true,
+		vmOperator:     vmoperator.NewController(options),
+	}
+
+	testSuite.init()
+
+	return testSuite
+}
+
 func (s *TestSuite) init() {
 
 	rootDir := testutil.GetRootDirOrDie()
 	webhookInstallOptions := envtest.WebhookInstallOptions{Paths: []string{}}
 
+	crdInstallOptions := envtest.CRDInstallOptions{}
+
 	if s.isWebhook {
 		webhookInstallOptions.Paths = append(webhookInstallOptions.Paths, filepath.Join(rootDir, "config", "webhook"))
+		// Makes CRDs of convertible types use the conversion webhook.
+		crdInstallOptions.Scheme = runtime.NewScheme()
+		utilruntime.Must(vmrayv1alpha1.AddToScheme(crdInstallOptions.Scheme))
+		utilruntime.Must(vmrayv1alpha2.AddToScheme(crdInstallOptions.Scheme))
 	}
 
 	s.envTest = envtest.Environment{
@@ -99,6 +124,7 @@ func (s *TestSuite) init() {
 			filepath.Join(rootDir, "test", "builder", "vmoperator", "crd"),
 		},
 		ErrorIfCRDPathMissing: false,
+		CRDInstallOptions:     crdInstallOptions,
 		WebhookInstallOptions: webhookInstallOptions,
 	}
 }
@@ -125,6 +151,9 @@ func (s *TestSuite) BeforeSuite() {
 
 	if s.startupManager {
 		s.createManager()
+		if s.vmOperator != nil {
+			Expect(s.vmOperator.SetupWithManager(s.manager)).To(Succeed())
+		}
 		s.startManager()
 		s.initializerManager()
 
@@ -138,6 +167,9 @@ func addToScheme(scheme *runtime.Scheme) {
 	err := vmrayv1alpha1.AddToScheme(scheme)
 	Expect(err).NotTo(HaveOccurred())
 
+	err = vmrayv1alpha2.AddToScheme(scheme)
+	Expect(err).NotTo(HaveOccurred())
+
 	err = vmopv1.AddToScheme(scheme)
 	Expect(err).NotTo(HaveOccurred())
 
@@ -203,6 +235,17 @@ func (s *TestSuite) GetK8sClient() client.Client {
 	return s.k8sClient
 }
 
+// GetConfig returns config of the test API server, used by the test cases to create
+// clients of their own, e.g. ones recording warnings returned by webhooks.
+func (s *TestSuite) GetConfig() *rest.Config {
+	return s.config
+}
+
+// GetFakeVmOperator returns the simulated vm-operator, used by the test cases to inject failures.
+func (s *TestSuite) GetFakeVmOperator() *vmoperator.Controller {
+	return s.vmOperator
+}
+
 func (s *TestSuite) createClient() {
 	var err error
 	envscheme := runtime.NewScheme()
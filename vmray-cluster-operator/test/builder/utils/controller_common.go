@@ -10,6 +10,7 @@ import (
 	vmopv1 "github.com/vmware-tanzu/vm-operator/api/v1alpha2"
 	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
 	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -32,8 +33,8 @@ func CreateRayClusterInstance(ctx context.Context, k8sClient client.Client,
 				MinWorkers: 3,
 				MaxWorkers: 5,
 				Resources: vmrayv1alpha1.NodeResource{
-					CPU:    2,
-					Memory: 1024,
+					CPU:    resource.NewQuantity(2, resource.DecimalSI),
+					Memory: resource.NewQuantity(1024, resource.BinarySI),
 				},
 			},
 			"node.1": {
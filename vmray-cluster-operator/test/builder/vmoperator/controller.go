@@ -0,0 +1,203 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package vmoperator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	vmopv1 "github.com/vmware-tanzu/vm-operator/api/v1alpha2"
+	vmopv1common "github.com/vmware-tanzu/vm-operator/api/v1alpha2/common"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	vmIPPattern      = "10.20.%d.%d"
+	serviceIPPattern = "10.30.%d.%d"
+	failureReason    = "SimulatedFailure"
+	poweredOnReason  = "PoweredOn"
+	poweredOffReason = "PoweredOff"
+)
+
+// Options configure how the simulated vm-operator behaves.
+type Options struct {
+	// BootDelay is the time a powered on VM takes to get its IP.
+	BootDelay time.Duration
+	// ServiceDelay is the time a VM service takes to get its ingress IP.
+	ServiceDelay time.Duration
+}
+
+// Controller simulates vm-operator in envtest, which only serves vm-operator's CRDs.
+// VMs are powered on & off as requested by their spec and get an IP once booted,
+// VM services get an ingress IP. Failures can be injected per VM.
+type Controller struct {
+	client  client.Client
+	options Options
+
+	mu          sync.Mutex
+	ips         map[types.NamespacedName]string
+	poweredOnAt map[types.NamespacedName]time.Time
+	failures    map[types.NamespacedName]string
+	ipCount     int
+}
+
+func NewController(options Options) *Controller {
+	return &Controller{
+		options:     options,
+		ips:         make(map[types.NamespacedName]string),
+		poweredOnAt: make(map[types.NamespacedName]time.Time),
+		failures:    make(map[types.NamespacedName]string),
+	}
+}
+
+// SetupWithManager registers reconcilers of VMs & VM services with the manager.
+func (c *Controller) SetupWithManager(mgr ctrl.Manager) error {
+	c.client = mgr.GetClient()
+	if err := ctrl.NewControllerManagedBy(mgr).
+		Named("fake-virtualmachine").
+		For(&vmopv1.VirtualMachine{}).
+		Complete(reconcile.Func(c.reconcileVm)); err != nil {
+		return err
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("fake-virtualmachineservice").
+		For(&vmopv1.VirtualMachineService{}).
+		Complete(reconcile.Func(c.reconcileVmService))
+}
+
+// InjectFailure makes the VM report a failure, it's powered off and loses its IP
+// till the failure is cleared. Injecting a failure before the VM is created makes
+// the VM fail on creation.
+func (c *Controller) InjectFailure(namespace, name, message string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures[types.NamespacedName{Namespace: namespace, Name: name}] = message
+}
+
+// ClearFailure recovers the VM from an injected failure, it boots again.
+func (c *Controller) ClearFailure(namespace, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.failures, types.NamespacedName{Namespace: namespace, Name: name})
+}
+
+// GetVmIp returns IP assigned to the VM, it's stable across reboots of the VM.
+func (c *Controller) GetVmIp(namespace, name string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ips[types.NamespacedName{Namespace: namespace, Name: name}]
+}
+
+func (c *Controller) nextIp(pattern string) string {
+	c.ipCount++
+	return fmt.Sprintf(pattern, c.ipCount/250, c.ipCount%250+1)
+}
+
+func (c *Controller) reconcileVm(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	vm := &vmopv1.VirtualMachine{}
+	if err := c.client.Get(ctx, req.NamespacedName, vm); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			c.mu.Lock()
+			delete(c.ips, req.NamespacedName)
+			delete(c.poweredOnAt, req.NamespacedName)
+			c.mu.Unlock()
+		}
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	if !vm.ObjectMeta.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+
+	c.mu.Lock()
+	failure, failed := c.failures[req.NamespacedName]
+	poweredOnAt, poweredOn := c.poweredOnAt[req.NamespacedName]
+	c.mu.Unlock()
+
+	patch := client.MergeFrom(vm.DeepCopy())
+	requeueAfter := time.Duration(0)
+	switch {
+	case failed:
+		// Failed VM is powered off, it has to be powered on again once recovered.
+		// Requeue to notice the failure being cleared.
+		c.powerOff(req.NamespacedName, vm, metav1.ConditionFalse, failureReason, failure)
+		requeueAfter = time.Second
+	case vm.Spec.PowerState == vmopv1.VirtualMachinePowerStateOff:
+		c.powerOff(req.NamespacedName, vm, metav1.ConditionTrue, poweredOffReason, "")
+	default:
+		if !poweredOn {
+			poweredOnAt = time.Now()
+			c.mu.Lock()
+			c.poweredOnAt[req.NamespacedName] = poweredOnAt
+			c.mu.Unlock()
+		}
+		vm.Status.PowerState = vmopv1.VirtualMachinePowerStateOn
+		vm.Status.Class = &vmopv1common.LocalObjectRef{Name: vm.Spec.ClassName}
+		if remaining := time.Until(poweredOnAt.Add(c.options.BootDelay)); remaining > 0 {
+			requeueAfter = remaining
+			break
+		}
+		c.mu.Lock()
+		ip, ok := c.ips[req.NamespacedName]
+		if !ok {
+			ip = c.nextIp(vmIPPattern)
+			c.ips[req.NamespacedName] = ip
+		}
+		c.mu.Unlock()
+		vm.Status.Network = &vmopv1.VirtualMachineNetworkStatus{PrimaryIP4: ip}
+		meta.SetStatusCondition(&vm.Status.Conditions, metav1.Condition{
+			Type:   vmopv1.ReadyConditionType,
+			Status: metav1.ConditionTrue,
+			Reason: poweredOnReason,
+		})
+	}
+
+	if err := c.client.Status().Patch(ctx, vm, patch); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
+}
+
+func (c *Controller) powerOff(key types.NamespacedName, vm *vmopv1.VirtualMachine,
+	ready metav1.ConditionStatus, reason, message string) {
+	c.mu.Lock()
+	delete(c.poweredOnAt, key)
+	c.mu.Unlock()
+
+	vm.Status.PowerState = vmopv1.VirtualMachinePowerStateOff
+	vm.Status.Network = nil
+	meta.SetStatusCondition(&vm.Status.Conditions, metav1.Condition{
+		Type:    vmopv1.ReadyConditionType,
+		Status:  ready,
+		Reason:  reason,
+		Message: message,
+	})
+}
+
+func (c *Controller) reconcileVmService(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	vmservice := &vmopv1.VirtualMachineService{}
+	if err := c.client.Get(ctx, req.NamespacedName, vmservice); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	if len(vmservice.Status.LoadBalancer.Ingress) > 0 || !vmservice.ObjectMeta.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+	if remaining := time.Until(vmservice.ObjectMeta.CreationTimestamp.Add(c.options.ServiceDelay)); remaining > 0 {
+		return reconcile.Result{RequeueAfter: remaining}, nil
+	}
+
+	c.mu.Lock()
+	ip := c.nextIp(serviceIPPattern)
+	c.mu.Unlock()
+
+	patch := client.MergeFrom(vmservice.DeepCopy())
+	vmservice.Status.LoadBalancer.Ingress = []vmopv1.LoadBalancerIngress{{IP: ip}}
+	return reconcile.Result{}, client.IgnoreNotFound(c.client.Status().Patch(ctx, vmservice, patch))
+}
@@ -0,0 +1,27 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package integration_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/test/builder"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/test/builder/vmoperator"
+)
+
+var suite = builder.NewTestSuiteWithFakeVmOperator(vmoperator.Options{
+	BootDelay:    time.Second,
+	ServiceDelay: time.Second,
+})
+
+var _ = BeforeSuite(suite.BeforeSuite)
+
+var _ = AfterSuite(suite.AfterSuite)
+
+func TestIntegration(t *testing.T) {
+	suite.Register(t, "Integration testcases against simulated vm-operator", nodeLifecycleTests)
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2024 VMware by Broadcom, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package integration_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	vmrayv1alpha1 "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/api/v1alpha1"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/internal/controller/lcm"
+	vmprovider "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider"
+	"gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/vmop"
+	tls_utils "gitlab.eng.vmware.com/xlabs/x77-taiga/vmray/vmray-cluster-operator/pkg/provider/vmop/tls"
+)
+
+const (
+	namespace   = "integration-namespace"
+	clustername = "integration-cluster"
+	timeout     = 30 * time.Second
+	interval    = 200 * time.Millisecond
+)
+
+func nodeLifecycleTests() {
+
+	Describe("Node lifecycle with vm-operator provider", func() {
+		ctx := context.Background()
+
+		// processUntil processes node's state till it reaches the expected VM status.
+		processUntil := func(nlcm *lcm.NodeLifecycleManager, req lcm.NodeLcmRequest, status vmrayv1alpha1.VMNodeStatus) {
+			Eventually(func() vmrayv1alpha1.VMNodeStatus {
+				// Errors are expected while VM service or VM isn't ready yet.
+				_ = nlcm.ProcessNodeVmState(ctx, req)
+				return req.NodeStatus.VmStatus
+			}).WithTimeout(timeout).WithPolling(interval).Should(Equal(status))
+		}
+
+		It("Brings up head & worker nodes, recovers from failures and resizes VMs", func() {
+			k8sClient := suite.GetK8sClient()
+			vmOperator := suite.GetFakeVmOperator()
+			nlcm := lcm.NewNodeLifecycleManager(vmop.NewVmOperatorProvider(k8sClient))
+
+			nsSpec := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
+			Expect(k8sClient.Create(ctx, nsSpec)).To(Succeed())
+			Expect(tls_utils.CreateVMRayClusterRootSecret(ctx, k8sClient, namespace, clustername)).To(Succeed())
+
+			headname := vmprovider.GetHeadNodeName(clustername, "")
+			headReq := lcm.NodeLcmRequest{
+				Namespace:   namespace,
+				Clustername: clustername,
+				Name:        headname,
+				NodeType:    "ray_head",
+				DockerImage: "rayproject/ray:2.9.0",
+				NodeConfig: vmrayv1alpha1.CommonNodeConfig{
+					VMUser:             "rayvm-user",
+					VMPasswordSaltHash: "rayvm-salthash",
+					VMImage:            "vmi-00001",
+					StorageClass:       "storage-default",
+					NodeTypes: map[string]vmrayv1alpha1.NodeType{
+						"ray_head": {VMClass: "best-effort-xlarge"},
+						"worker_1": {VMClass: "best-effort-xsmall"},
+					},
+				},
+				VMServiceStatus: &vmrayv1alpha1.VMServiceStatus{},
+				NodeStatus:      &vmrayv1alpha1.VMRayNodeStatus{},
+			}
+
+			// 1. Head node is deployed once its VM service gets an ingress IP, then gets its IP.
+			processUntil(nlcm, headReq, vmrayv1alpha1.RUNNING)
+			Expect(headReq.VMServiceStatus.Ip).ToNot(BeEmpty())
+			Expect(headReq.NodeStatus.Ip).To(Equal(vmOperator.GetVmIp(namespace, headname)))
+			Expect(headReq.NodeStatus.VMClass).To(Equal("best-effort-xlarge"))
+
+			// 2. Worker node is deployed & joins the head node.
+			workerReq := headReq
+			workerReq.Name = "worker-1"
+			workerReq.NodeType = "worker_1"
+			workerReq.HeadNodeStatus = headReq.NodeStatus
+			workerReq.NodeStatus = &vmrayv1alpha1.VMRayNodeStatus{}
+			processUntil(nlcm, workerReq, vmrayv1alpha1.RUNNING)
+			workerIp := workerReq.NodeStatus.Ip
+			Expect(workerIp).ToNot(BeEmpty())
+
+			// 3. Failed worker is detected & recovers with the same IP.
+			vmOperator.InjectFailure(namespace, "worker-1", "host crashed")
+			processUntil(nlcm, workerReq, vmrayv1alpha1.FAIL)
+			vmOperator.ClearFailure(namespace, "worker-1")
+			processUntil(nlcm, workerReq, vmrayv1alpha1.RUNNING)
+			Expect(workerReq.NodeStatus.Ip).To(Equal(workerIp))
+
+			// 4. Worker VM is resized in place.
+			workerReq.NodeConfig.NodeTypes = map[string]vmrayv1alpha1.NodeType{
+				"ray_head": {VMClass: "best-effort-xlarge"},
+				"worker_1": {VMClass: "best-effort-large"},
+			}
+			Eventually(func() (bool, error) {
+				return nlcm.ResizeNodeVm(ctx, workerReq)
+			}).WithTimeout(timeout).WithPolling(interval).Should(BeTrue())
+			workerReq.NodeStatus.VmStatus = vmrayv1alpha1.INITIALIZED
+			processUntil(nlcm, workerReq, vmrayv1alpha1.RUNNING)
+			Expect(workerReq.NodeStatus.VMClass).To(Equal("best-effort-large"))
+			Expect(workerReq.NodeStatus.ConfigHash).To(Equal(lcm.GetNodeConfigHash(workerReq)))
+
+			// 5. Delete VMs & auxiliary resources.
+			provider := vmop.NewVmOperatorProvider(k8sClient)
+			Expect(provider.Delete(ctx, namespace, "worker-1")).To(Succeed())
+			Expect(provider.Delete(ctx, namespace, headname)).To(Succeed())
+			Expect(provider.DeleteAuxiliaryResources(ctx, namespace, clustername)).To(Succeed())
+		})
+	})
+}